@@ -2,4 +2,6 @@ module calculadora-investimentos
 
 go 1.24.1
 
+require golang.org/x/net v0.47.0
+
 require github.com/jung-kurt/gofpdf v1.16.2 // indirect
@@ -26,6 +26,40 @@ type QuoteResponse struct {
 	} `json:"results"`
 }
 
+// HistoricoResponse é a estrutura de resposta da API BrAPI para o histórico de preços
+type HistoricoResponse struct {
+	Results []struct {
+		Symbol              string `json:"symbol"`
+		HistoricalDataPrice []struct {
+			Date  int64   `json:"date"`
+			Close float64 `json:"close"`
+		} `json:"historicalDataPrice"`
+	} `json:"results"`
+}
+
+// IndicadoresResponse é a estrutura de resposta da API BrAPI para os indicadores fundamentalistas
+type IndicadoresResponse struct {
+	Results []struct {
+		Symbol               string  `json:"symbol"`
+		PriceEarnings        float64 `json:"priceEarnings"`
+		DefaultKeyStatistics struct {
+			PriceToBook float64 `json:"priceToBook"`
+		} `json:"defaultKeyStatistics"`
+	} `json:"results"`
+}
+
+// Indicadores reúne os múltiplos de um ativo; zero indica indicador indisponível
+type Indicadores struct {
+	PL  float64
+	PVP float64
+}
+
+// PrecoHistorico representa o preço de fechamento de um ativo em uma data
+type PrecoHistorico struct {
+	Data       time.Time
+	Fechamento float64
+}
+
 // BrapiClient representa um cliente para a API BrAPI
 type BrapiClient struct {
 	BaseURL      string
@@ -103,6 +137,92 @@ func (c *BrapiClient) GetQuote(ticker string) (float64, error) {
 	return price, nil
 }
 
+// GetIndicadores obtém o P/L e o P/VP de um ativo (com cache)
+func (c *BrapiClient) GetIndicadores(ticker string) (Indicadores, error) {
+	cacheKey := fmt.Sprintf("indicadores_%s", ticker)
+	if cached, found := c.Cache.Get(cacheKey); found {
+		return cached.(Indicadores), nil
+	}
+
+	url := fmt.Sprintf("%s/quote/%s?token=%s&fundamental=true&modules=defaultKeyStatistics", c.BaseURL, ticker, c.Token)
+
+	resp, err := c.HTTPClient.Get(url)
+	if err != nil {
+		return Indicadores{}, fmt.Errorf("erro ao fazer requisição: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Indicadores{}, fmt.Errorf("API retornou status %d", resp.StatusCode)
+	}
+
+	var data IndicadoresResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return Indicadores{}, fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+
+	if len(data.Results) == 0 {
+		return Indicadores{}, fmt.Errorf("nenhum resultado encontrado para o ticker: %s", ticker)
+	}
+
+	indicadores := Indicadores{
+		PL:  data.Results[0].PriceEarnings,
+		PVP: data.Results[0].DefaultKeyStatistics.PriceToBook,
+	}
+
+	// Os múltiplos mudam pouco ao longo do dia, então podem ficar mais tempo em cache
+	c.Cache.Set(cacheKey, indicadores, 24*time.Hour)
+
+	return indicadores, nil
+}
+
+// GetHistoricoMensal obtém o histórico de fechamentos mensais de um ativo (com cache)
+func (c *BrapiClient) GetHistoricoMensal(ticker string, periodo string) ([]PrecoHistorico, error) {
+	cacheKey := fmt.Sprintf("historico_%s_%s", ticker, periodo)
+	if cached, found := c.Cache.Get(cacheKey); found {
+		log.Printf("Cache HIT para histórico de %s", ticker)
+		return cached.([]PrecoHistorico), nil
+	}
+
+	url := fmt.Sprintf("%s/quote/%s?token=%s&range=%s&interval=1mo", c.BaseURL, ticker, c.Token, periodo)
+
+	resp, err := c.HTTPClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer requisição: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API retornou status %d", resp.StatusCode)
+	}
+
+	var data HistoricoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+
+	if len(data.Results) == 0 {
+		return nil, fmt.Errorf("nenhum resultado encontrado para o ticker: %s", ticker)
+	}
+
+	var historico []PrecoHistorico
+	for _, ponto := range data.Results[0].HistoricalDataPrice {
+		if ponto.Close <= 0 {
+			continue
+		}
+		historico = append(historico, PrecoHistorico{
+			Data:       time.Unix(ponto.Date, 0),
+			Fechamento: ponto.Close,
+		})
+	}
+
+	// Histórico muda pouco ao longo do dia, então pode ficar mais tempo em cache
+	c.Cache.Set(cacheKey, historico, 24*time.Hour)
+	log.Printf("Histórico de %s armazenado em cache (%d pontos)", ticker, len(historico))
+
+	return historico, nil
+}
+
 // GetQuotesBatch obtém cotações para múltiplos ativos (com cache)
 func (c *BrapiClient) GetQuotesBatch(tickers []string) (map[string]float64, error) {
 	result := make(map[string]float64)
@@ -0,0 +1,237 @@
+package b3
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Instância global do calendário
+var (
+	instance     *Calendario
+	instanceOnce sync.Once
+)
+
+// Calendario representa o calendário de pregões da B3
+type Calendario struct {
+	diretorio string
+	feriados  map[string]string // Data (AAAA-MM-DD) -> descrição
+	anos      map[int]bool      // Anos já carregados (de arquivo ou por regra)
+	mu        sync.RWMutex
+}
+
+// GetInstance retorna a instância única do calendário. O diretório de feriados é fixado na primeira
+// chamada; chamadas seguintes com outro diretório recebem a mesma instância (e geram um aviso no log).
+// Para um calendário com outro diretório, use NovoCalendario.
+func GetInstance(diretorio string) *Calendario {
+	instanceOnce.Do(func() {
+		instance = NovoCalendario(diretorio)
+	})
+	if diretorio != instance.diretorio {
+		log.Printf("Calendário da B3 já inicializado com %s; ignorando o diretório %s", instance.diretorio, diretorio)
+	}
+	return instance
+}
+
+// NovoCalendario cria um calendário que carrega os feriados sob demanda, ano a ano, do diretório
+// informado. Anos sem arquivo usam os feriados calculados pelas regras conhecidas.
+func NovoCalendario(diretorio string) *Calendario {
+	return &Calendario{
+		diretorio: diretorio,
+		feriados:  make(map[string]string),
+		anos:      make(map[int]bool),
+	}
+}
+
+// EhDiaDePregao verifica se há pregão na data (dia útil que não é feriado da B3)
+func (c *Calendario) EhDiaDePregao(data time.Time) bool {
+	if data.Weekday() == time.Saturday || data.Weekday() == time.Sunday {
+		return false
+	}
+	_, feriado := c.Feriado(data)
+	return !feriado
+}
+
+// Feriado retorna a descrição do feriado na data, se houver
+func (c *Calendario) Feriado(data time.Time) (string, bool) {
+	c.carregarAno(data.Year())
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	descricao, existe := c.feriados[data.Format("2006-01-02")]
+	return descricao, existe
+}
+
+// ProximoDiaDePregao retorna o primeiro dia de pregão estritamente depois da data
+func (c *Calendario) ProximoDiaDePregao(data time.Time) time.Time {
+	return c.DiaDePregaoOuProximo(data.AddDate(0, 0, 1))
+}
+
+// DiaDePregaoAnterior retorna o último dia de pregão estritamente antes da data
+func (c *Calendario) DiaDePregaoAnterior(data time.Time) time.Time {
+	return c.DiaDePregaoOuAnterior(data.AddDate(0, 0, -1))
+}
+
+// DiaDePregaoOuProximo retorna a própria data, se houver pregão, ou o próximo dia de pregão
+func (c *Calendario) DiaDePregaoOuProximo(data time.Time) time.Time {
+	for !c.EhDiaDePregao(data) {
+		data = data.AddDate(0, 0, 1)
+	}
+	return data
+}
+
+// DiaDePregaoOuAnterior retorna a própria data, se houver pregão, ou o dia de pregão anterior
+func (c *Calendario) DiaDePregaoOuAnterior(data time.Time) time.Time {
+	for !c.EhDiaDePregao(data) {
+		data = data.AddDate(0, 0, -1)
+	}
+	return data
+}
+
+// DiasDePregaoEntre conta os dias de pregão no intervalo (inicio, fim]. O resultado é negativo
+// quando fim é anterior a inicio e zero quando as duas datas caem no mesmo dia.
+func (c *Calendario) DiasDePregaoEntre(inicio, fim time.Time) int {
+	inicio = truncarDia(inicio)
+	fim = truncarDia(fim)
+
+	sinal := 1
+	if fim.Before(inicio) {
+		inicio, fim = fim, inicio
+		sinal = -1
+	}
+
+	dias := 0
+	for data := inicio.AddDate(0, 0, 1); !data.After(fim); data = data.AddDate(0, 0, 1) {
+		if c.EhDiaDePregao(data) {
+			dias++
+		}
+	}
+	return dias * sinal
+}
+
+// carregarAno carrega os feriados de um ano, do arquivo ou pelas regras, se ainda não carregado
+func (c *Calendario) carregarAno(ano int) {
+	c.mu.RLock()
+	carregado := c.anos[ano]
+	c.mu.RUnlock()
+	if carregado {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.anos[ano] {
+		return
+	}
+
+	feriados, err := lerArquivoFeriados(filepath.Join(c.diretorio, strconv.Itoa(ano)+".txt"))
+	if err != nil {
+		log.Printf("Feriados da B3 para %d indisponíveis (%v). Usando feriados calculados", ano, err)
+		feriados = feriadosPorRegra(ano)
+	}
+
+	for data, descricao := range feriados {
+		c.feriados[data] = descricao
+	}
+	c.anos[ano] = true
+}
+
+// lerArquivoFeriados lê um arquivo com uma data (DD/MM/AAAA) e uma descrição por linha,
+// separadas por tabulação. Linhas vazias e iniciadas por # são ignoradas.
+func lerArquivoFeriados(caminho string) (map[string]string, error) {
+	file, err := os.Open(caminho)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	feriados := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	numeroLinha := 0
+
+	for scanner.Scan() {
+		numeroLinha++
+		linha := strings.TrimSpace(scanner.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+
+		campos := strings.SplitN(linha, "\t", 2)
+		data, err := time.Parse("02/01/2006", strings.TrimSpace(campos[0]))
+		if err != nil {
+			return nil, fmt.Errorf("data inválida na linha %d de %s: %w", numeroLinha, caminho, err)
+		}
+
+		descricao := ""
+		if len(campos) == 2 {
+			descricao = strings.TrimSpace(campos[1])
+		}
+		feriados[data.Format("2006-01-02")] = descricao
+	}
+
+	return feriados, scanner.Err()
+}
+
+// feriadosPorRegra calcula os feriados nacionais e os fechamentos da B3 de um ano
+func feriadosPorRegra(ano int) map[string]string {
+	data := func(mes time.Month, dia int) time.Time {
+		return time.Date(ano, mes, dia, 0, 0, 0, 0, time.UTC)
+	}
+	pascoa := CalcularPascoa(ano)
+
+	datas := map[time.Time]string{
+		data(time.January, 1):     "Confraternização Universal",
+		pascoa.AddDate(0, 0, -48): "Carnaval",
+		pascoa.AddDate(0, 0, -47): "Carnaval",
+		pascoa.AddDate(0, 0, -2):  "Paixão de Cristo",
+		data(time.April, 21):      "Tiradentes",
+		data(time.May, 1):         "Dia do Trabalho",
+		pascoa.AddDate(0, 0, 60):  "Corpus Christi",
+		data(time.September, 7):   "Independência do Brasil",
+		data(time.October, 12):    "Nossa Senhora Aparecida",
+		data(time.November, 2):    "Finados",
+		data(time.November, 15):   "Proclamação da República",
+		data(time.November, 20):   "Dia Nacional de Zumbi e da Consciência Negra",
+		data(time.December, 24):   "Véspera de Natal",
+		data(time.December, 25):   "Natal",
+		data(time.December, 31):   "Último dia do ano",
+	}
+
+	feriados := make(map[string]string, len(datas))
+	for dia, descricao := range datas {
+		feriados[dia.Format("2006-01-02")] = descricao
+	}
+	return feriados
+}
+
+// CalcularPascoa calcula a data da Páscoa para um determinado ano
+func CalcularPascoa(ano int) time.Time {
+	// Algoritmo de Gauss para calcular a Páscoa
+	a := ano % 19
+	b := ano / 100
+	c := ano % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	mes := (h + l - 7*m + 114) / 31
+	dia := ((h + l - 7*m + 114) % 31) + 1
+
+	return time.Date(ano, time.Month(mes), dia, 0, 0, 0, 0, time.UTC)
+}
+
+// truncarDia remove o horário da data, mantendo o fuso
+func truncarDia(data time.Time) time.Time {
+	return time.Date(data.Year(), data.Month(), data.Day(), 0, 0, 0, 0, data.Location())
+}
@@ -2,12 +2,31 @@ package config
 
 import "time"
 
+// PremissaClasse representa as premissas de longo prazo de uma classe de ativos (% ao ano)
+type PremissaClasse struct {
+	CrescimentoPreco      float64 // Valorização anual esperada do preço
+	CrescimentoDividendos float64 // Crescimento anual esperado dos dividendos por cota
+	DividendYield         float64 // Yield usado quando a carteira não tem histórico de proventos
+	Volatilidade          float64 // Desvio padrão anual dos retornos de preço
+}
+
+// RegraDataCom define, para uma classe de ativos, as janelas (em pregões antes da data com)
+// de cada status de compra e os parâmetros da perda esperada ao comprar antes da data com
+type RegraDataCom struct {
+	DiasNaoComprar int     // NAO_COMPRAR até este número de pregões (0 = apenas no dia da data com)
+	DiasEvitar     int     // EVITAR até este número de pregões
+	DiasAlerta     int     // ALERTA até este número de pregões
+	QuedaExData    float64 // Queda típica do preço na data ex, em fração do provento bruto (1 = valor integral)
+	PerdaToleravel float64 // Perda esperada total (R$) abaixo da qual a compra é liberada dentro da janela
+}
+
 // Config representa a configuração da aplicação
 type Config struct {
 	Port              int
 	APIToken          string
 	APIBaseURL        string
 	DataDir           string
+	DiretorioFeriados string // Arquivos de feriados da B3, um por ano (AAAA.txt)
 	TemplatesDir      string
 	StaticDir         string
 	DefaultTimeout    int
@@ -15,26 +34,203 @@ type Config struct {
 	DistribuicaoIdeal map[string]float64
 	CacheDuracao      time.Duration
 	CacheLimpeza      time.Duration
+	// Classes internacionais e de criptoativos
+	TickersCripto []string // ETFs de criptoativos, separados da classe de ETFs
+	// Exposição cambial
+	MoedaPorTicker           map[string]string // Ticker -> exposição ("USD" ou "OURO"); ausentes seguem a moeda da classe
+	MoedaPorClasse           map[string]string // Classe -> exposição padrão; classes ausentes ficam em reais
+	MetaExposicaoEstrangeira float64           // % da carteira exposta a dólar e ouro buscado pela distribuição (0 = sem meta)
+	// Exposição econômica por emissor
+	EmissorPorTicker          map[string]string // Ticker ou raiz de 4 letras -> empresa emissora (ausentes usam a raiz do ticker)
+	LimiteConcentracaoEmissor float64           // % máximo da classe de ações por emissor após o aporte (0 = sem limite)
+	// Classificação setorial das ações (data/setores_acoes.txt)
+	LimiteSetorAcoes    float64            // % máximo da classe de ações por setor após o aporte (0 = sem limite)
+	LimitePorSetorAcoes map[string]float64 // Setor -> % máximo, substituindo o limite padrão
+	// Preço-teto das ações
+	MetodosPrecoTeto      []string // "bazin" e/ou "graham"; vazio desativa. Com ambos, vale o menor preço-teto
+	TaxaMinimaBazin       float64  // Dividend yield mínimo (%) exigido pelo método de Bazin
+	PoliticaPrecoTeto     string   // "pular" (não compra acima do preço-teto) ou "reduzir"
+	ReducaoAcimaPrecoTeto float64  // % da compra cortado pela política "reduzir"
+	// Inclinação dos pesos dos FIIs pelo P/VP relativo à mediana do segmento
+	InclinacaoPVPFII       float64 // % de variação do peso por 1% de desvio do P/VP em relação à mediana (0 desativa)
+	LimiteInclinacaoPVPFII float64 // Variação máxima (%) do peso ideal de cada FII
+	// Histórico de proventos
+	CacheDuracaoProventos time.Duration
+	EstimadorProventos    string // ultimo, media12m, mediana ou ponderado
+	// Tributação de proventos (alíquotas em %)
+	AliquotaIRJCP            float64
+	AliquotaIRDividendos     float64
+	AliquotaIRRendimentosFII float64         // Aplicada apenas a FIIs sem isenção
+	IsencaoIRFIIPadrao       bool            // Se os FIIs são considerados isentos por padrão
+	IsencaoIRFIIPorTicker    map[string]bool // Exceções por ticker (true = isento, false = tributado)
+	// Análise de data com
+	WorkersDataCom              int                     // Análises de data com executadas em paralelo
+	IntervaloRequisicoesPorHost time.Duration           // Intervalo mínimo entre requisições ao mesmo host
+	CacheDuracaoErroDataCom     time.Duration           // Por quanto tempo uma análise que falhou não é refeita
+	RegrasDataCom               map[string]RegraDataCom // Por tipo de ativo (FII, ACAO)
+	UsarPerdaEsperadaDataCom    bool                    // Considera o valor em jogo, e não só os pregões, no status de compra
+	// Renda fixa (premissas em % ao ano)
+	TaxaCDIAnual   float64
+	TaxaSelicAnual float64
+	TaxaIPCAAnual  float64
+	TiposIsentosIR []string // Tipos de título isentos de IR para pessoa física
+	// Percentual mínimo da carteira de renda fixa (atual mais aportes) em títulos com liquidez diária
+	PercentualMinimoLiquidezDiaria float64
+	// Reserva mínima (R$) em títulos de liquidez diária antes de aplicar em produtos sem liquidez (0 desativa)
+	ReservaMinimaLiquidezDiaria float64
+	// Reserva de emergência, completada antes de investir nas classes (meta zero desativa)
+	ReservaEmergenciaValor float64 // Meta fixa em R$ (tem precedência sobre os meses de despesas)
+	ReservaEmergenciaMeses float64 // Meses de despesas usados quando não há meta fixa
+	DespesaMensal          float64 // Despesa mensal padrão quando não informada no formulário
+	// Garantia do FGC (valores em R$)
+	LimiteFGCPorConglomerado float64           // Garantia por CPF em cada conglomerado financeiro
+	LimiteFGCGlobal          float64           // Teto global de garantias a cada 4 anos
+	TiposCobertosFGC         []string          // Tipos de título cobertos pelo FGC
+	ConglomeradoPorEmissor   map[string]string // Emissor -> conglomerado financeiro (emissores ausentes formam o próprio conglomerado)
+	// Calendário de proventos
+	MesesCalendarioPadrao    int
+	MesesCalendarioMaximo    int
+	PrazoPagamentoPadraoFII  int // Dias entre data com e pagamento quando não há histórico
+	PrazoPagamentoPadraoAcao int
+	// Planejamento de aportes mensais
+	MesesPlanejamentoPadrao int
+	MesesPlanejamentoMaximo int
+	ToleranciaMetaClasse    float64 // Pontos percentuais de tolerância para considerar a classe na meta
+	// Projeção de patrimônio de longo prazo
+	AnosProjecaoPadrao int
+	AnosProjecaoMaximo int
+	PremissasProjecao  map[string]PremissaClasse
+	// Simulação de Monte Carlo
+	SimulacoesMonteCarlo       int
+	SementeMonteCarlo          int64
+	PeriodoHistoricoMonteCarlo string // Período do histórico de preços usado no bootstrap (ex: "5y")
+	MinimoRetornosHistoricos   int    // Quantidade mínima de retornos mensais para usar o bootstrap
 }
 
 // Load carrega a configuração da aplicação
 func Load() *Config {
 	return &Config{
-		Port:           5000,
-		APIToken:       "dGubyGPMakfrACS1qoSTye",
-		APIBaseURL:     "https://brapi.dev/api",
-		DataDir:        "./data",
-		TemplatesDir:   "./templates",
-		StaticDir:      "./static",
-		DefaultTimeout: 10, // segundos
-		IDInvestidor10: "1399345",
+		Port:              5000,
+		APIToken:          "dGubyGPMakfrACS1qoSTye",
+		APIBaseURL:        "https://brapi.dev/api",
+		DataDir:           "./data",
+		DiretorioFeriados: "./data/feriados_b3",
+		TemplatesDir:      "./templates",
+		StaticDir:         "./static",
+		DefaultTimeout:    10, // segundos
+		IDInvestidor10:    "1399345",
+		// Soma 100%; as classes não selecionadas têm o percentual redistribuído entre as selecionadas
+		// (FIIs, ações, ETFs e renda fixa, a seleção padrão, ficam com 30%, 30%, 20% e 20%)
 		DistribuicaoIdeal: map[string]float64{
-			"FIIs":      30.0,
-			"Ações":     30.0,
-			"ETFs":      20.0,
-			"RendaFixa": 20.0,
+			"FIIs":      24.0,
+			"Ações":     24.0,
+			"ETFs":      16.0,
+			"RendaFixa": 16.0,
+			"BDRs":      12.0, // Usadas apenas quando selecionadas no formulário
+			"Cripto":    8.0,
+		},
+		MoedaPorTicker: map[string]string{
+			"WRLD11": "USD",
+			"GPUS11": "USD",
+			"BNDX11": "USD",
+			"USDB11": "USD",
+			"ALUG11": "USD",
+			"IVVB11": "USD",
+			"NASD11": "USD",
+			"SPXI11": "USD",
+			"GOLD11": "OURO",
+		},
+		MoedaPorClasse: map[string]string{
+			"BDRs":   "USD",
+			"Cripto": "USD", // Criptoativos são negociados em dólar no exterior
+		},
+		MetaExposicaoEstrangeira: 0,
+		EmissorPorTicker: map[string]string{
+			"TAEE": "Taesa",
+			"ITSA": "Itaúsa",
+			"ITUB": "Itaú Unibanco",
+			"BBDC": "Bradesco",
+			"BBAS": "Banco do Brasil",
+			"SANB": "Santander Brasil",
+			"BPAC": "BTG Pactual",
+			"PETR": "Petrobras",
+			"VALE": "Vale",
+			"SAPR": "Sanepar",
+			"KLBN": "Klabin",
+			"CMIG": "Cemig",
+			"ELET": "Eletrobras",
+			"TRPL": "ISA Energia", // Antigo ticker da ISA CTEEP
+			"ISAE": "ISA Energia",
+			"ALUP": "Alupar",
+			"ENGI": "Energisa",
+		},
+		LimiteConcentracaoEmissor:   0,
+		LimiteSetorAcoes:            0,
+		LimitePorSetorAcoes:         map[string]float64{},
+		MetodosPrecoTeto:            []string{},
+		TaxaMinimaBazin:             6.0,
+		PoliticaPrecoTeto:           "reduzir",
+		ReducaoAcimaPrecoTeto:       50,
+		InclinacaoPVPFII:            0,
+		LimiteInclinacaoPVPFII:      30,
+		TickersCripto:               []string{"HASH11", "QBTC11", "QETH11", "BITH11", "ETHE11", "DEFI11"},
+		CacheDuracao:                30 * time.Minute, // Duração do cache (30 minutos)
+		CacheLimpeza:                10 * time.Minute, // Intervalo de limpeza (10 minutos)
+		CacheDuracaoProventos:       12 * time.Hour,   // Proventos mudam pouco ao longo do dia
+		EstimadorProventos:          "media12m",
+		AliquotaIRJCP:               15.0,
+		AliquotaIRDividendos:        0.0,
+		AliquotaIRRendimentosFII:    20.0,
+		IsencaoIRFIIPadrao:          true,
+		IsencaoIRFIIPorTicker:       map[string]bool{},
+		WorkersDataCom:              4,
+		IntervaloRequisicoesPorHost: 500 * time.Millisecond,
+		CacheDuracaoErroDataCom:     5 * time.Minute,
+		RegrasDataCom: map[string]RegraDataCom{
+			"FII":  {DiasNaoComprar: 0, DiasEvitar: 2, DiasAlerta: 5, QuedaExData: 1.0, PerdaToleravel: 5.0},
+			"ACAO": {DiasNaoComprar: 0, DiasEvitar: 2, DiasAlerta: 5, QuedaExData: 1.0, PerdaToleravel: 5.0},
+		},
+		UsarPerdaEsperadaDataCom:       false,
+		TaxaCDIAnual:                   14.90,
+		TaxaSelicAnual:                 15.00,
+		TaxaIPCAAnual:                  4.50,
+		TiposIsentosIR:                 []string{"LCI", "LCA", "CRI", "CRA", "DEBENTURE INCENTIVADA"},
+		PercentualMinimoLiquidezDiaria: 20.0,
+		ReservaMinimaLiquidezDiaria:    0,
+		ReservaEmergenciaValor:         0,
+		ReservaEmergenciaMeses:         6,
+		DespesaMensal:                  0,
+		LimiteFGCPorConglomerado:       250000.0,
+		LimiteFGCGlobal:                1000000.0,
+		TiposCobertosFGC:               []string{"CDB", "RDB", "LC", "LCI", "LCA", "LIG", "LH", "Poupança"},
+		ConglomeradoPorEmissor: map[string]string{
+			"Banco Inter":       "Inter",
+			"Inter DTVM":        "Inter",
+			"BTG Pactual":       "BTG Pactual",
+			"Banco BTG Pactual": "BTG Pactual",
+			"XP Investimentos":  "XP",
+			"Banco XP":          "XP",
+		},
+		MesesCalendarioPadrao:    12,
+		MesesCalendarioMaximo:    24,
+		PrazoPagamentoPadraoFII:  10,
+		PrazoPagamentoPadraoAcao: 30,
+		MesesPlanejamentoPadrao:  12,
+		MesesPlanejamentoMaximo:  60,
+		ToleranciaMetaClasse:     1.0,
+		AnosProjecaoPadrao:       10,
+		AnosProjecaoMaximo:       50,
+		PremissasProjecao: map[string]PremissaClasse{
+			"FIIs":      {CrescimentoPreco: 2.0, CrescimentoDividendos: 3.0, DividendYield: 10.0, Volatilidade: 12.0},
+			"Ações":     {CrescimentoPreco: 5.0, CrescimentoDividendos: 6.0, DividendYield: 7.0, Volatilidade: 25.0},
+			"ETFs":      {CrescimentoPreco: 7.0, CrescimentoDividendos: 0.0, DividendYield: 0.0, Volatilidade: 18.0},
+			"RendaFixa": {CrescimentoPreco: 10.0, CrescimentoDividendos: 0.0, DividendYield: 0.0, Volatilidade: 1.0},
+			"BDRs":      {CrescimentoPreco: 7.0, CrescimentoDividendos: 0.0, DividendYield: 0.0, Volatilidade: 22.0},
+			"Cripto":    {CrescimentoPreco: 10.0, CrescimentoDividendos: 0.0, DividendYield: 0.0, Volatilidade: 60.0},
 		},
-		CacheDuracao: 30 * time.Minute, // Duração do cache (30 minutos)
-		CacheLimpeza: 10 * time.Minute, // Intervalo de limpeza (10 minutos)
+		SimulacoesMonteCarlo:       1000,
+		SementeMonteCarlo:          42,
+		PeriodoHistoricoMonteCarlo: "5y",
+		MinimoRetornosHistoricos:   24,
 	}
 }
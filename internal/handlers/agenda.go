@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"calculadora-investimentos/internal/services"
+	"log"
+	"net/http"
+)
+
+// AgendaICSHandler manipula requisições para o feed iCalendar das datas com e pagamentos
+// dos ativos em carteira e recomendados
+func AgendaICSHandler(w http.ResponseWriter, r *http.Request) {
+	handlers := NewHandlers()
+
+	if r.Method != "GET" {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var ativos []services.AtivoAgenda
+
+	if carteiraFII, err := handlers.DataService.ObterCarteiraAtualFII(); err != nil {
+		log.Println("Erro ao obter carteira atual de FIIs:", err)
+	} else {
+		for _, ativo := range carteiraFII.Data {
+			ativos = append(ativos, services.AtivoAgenda{Ticker: ativo.TickerName, TipoAtivo: "FII", Origem: "Carteira"})
+		}
+	}
+
+	if carteiraAcao, err := handlers.DataService.ObterCarteiraAtualAcao(); err != nil {
+		log.Println("Erro ao obter carteira atual de ações:", err)
+	} else {
+		for _, ativo := range carteiraAcao.Data {
+			ativos = append(ativos, services.AtivoAgenda{Ticker: ativo.TickerName, TipoAtivo: "ACAO", Origem: "Carteira"})
+		}
+	}
+
+	if recomendadosFII, err := handlers.DataService.CarregarRecomendadosFII(); err != nil {
+		log.Println("Erro ao carregar recomendações de FIIs:", err)
+	} else {
+		for _, fii := range recomendadosFII {
+			ativos = append(ativos, services.AtivoAgenda{Ticker: fii.Ticker, TipoAtivo: "FII", Origem: "Recomendado"})
+		}
+	}
+
+	if recomendadosAcao, err := handlers.DataService.CarregarRecomendadosAcao(); err != nil {
+		log.Println("Erro ao carregar recomendações de ações:", err)
+	} else {
+		for _, acao := range recomendadosAcao {
+			ativos = append(ativos, services.AtivoAgenda{Ticker: acao.Ticker, TipoAtivo: "ACAO", Origem: "Recomendado"})
+		}
+	}
+
+	ics := handlers.AgendaICSService.GerarICS(ativos, handlers.Relogio.Agora())
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="datas-com.ics"`)
+	w.Write([]byte(ics))
+}
@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"calculadora-investimentos/internal/services"
 	"calculadora-investimentos/internal/utils"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -14,9 +17,6 @@ func CalcularHandler(w http.ResponseWriter, r *http.Request) {
 	// Definir o tipo de conteúdo como JSON
 	w.Header().Set("Content-Type", "application/json")
 
-	// Criar uma nova instância de Handlers
-	handlers := NewHandlers()
-
 	// Verificar se é uma requisição POST
 	if r.Method != "POST" {
 		json.NewEncoder(w).Encode(models.RespostaCalculadora{
@@ -102,130 +102,32 @@ func CalcularHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verificar se há distribuição personalizada
-	distribuicaoPersonalizada := r.FormValue("distribuicaoPersonalizada") == "true"
-	var tiposInvestimento models.TiposInvestimento
-	tiposInvestimento.FIIs = true
-	tiposInvestimento.Acoes = true
-	tiposInvestimento.ETFs = true
-	tiposInvestimento.RendaFixa = true
-
-	if distribuicaoPersonalizada {
-		tiposSelecionadosJSON := r.FormValue("tiposInvestimento")
-		if tiposSelecionadosJSON != "" {
-			var tiposSelecionados []string
-			err := json.Unmarshal([]byte(tiposSelecionadosJSON), &tiposSelecionados)
-			if err != nil {
-				log.Println("Erro ao processar tipos de investimento selecionados:", err)
-			} else {
-				// Resetar todos os tipos para false
-				tiposInvestimento.FIIs = false
-				tiposInvestimento.Acoes = false
-				tiposInvestimento.ETFs = false
-				tiposInvestimento.RendaFixa = false
-
-				// Marcar apenas os tipos selecionados
-				for _, tipo := range tiposSelecionados {
-					switch tipo {
-					case "FIIs":
-						tiposInvestimento.FIIs = true
-					case "Ações":
-						tiposInvestimento.Acoes = true
-					case "ETFs":
-						tiposInvestimento.ETFs = true
-					case "RendaFixa":
-						tiposInvestimento.RendaFixa = true
-					}
-				}
-			}
-		}
-	}
-
-	// Carregar dados
-	recomendadosFII, err := handlers.DataService.CarregarRecomendadosFII()
-	if err != nil {
-		log.Println("Erro ao carregar recomendações de FIIs:", err)
-		json.NewEncoder(w).Encode(models.RespostaCalculadora{
-			Status:  "error",
-			Message: "Erro ao carregar recomendações de FIIs: " + err.Error(),
-		})
-		return
-	}
+	// Obter os tipos de investimento selecionados
+	tiposInvestimento := lerTiposInvestimento(r)
 
-	recomendadosAcao, err := handlers.DataService.CarregarRecomendadosAcao()
+	// Executar todo o cálculo na data de referência informada (ou hoje)
+	rel, err := lerDataReferencia(r)
 	if err != nil {
-		log.Println("Erro ao carregar recomendações de ações:", err)
-		json.NewEncoder(w).Encode(models.RespostaCalculadora{
-			Status:  "error",
-			Message: "Erro ao carregar recomendações de ações: " + err.Error(),
-		})
+		responderErro(w, err.Error())
 		return
 	}
 
-	recomendadosETF, err := handlers.DataService.CarregarRecomendadosETF()
-	if err != nil {
-		log.Println("Erro ao carregar recomendações de ETFs:", err)
-		// Usar dados padrão mínimos
-		recomendadosETF = []models.ETFRecomendado{
-			{Ticker: "WRLD11", Nome: "ETF BDRs Mundo", PesoIdeal: 100, Preco: 123.68},
-		}
-	}
+	// Criar uma nova instância de Handlers com o relógio da requisição
+	handlers := NewHandlersComRelogio(rel)
 
-	// Carregar carteiras
-	carteiraFII, err := handlers.DataService.ObterCarteiraAtualFII()
+	// Carregar recomendações e carteiras
+	entradas, err := handlers.carregarEntradasCalculo()
 	if err != nil {
-		log.Println("Erro ao obter carteira atual de FIIs:", err)
-		json.NewEncoder(w).Encode(models.RespostaCalculadora{
-			Status:  "error",
-			Message: "Erro ao obter carteira atual de FIIs: " + err.Error(),
-		})
+		responderErro(w, err.Error())
 		return
 	}
 
-	carteiraAcao, err := handlers.DataService.ObterCarteiraAtualAcao()
-	if err != nil {
-		log.Println("Erro ao obter carteira atual de ações:", err)
-		// Cria uma carteira vazia em caso de erro
-		carteiraAcao = &models.CarteiraAcoes{
-			Total: 0,
-			Data:  []models.AtivoAcao{},
-			Draw:  1,
-		}
-	}
-
-	carteiraETF, err := handlers.DataService.ObterCarteiraAtualETF()
-	if err != nil {
-		log.Println("Erro ao obter carteira atual de ETFs:", err)
-		// Cria uma carteira vazia em caso de erro
-		carteiraETF = &models.CarteiraETFs{
-			Total: 0,
-			Data:  []models.AtivoETF{},
-			Draw:  1,
-		}
-	}
-
-	carteiraRendaFixa, err := handlers.DataService.ObterCarteiraAtualRendaFixa()
-	if err != nil {
-		log.Println("Erro ao obter carteira atual de renda fixa:", err)
-		// Cria uma carteira vazia em caso de erro
-		carteiraRendaFixa = &models.CarteiraRendaFixa{
-			Total: 0,
-			Data:  []models.AtivoRendaFixa{},
-			Draw:  1,
-		}
-	}
-
 	// Calcular recomendações
 	dados, err := handlers.CalculadoraService.CalcularRecomendacoes(
 		valorInvestimento,
 		tiposInvestimento,
-		carteiraFII,
-		carteiraAcao,
-		carteiraETF,
-		carteiraRendaFixa,
-		recomendadosFII,
-		recomendadosAcao,
-		recomendadosETF,
+		&entradas.EntradasCalculo,
+		lerMetaReservaEmergencia(r, handlers.DistribuidoraService),
 	)
 	if err != nil {
 		log.Println("Erro ao calcular recomendações:", err)
@@ -236,6 +138,42 @@ func CalcularHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Avaliar os títulos de renda fixa até o vencimento
+	dados.RendaFixa = handlers.RendaFixaService.AvaliarCarteira(entradas.CarteiraRendaFixa)
+
+	// Dividir o valor destinado à renda fixa entre os títulos recomendados
+	dados.RecomendacoesRendaFixa, dados.ValorNaoAlocadoRendaFixa = handlers.RendaFixaService.RecomendarAplicacoes(
+		dados.ValoresRecomendados[services.NomeRendaFixa],
+		entradas.CarteiraRendaFixa,
+		entradas.RecomendadosRendaFixa,
+	)
+
+	// Completar a reserva de emergência com títulos de liquidez diária
+	if reserva := dados.ReservaEmergencia; reserva != nil && reserva.Aporte > 0 {
+		reserva.Recomendacoes, reserva.ValorNaoAlocado = handlers.RendaFixaService.RecomendarReserva(
+			reserva.Aporte,
+			entradas.CarteiraRendaFixa,
+			entradas.RecomendadosRendaFixa,
+		)
+	}
+
+	// Projetar a evolução de longo prazo da carteira final
+	parametrosProjecao := lerParametrosProjecao(r)
+	dados.Projecao = handlers.ProjetoraService.ProjetarPatrimonio(dados, parametrosProjecao)
+
+	// Simular cenários de Monte Carlo com a semente informada (ou a padrão)
+	semente := handlers.Config.SementeMonteCarlo
+	if sementeStr := r.FormValue("sementeMonteCarlo"); sementeStr != "" {
+		if valor, err := strconv.ParseInt(sementeStr, 10, 64); err == nil {
+			semente = valor
+		}
+	}
+	dados.MonteCarlo = handlers.MonteCarloService.Simular(dados, parametrosProjecao, semente)
+
+	if relogio.Retroativo(rel) {
+		dados.DataReferencia = rel.Agora().Format("02/01/2006")
+	}
+
 	// Renderizar o template
 	html, err := handlers.RenderizarTemplateParaString("resultado.html", dados)
 	if err != nil {
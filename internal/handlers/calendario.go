@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"calculadora-investimentos/internal/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// CalendarioHandler manipula requisições para o calendário de proventos da carteira.
+// Com formato=json, retorna o calendário completo em JSON em vez do HTML renderizado.
+func CalendarioHandler(w http.ResponseWriter, r *http.Request) {
+	// Definir o tipo de conteúdo como JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	handlers := NewHandlers()
+
+	if r.Method != "GET" {
+		responderErro(w, "Método não permitido")
+		return
+	}
+
+	meses := handlers.Config.MesesCalendarioPadrao
+	if mesesStr := r.URL.Query().Get("meses"); mesesStr != "" {
+		valor, err := strconv.Atoi(mesesStr)
+		if err != nil {
+			responderErro(w, "Quantidade de meses inválida")
+			return
+		}
+		meses = valor
+	}
+
+	carteiraFII, err := handlers.DataService.ObterCarteiraAtualFII()
+	if err != nil {
+		log.Println("Erro ao obter carteira atual de FIIs:", err)
+		responderErro(w, "Erro ao obter carteira atual de FIIs: "+err.Error())
+		return
+	}
+
+	carteiraAcao, err := handlers.DataService.ObterCarteiraAtualAcao()
+	if err != nil {
+		log.Println("Erro ao obter carteira atual de ações:", err)
+		carteiraAcao = &models.CarteiraAcoes{Data: []models.AtivoAcao{}}
+	}
+
+	calendario := handlers.CalendarioService.GerarCalendario(carteiraFII, carteiraAcao, meses, handlers.Relogio.Agora())
+
+	if r.URL.Query().Get("formato") == "json" {
+		json.NewEncoder(w).Encode(calendario)
+		return
+	}
+
+	html, err := handlers.RenderizarTemplateParaString("calendario.html", calendario)
+	if err != nil {
+		responderErro(w, "Erro ao renderizar o calendário: "+err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.RespostaCalculadora{
+		Status:    "success",
+		Message:   "Calendário gerado com sucesso",
+		DadosHtml: html,
+	})
+}
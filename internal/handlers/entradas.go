@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"calculadora-investimentos/internal/services"
+	"calculadora-investimentos/internal/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// entradasCalculo agrupa as entradas da calculadora e os títulos de renda fixa recomendados,
+// usados depois do cálculo para detalhar as aplicações
+type entradasCalculo struct {
+	services.EntradasCalculo
+	RecomendadosRendaFixa []models.RendaFixaRecomendada
+}
+
+// carregarEntradasCalculo carrega as recomendações e as carteiras atuais
+func (h *Handlers) carregarEntradasCalculo() (*entradasCalculo, error) {
+	recomendadosFII, err := h.DataService.CarregarRecomendadosFII()
+	if err != nil {
+		log.Println("Erro ao carregar recomendações de FIIs:", err)
+		return nil, fmt.Errorf("Erro ao carregar recomendações de FIIs: %w", err)
+	}
+
+	recomendadosAcao, err := h.DataService.CarregarRecomendadosAcao()
+	if err != nil {
+		log.Println("Erro ao carregar recomendações de ações:", err)
+		return nil, fmt.Errorf("Erro ao carregar recomendações de ações: %w", err)
+	}
+
+	recomendadosETF, err := h.DataService.CarregarRecomendadosETF()
+	if err != nil {
+		log.Println("Erro ao carregar recomendações de ETFs:", err)
+		// Usar dados padrão mínimos
+		recomendadosETF = []models.ETFRecomendado{
+			{Ticker: "WRLD11", Nome: "ETF BDRs Mundo", PesoIdeal: 100, Preco: 123.68},
+		}
+	}
+
+	recomendadosRendaFixa, err := h.DataService.CarregarRecomendadosRendaFixa()
+	if err != nil {
+		log.Println("Erro ao carregar recomendações de renda fixa:", err)
+		// Sem a lista, a renda fixa continua sendo recomendada como valor total
+		recomendadosRendaFixa = []models.RendaFixaRecomendada{}
+	}
+
+	// BDRs e criptoativos são opcionais: sem a lista, a classe fica sem recomendações
+	recomendadosBDR, err := h.DataService.CarregarRecomendadosBDR()
+	if err != nil {
+		log.Println("Erro ao carregar recomendações de BDRs:", err)
+		recomendadosBDR = []models.ETFRecomendado{}
+	}
+
+	recomendadosCripto, err := h.DataService.CarregarRecomendadosCripto()
+	if err != nil {
+		log.Println("Erro ao carregar recomendações de criptoativos:", err)
+		recomendadosCripto = []models.ETFRecomendado{}
+	}
+
+	// Carregar carteiras
+	carteiraFII, err := h.DataService.ObterCarteiraAtualFII()
+	if err != nil {
+		log.Println("Erro ao obter carteira atual de FIIs:", err)
+		return nil, fmt.Errorf("Erro ao obter carteira atual de FIIs: %w", err)
+	}
+
+	carteiraAcao, err := h.DataService.ObterCarteiraAtualAcao()
+	if err != nil {
+		log.Println("Erro ao obter carteira atual de ações:", err)
+		// Cria uma carteira vazia em caso de erro
+		carteiraAcao = &models.CarteiraAcoes{
+			Total: 0,
+			Data:  []models.AtivoAcao{},
+			Draw:  1,
+		}
+	}
+
+	carteiraETF, err := h.DataService.ObterCarteiraAtualETF()
+	if err != nil {
+		log.Println("Erro ao obter carteira atual de ETFs:", err)
+		// Cria uma carteira vazia em caso de erro
+		carteiraETF = &models.CarteiraETFs{
+			Total: 0,
+			Data:  []models.AtivoETF{},
+			Draw:  1,
+		}
+	}
+
+	// Os ETFs de criptoativos vêm junto com os demais ETFs e formam uma classe à parte
+	carteiraETF, carteiraCripto := h.DataService.SepararCarteiraCripto(carteiraETF, recomendadosCripto)
+
+	carteiraBDR, err := h.DataService.ObterCarteiraAtualBDR()
+	if err != nil {
+		log.Println("Erro ao obter carteira atual de BDRs:", err)
+		// Cria uma carteira vazia em caso de erro
+		carteiraBDR = &models.CarteiraETFs{
+			Total: 0,
+			Data:  []models.AtivoETF{},
+			Draw:  1,
+		}
+	}
+
+	carteiraRendaFixa, err := h.DataService.ObterCarteiraAtualRendaFixa()
+	if err != nil {
+		log.Println("Erro ao obter carteira atual de renda fixa:", err)
+		// Cria uma carteira vazia em caso de erro
+		carteiraRendaFixa = &models.CarteiraRendaFixa{
+			Total: 0,
+			Data:  []models.AtivoRendaFixa{},
+			Draw:  1,
+		}
+	}
+
+	// Converter as carteiras de renda variável para o formato comum às classes
+	return &entradasCalculo{
+		EntradasCalculo: services.EntradasCalculo{
+			Carteiras: map[string][]models.Ativo{
+				services.ClasseFII.Nome:    services.AtivosFII(carteiraFII),
+				services.ClasseAcao.Nome:   services.AtivosAcao(carteiraAcao),
+				services.ClasseETF.Nome:    services.AtivosETF(carteiraETF),
+				services.ClasseBDR.Nome:    services.AtivosETF(carteiraBDR),
+				services.ClasseCripto.Nome: services.AtivosETF(carteiraCripto),
+			},
+			Recomendados: map[string][]models.AtivoRecomendado{
+				services.ClasseFII.Nome:    recomendadosFII,
+				services.ClasseAcao.Nome:   recomendadosAcao,
+				services.ClasseETF.Nome:    recomendadosETF,
+				services.ClasseBDR.Nome:    recomendadosBDR,
+				services.ClasseCripto.Nome: recomendadosCripto,
+			},
+			CarteiraRendaFixa: carteiraRendaFixa,
+		},
+		RecomendadosRendaFixa: recomendadosRendaFixa,
+	}, nil
+}
+
+// lerTiposInvestimento obtém os tipos de investimento selecionados no formulário
+func lerTiposInvestimento(r *http.Request) models.TiposInvestimento {
+	tiposInvestimento := services.TiposInvestimentoPadrao()
+
+	// Verificar se há distribuição personalizada
+	if r.FormValue("distribuicaoPersonalizada") != "true" {
+		return tiposInvestimento
+	}
+
+	tiposSelecionadosJSON := r.FormValue("tiposInvestimento")
+	if tiposSelecionadosJSON == "" {
+		return tiposInvestimento
+	}
+
+	var tiposSelecionados []string
+	err := json.Unmarshal([]byte(tiposSelecionadosJSON), &tiposSelecionados)
+	if err != nil {
+		log.Println("Erro ao processar tipos de investimento selecionados:", err)
+		return tiposInvestimento
+	}
+
+	// Marcar apenas os tipos selecionados
+	return services.SelecionarClasses(tiposSelecionados)
+}
+
+// lerParametrosProjecao obtém os parâmetros opcionais da projeção de longo prazo
+func lerParametrosProjecao(r *http.Request) services.ParametrosProjecao {
+	params := services.ParametrosProjecao{
+		ReinvestirDividendos: r.FormValue("reinvestirDividendos") != "false",
+	}
+
+	if anosStr := r.FormValue("anosProjecao"); anosStr != "" {
+		if anos, err := strconv.Atoi(anosStr); err == nil {
+			params.Anos = anos
+		}
+	}
+
+	if aporteStr := r.FormValue("aporteMensalProjecao"); aporteStr != "" {
+		if aporte, err := utils.ProcessarValorMonetario(aporteStr); err == nil && aporte > 0 {
+			params.AporteMensal = aporte
+		}
+	}
+
+	if metaStr := r.FormValue("metaRendaMensal"); metaStr != "" {
+		if meta, err := utils.ProcessarValorMonetario(metaStr); err == nil && meta > 0 {
+			params.MetaRendaMensal = meta
+		}
+	}
+
+	return params
+}
+
+// lerMetaReservaEmergencia obtém a meta da reserva de emergência: o valor informado no formulário ou,
+// sem ele, a meta configurada a partir das despesas mensais (informadas ou padrão)
+func lerMetaReservaEmergencia(r *http.Request, distribuidora *services.DistribuidoraService) float64 {
+	if metaStr := r.FormValue("reservaEmergencia"); metaStr != "" {
+		if meta, err := utils.ProcessarValorMonetario(metaStr); err == nil && meta >= 0 {
+			return meta
+		}
+	}
+
+	despesaMensal := 0.0
+	if despesaStr := r.FormValue("despesasMensais"); despesaStr != "" {
+		if despesa, err := utils.ProcessarValorMonetario(despesaStr); err == nil && despesa > 0 {
+			despesaMensal = despesa
+		}
+	}
+	return distribuidora.MetaReservaEmergencia(despesaMensal)
+}
+
+// lerDataReferencia obtém a data de referência opcional do cálculo (AAAA-MM-DD).
+// Sem data, retorna o relógio do sistema; com data, um relógio fixo naquele dia. Só as análises que
+// dependem do relógio (datas com, proventos e histórico de preços) voltam à data de referência: a
+// carteira, as cotações e os indicadores continuam sendo os atuais do Investidor10 e da BrAPI.
+func lerDataReferencia(r *http.Request) (relogio.Relogio, error) {
+	dataStr := r.FormValue("dataReferencia")
+	if dataStr == "" {
+		return relogio.Sistema{}, nil
+	}
+
+	data, err := time.ParseInLocation("2006-01-02", dataStr, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("Data de referência inválida: use o formato AAAA-MM-DD")
+	}
+	agora := time.Now()
+	if data.After(agora) {
+		return nil, fmt.Errorf("A data de referência não pode estar no futuro")
+	}
+
+	return relogio.NovoFixo(data, agora), nil
+}
+
+// responderErro envia uma resposta JSON de erro no formato esperado pelo frontend
+func responderErro(w http.ResponseWriter, mensagem string) {
+	json.NewEncoder(w).Encode(models.RespostaCalculadora{
+		Status:  "error",
+		Message: mensagem,
+	})
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"bytes"
 	"calculadora-investimentos/internal/api"
+	"calculadora-investimentos/internal/b3"
 	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/relogio"
 	"calculadora-investimentos/internal/services"
 	"calculadora-investimentos/internal/utils"
 	"encoding/json"
@@ -14,16 +16,30 @@ import (
 
 // Handlers contém todos os manipuladores HTTP
 type Handlers struct {
-	Config             *config.Config
-	BrapiClient        *api.BrapiClient
-	CalculadoraService *services.Calculadora
-	DataService        *services.DataService
-	DividendoService   *services.DividendoService
-	DataComService     *services.DataComService
+	Config               *config.Config
+	Relogio              relogio.Relogio
+	BrapiClient          *api.BrapiClient
+	CalculadoraService   *services.Calculadora
+	DataService          *services.DataService
+	DividendoService     *services.DividendoService
+	DataComService       *services.DataComService
+	PlanejadoraService   *services.PlanejadoraService
+	ProjetoraService     *services.ProjetoraService
+	MonteCarloService    *services.MonteCarloService
+	CalendarioService    *services.CalendarioService
+	AgendaICSService     *services.AgendaICSService
+	RendaFixaService     *services.RendaFixaService
+	DistribuidoraService *services.DistribuidoraService
 }
 
-// NewHandlers cria uma nova instância de Handlers
+// NewHandlers cria uma nova instância de Handlers usando o relógio do sistema
 func NewHandlers() *Handlers {
+	return NewHandlersComRelogio(relogio.Sistema{})
+}
+
+// NewHandlersComRelogio cria uma nova instância de Handlers cujos serviços usam o relógio
+// informado como data atual (por exemplo, um relógio fixo na data de referência do cálculo)
+func NewHandlersComRelogio(rel relogio.Relogio) *Handlers {
 	// Carregar configuração
 	cfg := config.Load()
 
@@ -36,12 +52,33 @@ func NewHandlers() *Handlers {
 		cfg.CacheLimpeza,
 	)
 
+	// Inicializar o calendário de pregões da B3 antes dos serviços que o utilizam
+	b3.GetInstance(cfg.DiretorioFeriados)
+
 	// Criar serviços
-	distribuidoraService := services.NewDistribuidoraService(cfg)
-	recomendadoraService := services.NewRecomendadoraService()
-	otimizadoraService := services.NewOtimizadoraService()
+	distribuidoraService := services.NewDistribuidoraService(cfg, brapiClient)
+	dataComService := services.NewDataComService(cfg, brapiClient.Cache, rel)
 	dataService := services.NewDataService(cfg, brapiClient)
-	dividendoService := services.NewDividendoService()
+
+	// Sem a composição dos FoFs o valor de cada FoF fica atribuído ao próprio fundo, com aviso no relatório
+	composicaoFoFs, errFoFs := dataService.CarregarComposicaoFoFs()
+	if errFoFs != nil {
+		log.Printf("Erro ao carregar composição dos FoFs: %v", errFoFs)
+	}
+	emissorService := services.NewEmissorService(cfg, composicaoFoFs, errFoFs)
+
+	// Sem a classificação setorial as ações ficam como não classificadas e sem limite por setor
+	setoresAcoes, err := dataService.CarregarSetoresAcoes()
+	if err != nil {
+		log.Printf("Erro ao carregar setores das ações: %v", err)
+	}
+	setorService := services.NewSetorService(cfg, setoresAcoes)
+	dividendoService := services.NewDividendoService(cfg, brapiClient.Cache)
+	precoTetoService := services.NewPrecoTetoService(cfg, dividendoService, brapiClient, rel)
+
+	recomendadoraService := services.NewRecomendadoraService(dataComService, emissorService, setorService, precoTetoService)
+	otimizadoraService := services.NewOtimizadoraService(dataComService, emissorService, setorService)
+	tributacaoService := services.NewTributacaoService(cfg)
 
 	// Criar serviço de calculadora com dividendoService
 	calculadoraService := services.NewCalculadora(
@@ -49,14 +86,35 @@ func NewHandlers() *Handlers {
 		recomendadoraService,
 		otimizadoraService,
 		dividendoService,
+		tributacaoService,
+		emissorService,
+		setorService,
+		precoTetoService,
+		rel,
 	)
 
+	// Criar serviço de planejamento sobre a calculadora
+	planejadoraService := services.NewPlanejadoraService(cfg, calculadoraService)
+	projetoraService := services.NewProjetoraService(cfg, rel)
+	monteCarloService := services.NewMonteCarloService(cfg, brapiClient, rel)
+	calendarioService := services.NewCalendarioService(cfg, dividendoService, tributacaoService, dataComService)
+	agendaICSService := services.NewAgendaICSService(dataComService)
+	rendaFixaService := services.NewRendaFixaService(cfg, rel)
+
 	return &Handlers{
-		Config:             cfg,
-		BrapiClient:        brapiClient,
-		CalculadoraService: calculadoraService,
-		DataService:        dataService,
-		DividendoService:   dividendoService,
+		Config:               cfg,
+		Relogio:              rel,
+		BrapiClient:          brapiClient,
+		CalculadoraService:   calculadoraService,
+		DataService:          dataService,
+		DividendoService:     dividendoService,
+		PlanejadoraService:   planejadoraService,
+		ProjetoraService:     projetoraService,
+		MonteCarloService:    monteCarloService,
+		CalendarioService:    calendarioService,
+		AgendaICSService:     agendaICSService,
+		RendaFixaService:     rendaFixaService,
+		DistribuidoraService: distribuidoraService,
 	}
 }
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"calculadora-investimentos/internal/services"
 	"net/http"
 )
 
@@ -9,8 +10,15 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
 	// Criar uma nova instância de Handlers
 	handlers := NewHandlers()
 
-	// Renderizar a página inicial
-	err := handlers.RenderizarTemplate(w, "index.html", nil)
+	// Renderizar a página inicial com os percentuais configurados e os efetivos da seleção padrão
+	dados := struct {
+		DistribuicaoConfigurada map[string]float64
+		DistribuicaoPadrao      map[string]float64
+	}{
+		DistribuicaoConfigurada: handlers.Config.DistribuicaoIdeal,
+		DistribuicaoPadrao:      handlers.DistribuidoraService.CalcularDistribuicaoIdeal(services.TiposInvestimentoPadrao()),
+	}
+	err := handlers.RenderizarTemplate(w, "index.html", dados)
 	if err != nil {
 		http.Error(w, "Erro ao carregar o template: "+err.Error(), http.StatusInternalServerError)
 		return
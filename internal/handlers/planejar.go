@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/utils"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PlanejarHandler manipula requisições para o planejamento de aportes mensais
+func PlanejarHandler(w http.ResponseWriter, r *http.Request) {
+	// Definir o tipo de conteúdo como JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	handlers := NewHandlers()
+
+	if r.Method != "POST" {
+		responderErro(w, "Método não permitido")
+		return
+	}
+
+	// Processar o formulário (multipart ou padrão)
+	var err error
+	if strings.Contains(r.Header.Get("Content-Type"), "multipart/form-data") {
+		err = r.ParseMultipartForm(10 << 20) // 10 MB
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		log.Println("Erro ao processar formulário de planejamento:", err)
+		responderErro(w, "Erro ao processar o formulário: "+err.Error())
+		return
+	}
+
+	aporteStr := r.FormValue("aporteMensal")
+	if aporteStr == "" {
+		responderErro(w, "Por favor, digite o valor do aporte mensal")
+		return
+	}
+
+	aporteMensal, err := utils.ProcessarValorMonetario(aporteStr)
+	if err != nil || aporteMensal <= 0 {
+		responderErro(w, "Valor de aporte mensal inválido")
+		return
+	}
+
+	meses := handlers.Config.MesesPlanejamentoPadrao
+	if mesesStr := r.FormValue("meses"); mesesStr != "" {
+		meses, err = strconv.Atoi(mesesStr)
+		if err != nil {
+			responderErro(w, "Horizonte de planejamento inválido")
+			return
+		}
+	}
+
+	tiposInvestimento := lerTiposInvestimento(r)
+
+	entradas, err := handlers.carregarEntradasCalculo()
+	if err != nil {
+		responderErro(w, err.Error())
+		return
+	}
+
+	plano, err := handlers.PlanejadoraService.PlanejarAportes(
+		aporteMensal,
+		meses,
+		tiposInvestimento,
+		&entradas.EntradasCalculo,
+		lerMetaReservaEmergencia(r, handlers.DistribuidoraService),
+	)
+	if err != nil {
+		log.Println("Erro ao planejar aportes:", err)
+		responderErro(w, "Erro ao planejar aportes: "+err.Error())
+		return
+	}
+
+	html, err := handlers.RenderizarTemplateParaString("planejamento.html", plano)
+	if err != nil {
+		responderErro(w, "Erro ao renderizar o planejamento: "+err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.RespostaCalculadora{
+		Status:    "success",
+		Message:   "Planejamento realizado com sucesso",
+		DadosHtml: html,
+	})
+}
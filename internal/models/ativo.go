@@ -0,0 +1,68 @@
+package models
+
+// Classe descreve uma classe de renda variável e como ela participa dos cálculos comuns
+type Classe struct {
+	Codigo      string // Código do candidato na otimização das sobras ("FII", "ACAO", "ETF", "BDR" ou "CRIPTO")
+	Nome        string // Chave da classe na distribuição ("FIIs", "Ações", "ETFs", "BDRs" ou "Cripto")
+	Descricao   string // Nome do ativo usado nos logs
+	TipoDataCom string // Tipo usado na análise de data com; vazio quando a classe não é analisada
+	// Tipo usado na estimativa de proventos ("FII" ou "ACAO"); vazio quando a renda da classe segue
+	// apenas a premissa de dividend yield da projeção
+	TipoProventos string
+	// Só recebe sobras quando a classe já recebeu compras no aporte
+	SobrasSomenteComCompras bool
+	// Aplica o limite de concentração por emissor, somando todos os tickers da mesma empresa
+	LimitarPorEmissor bool
+	// Aplica os limites de concentração por setor, conforme a classificação setorial dos tickers
+	LimitarPorSetor bool
+	// Avalia o preço-teto dos recomendados antes das compras
+	AvaliarPrecoTeto bool
+	// Inclina os pesos ideais pelo P/VP relativo à mediana do segmento
+	InclinarPorPVP bool
+	// Estima os dividendos mensais das posições da carteira final pelo DY informado
+	DividendosPorDY bool
+	// Só entra no aporte quando selecionada na distribuição personalizada
+	Opcional bool
+}
+
+// SetorAcao é a classificação setorial de uma ação, lida de data/setores_acoes.txt
+type SetorAcao struct {
+	Setor    string
+	Subsetor string
+}
+
+// Ativo é a posição de renda variável na carteira atual, independente da classe e do formato
+// em que a carteira foi obtida
+type Ativo struct {
+	Ticker             string
+	Segmento           string
+	Tipo               string
+	Preco              float64
+	SemCotacao         bool // Preço atual indisponível ou inválido
+	Quantidade         int
+	PercentualCarteira float64
+	DY                 float64
+	PVP                float64
+	PL                 float64
+}
+
+// PrecoTeto é a avaliação de um ativo pelos métodos de preço-teto configurados
+type PrecoTeto struct {
+	PrecoBazin      float64 // Proventos dos últimos 12 meses divididos pela taxa mínima; zero sem proventos
+	PrecoGraham     float64 // Raiz de 22,5 x LPA x VPA; zero sem lucro ou patrimônio positivos
+	PrecoTeto       float64 // Menor preço-teto entre os métodos disponíveis; zero quando nenhum se aplica
+	MargemSeguranca float64 // (preço-teto - cotação) / preço-teto, em %
+	AcimaDoTeto     bool
+}
+
+// InclinacaoPVP explica o ajuste do peso ideal de um FII pelo P/VP relativo à mediana do segmento
+type InclinacaoPVP struct {
+	Ticker          string
+	Segmento        string
+	PVP             float64 // Zero quando o P/VP não está disponível
+	MedianaSegmento float64
+	Desvio          float64 // Desvio (%) do P/VP em relação à mediana
+	PesoOriginal    float64
+	PesoAjustado    float64
+	Explicacao      string
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// EventoProvento representa um provento esperado de um ativo da carteira
+type EventoProvento struct {
+	Ticker        string    `json:"ticker"`
+	TipoAtivo     string    `json:"tipo_ativo"` // FII ou ACAO
+	Tipo          string    `json:"tipo"`       // Dividendo, JCP ou Rendimento
+	JCP           bool      `json:"jcp"`
+	DataCom       time.Time `json:"data_com"`
+	DataPagamento time.Time `json:"data_pagamento"`
+	ValorPorCota  float64   `json:"valor_por_cota"`
+	Quantidade    int       `json:"quantidade"`
+	ValorBruto    float64   `json:"valor_bruto"`
+	ValorLiquido  float64   `json:"valor_liquido"`
+	Anunciado     bool      `json:"anunciado"` // true se já anunciado, false se projetado pelo histórico
+}
+
+// MesCalendario agrupa os proventos esperados de um mês, pela data de pagamento
+type MesCalendario struct {
+	Ano          int              `json:"ano"`
+	Mes          int              `json:"mes"`
+	Rotulo       string           `json:"rotulo"`
+	Eventos      []EventoProvento `json:"eventos"`
+	TotalBruto   float64          `json:"total_bruto"`
+	TotalLiquido float64          `json:"total_liquido"`
+}
+
+// CalendarioProventos contém o calendário consolidado de proventos da carteira
+type CalendarioProventos struct {
+	Inicio       time.Time        `json:"inicio"`
+	Fim          time.Time        `json:"fim"`
+	Meses        []MesCalendario  `json:"meses"`
+	Eventos      []EventoProvento `json:"eventos"`
+	TotalBruto   float64          `json:"total_bruto"`
+	TotalLiquido float64          `json:"total_liquido"`
+}
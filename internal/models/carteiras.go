@@ -108,10 +108,6 @@ type AtivoRendaFixa struct {
 	TickerType     string  `json:"ticker_type"`
 }
 
-// TiposInvestimento representa os tipos de investimento selecionados pelo usuário
-type TiposInvestimento struct {
-	FIIs      bool
-	Acoes     bool
-	ETFs      bool
-	RendaFixa bool
-}
+// TiposInvestimento indica as classes selecionadas pelo usuário, indexadas pelo nome da classe na
+// distribuição ("FIIs", "Ações", ..., "RendaFixa"); classes ausentes não estão selecionadas
+type TiposInvestimento map[string]bool
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// EtapaPlanoAportes representa um mês simulado do plano de aportes
+type EtapaPlanoAportes struct {
+	Mes                    int
+	Data                   time.Time // Data de referência usada nos cálculos do mês
+	ValorAporte            float64
+	DividendosReinvestidos float64
+	SobraAnterior          float64
+	ValorInvestido         float64 // Aporte + dividendos reinvestidos + sobra do mês anterior
+	RecomendacoesFII       []RecomendacaoCompraFII
+	RecomendacoesAcao      []RecomendacaoCompraAcao
+	RecomendacoesETF       []RecomendacaoCompraETF
+	RecomendacoesBDR       []RecomendacaoCompraETF
+	RecomendacoesCripto    []RecomendacaoCompraETF
+	ValorRendaFixa         float64
+	ValorReservaEmergencia float64
+	ValorRestante          float64
+	ValorTotalCarteira     float64
+	RendimentosMensais     float64
+	DistribuicaoFinal      map[string]float64
+	ClassesNaMeta          map[string]bool
+}
+
+// PlanoAportes representa o cronograma completo de aportes mensais
+type PlanoAportes struct {
+	AporteMensal                float64
+	Meses                       int
+	ToleranciaMeta              float64
+	ValorTotalInicial           float64
+	ValorTotalFinal             float64
+	TotalAportado               float64
+	TotalDividendosReinvestidos float64
+	DistribuicaoInicial         map[string]float64
+	DistribuicaoIdeal           map[string]float64
+	MesAtingimentoMeta          map[string]int // 0 indica que a classe não atingiu a meta no horizonte
+	Etapas                      []EtapaPlanoAportes
+}
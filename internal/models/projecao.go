@@ -0,0 +1,67 @@
+package models
+
+// PontoProjecao representa a situação projetada da carteira em um mês
+type PontoProjecao struct {
+	Mes                    int
+	Rotulo                 string // Mês/ano no formato 01/2006
+	Patrimonio             float64
+	RendaPassivaMensal     float64
+	Aporte                 float64
+	DividendosReinvestidos float64
+	TotalAportado          float64
+	PatrimonioPorClasse    map[string]float64
+}
+
+// ProjecaoPatrimonio representa a projeção mês a mês de patrimônio e renda passiva
+type ProjecaoPatrimonio struct {
+	Anos                        int
+	AporteMensal                float64
+	MetaRendaMensal             float64
+	ReinvestirDividendos        bool
+	PatrimonioInicial           float64
+	RendaMensalInicial          float64
+	PatrimonioFinal             float64
+	RendaMensalFinal            float64
+	TotalAportado               float64
+	TotalDividendosReinvestidos float64
+	MesMetaRenda                int // 0 indica que a meta não foi atingida no horizonte
+	RotuloMetaRenda             string
+	Pontos                      []PontoProjecao
+	// Séries para os gráficos
+	Rotulos         []string
+	SeriePatrimonio []float64
+	SerieRenda      []float64
+}
+
+// FaixaPercentis representa os percentis de uma distribuição simulada
+type FaixaPercentis struct {
+	P10 float64
+	P25 float64
+	P50 float64
+	P75 float64
+	P90 float64
+}
+
+// PontoMonteCarlo representa os percentis de patrimônio e renda em um mês simulado
+type PontoMonteCarlo struct {
+	Mes        int
+	Rotulo     string
+	Patrimonio FaixaPercentis
+	Renda      FaixaPercentis
+}
+
+// SimulacaoMonteCarlo representa o resultado da simulação de Monte Carlo da carteira final
+type SimulacaoMonteCarlo struct {
+	Simulacoes             int
+	Semente                int64
+	Anos                   int
+	AporteMensal           float64
+	MetaRendaMensal        float64
+	ProbabilidadeMetaRenda float64           // Percentual de cenários que atingem a meta até o fim do horizonte
+	FonteRetornos          map[string]string // Origem dos retornos por classe: histórico ou premissa
+	Pontos                 []PontoMonteCarlo
+	// Séries para os gráficos
+	Rotulos         []string
+	SeriePatrimonio map[string][]float64 // Percentil -> série mensal
+	SerieRenda      map[string][]float64
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Tipos de provento normalizados
+const (
+	TipoProventoDividendo  = "Dividendo"
+	TipoProventoJCP        = "JCP"
+	TipoProventoRendimento = "Rendimento"
+)
+
+// ProventoHistorico representa um provento pago (ou anunciado) por um ativo
+type ProventoHistorico struct {
+	Ticker        string
+	Tipo          string // Dividendo, JCP ou Rendimento
+	DataCom       time.Time
+	DataPagamento time.Time
+	Valor         float64 // Valor por cota/ação
+	JCP           bool    // Juros sobre capital próprio (tributado na fonte)
+}
+
+// AcaoCarteiraFinalComRendimento representa uma ação da carteira final com os proventos dos últimos 12 meses
+type AcaoCarteiraFinalComRendimento struct {
+	Ticker                   string
+	Nome                     string
+	Preco                    float64
+	DY                       float64
+	Quantidade               int
+	ValorTotal               float64
+	DividendosPorAcao12M     float64
+	JCPPorAcao12M            float64
+	PagamentosUltimoAno      int
+	Estimador                string
+	DividendosAnuais         float64
+	JCPAnuais                float64
+	RendimentoAnual          float64
+	DividendosAnuaisLiquidos float64
+	JCPAnuaisLiquidos        float64
+	RendimentoAnualLiquido   float64
+	YieldOnCost              float64 // (RendimentoAnual / ValorTotal) * 100
+	FonteHistorico           bool    // Indica se o rendimento veio do histórico ou do DY informado
+	SemHistorico             bool    // Cálculo retroativo sem proventos até a data de referência: rendimento zero
+	UltimosProventos         []ProventoHistorico
+}
+
+// ResumoRendaClasse resume a renda bruta e líquida de IR de uma classe de ativos
+type ResumoRendaClasse struct {
+	MensalBruta   float64
+	MensalLiquida float64
+	AnualBruta    float64
+	AnualLiquida  float64
+	ImpostoAnual  float64
+}
@@ -1,7 +1,8 @@
 package models
 
-// Estrutura para o FII recomendado
-type FIIRecomendado struct {
+// AtivoRecomendado é o ativo da lista de recomendados de qualquer classe de renda variável.
+// Segmento e Tipo só são preenchidos para FIIs.
+type AtivoRecomendado struct {
 	Ticker    string
 	Nome      string
 	Segmento  string
@@ -10,47 +11,52 @@ type FIIRecomendado struct {
 	Preco     float64
 }
 
+// Estrutura para o FII recomendado
+type FIIRecomendado = AtivoRecomendado
+
 // Estrutura para a ação recomendada
-type AcaoRecomendada struct {
-	Nome      string
-	Ticker    string
-	PesoIdeal float64
-	Preco     float64
+type AcaoRecomendada = AtivoRecomendado
+
+// Estrutura para ETF recomendado (também usada para BDRs e ETFs de criptoativos)
+type ETFRecomendado = AtivoRecomendado
+
+// Estrutura para título de renda fixa recomendado
+type RendaFixaRecomendada struct {
+	Nome            string
+	Tipo            string // Tesouro Direto, CDB, LCI etc.
+	Emissor         string
+	Indexador       string  // CDI, SELIC, IPCA ou PREFIXADO
+	Taxa            float64 // % do CDI para títulos CDI; taxa ou spread anual (%) para os demais
+	Vencimento      string  // dd/mm/aaaa
+	LiquidezDiaria  bool
+	AplicacaoMinima float64
+	PesoIdeal       float64
 }
 
-// Estrutura para ETF recomendado
-type ETFRecomendado struct {
-	Ticker    string
-	Nome      string
-	PesoIdeal float64
-	Preco     float64
+// Estrutura para recomendação de aplicação em renda fixa
+type RecomendacaoCompraRendaFixa struct {
+	Nome             string
+	Tipo             string
+	Emissor          string
+	DescricaoTaxa    string
+	Vencimento       string
+	LiquidezDiaria   bool
+	AplicacaoMinima  float64
+	PesoIdeal        float64 // Peso do título na lista de recomendados
+	ValorCompra      float64
+	Percentual       float64 // Percentual do valor destinado à renda fixa
+	TaxaLiquidaAnual float64 // Taxa líquida projetada até o vencimento (% a.a.)
+	Conglomerado     string
+	LimitadoPeloFGC  bool // Valor reduzido para não ultrapassar a garantia do FGC
 }
 
-// Estrutura para recomendação de compra de FII
-type RecomendacaoCompraFII struct {
+// RecomendacaoCompra é a recomendação de compra de qualquer classe de renda variável
+type RecomendacaoCompra struct {
 	Ticker         string
 	Nome           string
 	Segmento       string
 	Tipo           string
 	Preco          float64
-	PesoAtual      float64
-	PesoIdeal      float64
-	Diferenca      float64
-	Quantidade     int
-	ValorCompra    float64
-	PesoAposCompra float64
-	// Informações de Data Com
-	ProximaDataCom string
-	DiasAteDataCom int
-	StatusCompra   string
-	MensagemStatus string
-}
-
-// Estrutura para recomendação de compra de ação
-type RecomendacaoCompraAcao struct {
-	Ticker         string
-	Nome           string
-	Preco          float64
 	PL             float64
 	PVP            float64
 	DY             float64
@@ -60,35 +66,38 @@ type RecomendacaoCompraAcao struct {
 	Quantidade     int
 	ValorCompra    float64
 	PesoAposCompra float64
-	// Informações de Data Com
+	// Preço-teto (apenas classes avaliadas, ver Classe.AvaliarPrecoTeto)
+	PrecoTeto       float64
+	MargemSeguranca float64
+	CompraReduzida  bool // Compra reduzida por cotação acima do preço-teto
+	// Informações de Data Com (apenas classes analisadas, ver Classe.TipoDataCom)
 	ProximaDataCom string
 	DiasAteDataCom int
 	StatusCompra   string
 	MensagemStatus string
 }
 
+// Estrutura para recomendação de compra de FII
+type RecomendacaoCompraFII = RecomendacaoCompra
+
+// Estrutura para recomendação de compra de ação
+type RecomendacaoCompraAcao = RecomendacaoCompra
+
 // Estrutura para recomendação de compra de ETF
-type RecomendacaoCompraETF struct {
-	Ticker         string
-	Nome           string
-	Preco          float64
-	PesoAtual      float64
-	PesoIdeal      float64
-	Diferenca      float64
-	Quantidade     int
-	ValorCompra    float64
-	PesoAposCompra float64
-}
+type RecomendacaoCompraETF = RecomendacaoCompra
 
-// Estrutura para FII na carteira final
-type FIICarteiraFinal struct {
+// AtivoCarteiraFinal é o ativo de qualquer classe de renda variável na carteira final
+type AtivoCarteiraFinal struct {
 	Ticker            string
 	Nome              string
 	Segmento          string
 	Tipo              string
+	Setor             string // Só preenchido para ações
+	Subsetor          string
 	Preco             float64
-	DY                float64
+	PL                float64
 	PVP               float64
+	DY                float64
 	Quantidade        int
 	ValorTotal        float64
 	Peso              float64
@@ -96,27 +105,68 @@ type FIICarteiraFinal struct {
 	PesoIdeal         float64
 }
 
+// Estrutura para FII na carteira final
+type FIICarteiraFinal = AtivoCarteiraFinal
+
 // Estrutura para ETF na carteira final
-type ETFCarteiraFinal struct {
-	Ticker     string
-	Nome       string
-	Preco      float64
-	Quantidade int
-	ValorTotal float64
-	Peso       float64
-	PesoIdeal  float64
-}
+type ETFCarteiraFinal = AtivoCarteiraFinal
 
 // Estrutura para ação na carteira final
-type AcaoCarteiraFinal struct {
+type AcaoCarteiraFinal = AtivoCarteiraFinal
+
+// Estrutura para um ativo com exposição internacional na carteira final
+type AtivoInternacional struct {
 	Ticker     string
 	Nome       string
-	Preco      float64
-	PL         float64
-	PVP        float64
-	DY         float64
-	Quantidade int
+	Classe     string // "BDRs" ou "ETFs"
 	ValorTotal float64
-	Peso       float64
-	PesoIdeal  float64
+	Percentual float64 // Percentual da carteira de investimentos final
+}
+
+// ExposicaoInternacional resume a exposição da carteira final a ativos internacionais (BDRs e ETFs de índices externos)
+type ExposicaoInternacional struct {
+	Ativos             []AtivoInternacional
+	ValorBDRs          float64
+	ValorETFs          float64
+	ValorTotal         float64
+	PercentualCarteira float64
+}
+
+// Estrutura para o valor da carteira final exposto a uma moeda
+type ExposicaoMoeda struct {
+	Moeda      string // "BRL", "USD" ou "OURO"
+	Valor      float64
+	Percentual float64
+}
+
+// ExposicaoCambial separa a carteira final entre reais, dólar e ouro e compara a exposição
+// estrangeira com a meta configurada
+type ExposicaoCambial struct {
+	Moedas                []ExposicaoMoeda
+	ValorEstrangeiro      float64 // Dólar e ouro
+	PercentualEstrangeiro float64
+	Meta                  float64 // 0 quando não há meta configurada
+	MetaAtingivel         bool    // Falso quando as classes selecionadas não comportam a meta
+	PercentualIdeal       float64 // Exposição estrangeira prevista pela distribuição ideal
+}
+
+// Estrutura para a exposição econômica a um emissor, somando todos os seus tickers
+// e as participações indiretas por meio de FoFs
+type ExposicaoEmissor struct {
+	Emissor         string
+	Tickers         []string // Tickers mantidos diretamente
+	ValorDireto     float64
+	ValorIndireto   float64 // Participação por meio de FoFs
+	ValorTotal      float64
+	Percentual      float64 // Percentual da carteira de investimentos final
+	ValorAcoes      float64
+	PercentualAcoes float64 // Percentual da classe de ações
+	AcimaDoLimite   bool    // Concentração em ações acima do limite por emissor
+}
+
+// ExposicaoEmissores agrega a carteira final de FIIs, ações e BDRs por emissor
+type ExposicaoEmissores struct {
+	Emissores   []ExposicaoEmissor
+	LimiteAcoes float64 // Limite de concentração por emissor na classe de ações (0 = sem limite)
+	AvisoFoFs   string  // Preenchido quando a exposição via FoFs está desativada (composição ausente ou inválida)
 }
@@ -0,0 +1,92 @@
+package models
+
+// Indexadores de renda fixa reconhecidos pela avaliação
+const (
+	IndexadorCDI       = "CDI"
+	IndexadorSelic     = "SELIC"
+	IndexadorIPCA      = "IPCA"
+	IndexadorPrefixado = "PREFIXADO"
+)
+
+// PosicaoRendaFixa representa a avaliação de um título de renda fixa até o vencimento
+type PosicaoRendaFixa struct {
+	Nome                   string
+	Tipo                   string // CDB, LCI, Tesouro etc.
+	Emissor                string
+	Conglomerado           string // Conglomerado financeiro do emissor (garantia do FGC)
+	CobertoFGC             bool
+	Indexador              string // Um dos Indexador*
+	DescricaoTaxa          string // Ex: "110% do CDI", "IPCA + 6,00%"
+	DataAplicacao          string // dd/mm/aaaa (vazia quando não informada)
+	DataVencimento         string // dd/mm/aaaa
+	DiasUteisAteVencimento int
+	LiquidezDiaria         bool
+	ValorAplicado          float64
+	ValorAtual             float64
+	TaxaBrutaAnual         float64 // % ao ano projetada para o indexador
+	ValorBrutoVencimento   float64
+	Isento                 bool
+	AliquotaIR             float64 // % da tabela regressiva aplicada no vencimento
+	ValorIR                float64
+	ValorIOF               float64
+	ValorLiquidoVencimento float64
+	TaxaLiquidaAnual       float64 // % ao ano efetiva, líquida de IR e IOF, do valor atual até o vencimento
+	Vencido                bool
+	Erro                   string // Preenchido quando os dados do título não puderam ser interpretados
+}
+
+// ResumoRendaFixa consolida a avaliação das posições de renda fixa
+type ResumoRendaFixa struct {
+	Posicoes                    []PosicaoRendaFixa
+	ValorAtualTotal             float64
+	ValorBrutoVencimentoTotal   float64
+	ValorLiquidoVencimentoTotal float64
+	PremissaCDI                 float64 // % ao ano usados na projeção
+	PremissaSelic               float64
+	PremissaIPCA                float64
+	FGC                         *AnaliseFGC
+	Escada                      *EscadaVencimentos
+}
+
+// DegrauVencimento agrupa os títulos sem liquidez diária que vencem em um mesmo ano
+type DegrauVencimento struct {
+	Ano             int
+	Quantidade      int
+	ValorAtual      float64
+	ValorVencimento float64 // Valor líquido projetado no vencimento
+	Percentual      float64 // Percentual do valor atual da renda fixa
+}
+
+// EscadaVencimentos resume quanto da renda fixa está disponível diariamente e quanto está
+// travado até o vencimento, ano a ano
+type EscadaVencimentos struct {
+	Degraus                  []DegrauVencimento
+	ValorLiquidezDiaria      float64 // Títulos com liquidez diária ou já vencidos
+	ValorTravado             float64 // Títulos resgatáveis apenas no vencimento
+	ValorSemVencimento       float64 // Títulos sem liquidez diária cuja data de vencimento não foi interpretada
+	PercentualLiquidezDiaria float64
+	PercentualMinimo         float64 // Percentual mínimo configurado em liquidez diária
+	ReservaMinima            float64 // Reserva mínima configurada em liquidez diária (R$)
+	DeficitLiquidez          float64 // Quanto falta em liquidez diária para atender ao percentual e à reserva
+}
+
+// ExposicaoFGC representa a exposição coberta pelo FGC em um conglomerado financeiro
+type ExposicaoFGC struct {
+	Conglomerado      string
+	Emissores         []string
+	ValorAtual        float64
+	ValorVencimento   float64 // Valor bruto projetado no vencimento
+	Limite            float64
+	Excedente         float64 // Parte do valor atual acima da garantia
+	AcimaDoLimite     bool
+	AcimaNoVencimento bool // Ultrapassará a garantia até o vencimento dos títulos
+}
+
+// AnaliseFGC consolida a exposição da renda fixa à garantia do FGC
+type AnaliseFGC struct {
+	Exposicoes        []ExposicaoFGC
+	TotalCoberto      float64 // Valor atual em títulos cobertos pelo FGC
+	LimiteGlobal      float64
+	AcimaLimiteGlobal bool
+	ValorNaoCoberto   float64 // Títulos sem garantia do FGC (Tesouro, CRI, CRA, debêntures)
+}
@@ -0,0 +1,14 @@
+package models
+
+// ReservaEmergencia resume a reserva de emergência, formada pelos títulos de renda fixa com
+// liquidez diária e mantida fora da carteira de investimentos
+type ReservaEmergencia struct {
+	Meta            float64
+	ValorAtual      float64 // Parte da renda fixa com liquidez diária destinada à reserva (limitada à meta)
+	Aporte          float64 // Valor do investimento usado para completar a reserva
+	ValorFinal      float64
+	Percentual      float64 // Percentual da meta atingido após o aporte
+	Completa        bool
+	Recomendacoes   []RecomendacaoCompraRendaFixa
+	ValorNaoAlocado float64
+}
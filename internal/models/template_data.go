@@ -4,68 +4,118 @@ import "html/template"
 
 // TemplateDados representa os dados enviados ao template HTML
 type TemplateDados struct {
-	ValorInvestimento                 float64
-	ValorTotalCarteira                float64
+	ValorInvestimento  float64
+	DataReferencia     string // Preenchida apenas em cálculos retroativos (dd/mm/aaaa)
+	ValorTotalCarteira float64
+	// Valores, compras e carteiras finais indexados pelo nome da classe (a renda fixa usa a chave
+	// "RendaFixa"). Os campos de cada classe abaixo são preenchidos a partir destes mapas.
+	ValoresCarteira                   map[string]float64
+	ValoresRecomendados               map[string]float64
+	PercentuaisRecomendados           map[string]float64
+	ValoresFinais                     map[string]float64
+	Recomendacoes                     map[string][]RecomendacaoCompra
+	CarteirasFinais                   map[string][]AtivoCarteiraFinal
 	ValorTotalCarteiraFII             float64
 	ValorTotalCarteiraAcao            float64
 	ValorTotalCarteiraETF             float64
+	ValorTotalCarteiraBDR             float64
+	ValorTotalCarteiraCripto          float64
 	ValorTotalCarteiraRendaFixa       float64
 	ValorFuturoCarteira               float64
 	RecomendacoesFII                  []RecomendacaoCompraFII
 	RecomendacoesAcao                 []RecomendacaoCompraAcao
 	RecomendacoesETF                  []RecomendacaoCompraETF
+	RecomendacoesBDR                  []RecomendacaoCompraETF
+	RecomendacoesCripto               []RecomendacaoCompraETF
 	ValorTotalRecomendadoFII          float64
 	ValorTotalRecomendadoAcao         float64
 	ValorTotalRecomendadoETF          float64
+	ValorTotalRecomendadoBDR          float64
+	ValorTotalRecomendadoCripto       float64
 	ValorTotalRecomendadoFixa         float64
 	PercentualRecomendadoFII          float64
 	PercentualRecomendadoAcao         float64
 	PercentualRecomendadoETF          float64
+	PercentualRecomendadoBDR          float64
+	PercentualRecomendadoCripto       float64
 	PercentualRecomendadoFixa         float64
 	ValorRestante                     float64
 	CarteiraFinalFII                  []FIICarteiraFinal
 	CarteiraFinalAcao                 []AcaoCarteiraFinal
 	CarteiraFinalETF                  []ETFCarteiraFinal
+	CarteiraFinalBDR                  []ETFCarteiraFinal
+	CarteiraFinalCripto               []ETFCarteiraFinal
 	ValorTotalFinalFII                float64
 	ValorTotalFinalAcao               float64
 	ValorTotalFinalETF                float64
+	ValorTotalFinalBDR                float64
+	ValorTotalFinalCripto             float64
 	ValorTotalFinalFixa               float64
 	ValorTotalFinal                   float64
 	DYMedioPonderadoFII               float64
 	DYMedioPonderadoAcao              float64
-	DividendosMensaisTotaisFII        float64
-	DividendosAnuaisTotalFII          float64
 	DividendosAnuaisTotalAcao         float64
 	TipoFII                           map[string]map[string]float64
 	SegmentoFII                       map[string]map[string]float64
+	InclinacoesPVPFII                 []InclinacaoPVP // Vazio quando a inclinação por P/VP está desativada
+	SetorAcao                         map[string]map[string]float64
+	SubsetorAcao                      map[string]map[string]float64
 	DistribuicaoAtual                 map[string]float64
 	DistribuicaoFinal                 map[string]float64
 	DistribuicaoIdeal                 map[string]float64
 	AtivosRendaFixa                   []AtivoRendaFixa
+	RendaFixa                         *ResumoRendaFixa // Avaliação dos títulos até o vencimento
+	RecomendacoesRendaFixa            []RecomendacaoCompraRendaFixa
+	ValorNaoAlocadoRendaFixa          float64            // Parte do valor da renda fixa que não atinge a aplicação mínima de nenhum título
+	ReservaEmergencia                 *ReservaEmergencia // Exibida separadamente da carteira de investimentos
+	ExposicaoInternacional            *ExposicaoInternacional
+	ExposicaoCambial                  *ExposicaoCambial
+	ExposicaoEmissores                *ExposicaoEmissores
 	PercentualRendaFixaNoInvestimento float64
 	// Novos campos para rendimentos
 	CarteiraFinalFIIComRendimento []FIICarteiraFinalComRendimento
 	TotalRendimentosMensaisFII    float64
 	TotalRendimentosAnuaisFII     float64
 	YieldMedioCarteiraFII         float64
+	EstimadorProventos            string // Descrição do estimador usado nas projeções de proventos
+	// Proventos das ações (dividendos e JCP separados)
+	CarteiraFinalAcaoComRendimento []AcaoCarteiraFinalComRendimento
+	TotalDividendosAnuaisAcao      float64
+	TotalJCPAnuaisAcao             float64
+	// Renda bruta e líquida de IR de cada classe que paga proventos, indexada pelo nome da classe. Os
+	// totais de FIIs e ações acima e abaixo são preenchidos a partir deste mapa para os templates.
+	TotalRendimentosMensaisFIILiquido float64
+	DividendosAnuaisTotalAcaoLiquido  float64
+	RendaPorClasse                    map[string]ResumoRendaClasse
+	RendaTotal                        ResumoRendaClasse
+	// Projeção de longo prazo
+	Projecao   *ProjecaoPatrimonio
+	MonteCarlo *SimulacaoMonteCarlo
 }
 
 // FIICarteiraFinalComRendimento representa um FII com informações de rendimento
 type FIICarteiraFinalComRendimento struct {
-	Ticker           string
-	Nome             string
-	Segmento         string
-	Tipo             string
-	Preco            float64
-	DY               float64
-	PVP              float64
-	Quantidade       int
-	ValorTotal       float64
-	Peso             float64
-	PesoIdeal        float64
-	UltimoDividendo  float64
-	RendimentoMensal float64
-	YieldOnCost      float64 // (UltimoDividendo * 12 / Preco) * 100
+	Ticker              string
+	Nome                string
+	Segmento            string
+	Tipo                string
+	Preco               float64
+	DY                  float64
+	PVP                 float64
+	Quantidade          int
+	ValorTotal          float64
+	Peso                float64
+	PesoIdeal           float64
+	UltimoDividendo     float64
+	DividendoEstimado   float64 // Dividendo mensal por cota segundo o estimador
+	PagamentosUltimoAno int
+	Estimador           string
+	RendimentoMensal    float64
+	IsentoIR            bool
+	AliquotaIR          float64
+	RendimentoLiquido   float64 // Rendimento mensal líquido de IR
+	YieldOnCost         float64 // (DividendoEstimado * 12 / Preco) * 100
+	SemHistorico        bool    // Cálculo retroativo sem proventos até a data de referência: rendimento zero
 }
 
 // RespostaCalculadora representa o formato de resposta JSON para o frontend
@@ -0,0 +1,58 @@
+package relogio
+
+import "time"
+
+// Relogio fornece a data e hora atuais para a lógica que depende de datas.
+// Os serviços recebem um Relogio em vez de chamar time.Now() diretamente, o que
+// permite executar os cálculos em uma data de referência (análises retroativas).
+type Relogio interface {
+	Agora() time.Time
+}
+
+// Sistema é o relógio padrão, baseado na hora do sistema
+type Sistema struct{}
+
+// Agora retorna a hora atual do sistema
+func (Sistema) Agora() time.Time {
+	return time.Now()
+}
+
+// Fixo é um relógio parado em um instante específico
+type Fixo struct {
+	Momento time.Time
+	// Quanto o instante fica antes do início do dia corrente do sistema, medido na criação do
+	// relógio por NovoFixo (zero para relógios montados diretamente)
+	Defasagem time.Duration
+}
+
+// NovoFixo cria um relógio parado no instante informado, medindo uma única vez sua defasagem em
+// relação ao dia corrente (agora). Assim Retroativo não consulta a hora do sistema a cada chamada
+// e uma análise não muda de comportamento se atravessar a meia-noite.
+func NovoFixo(momento, agora time.Time) Fixo {
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location())
+	return Fixo{Momento: momento, Defasagem: hoje.Sub(momento)}
+}
+
+// Agora retorna sempre o instante configurado
+func (f Fixo) Agora() time.Time {
+	return f.Momento
+}
+
+// Retroativo indica se o relógio aponta para um dia anterior ao dia corrente em que foi criado,
+// caso em que dados publicados depois da data de referência não devem ser considerados
+func Retroativo(r Relogio) bool {
+	fixo, ok := r.(Fixo)
+	return ok && fixo.Defasagem > 0
+}
+
+// Avancar retorna um relógio parado no instante do relógio informado deslocado pelo período,
+// preservando o dia corrente de referência usado por Retroativo. Um relógio retroativo avançado
+// além do dia corrente deixa de ser retroativo.
+func Avancar(r Relogio, anos, meses, dias int) Fixo {
+	momento := r.Agora()
+	hoje := momento
+	if fixo, ok := r.(Fixo); ok {
+		hoje = fixo.Momento.Add(fixo.Defasagem)
+	}
+	return NovoFixo(momento.AddDate(anos, meses, dias), hoje)
+}
@@ -0,0 +1,57 @@
+package relogio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetroativoUsaDefasagemMedidaNaCriacao(t *testing.T) {
+	agora := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+
+	casos := []struct {
+		nome     string
+		momento  time.Time
+		esperado bool
+	}{
+		{"dia anterior", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), true},
+		{"mesmo dia", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+		{"mesmo dia mais tarde", time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC), false},
+		{"ano anterior", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if obtido := Retroativo(NovoFixo(caso.momento, agora)); obtido != caso.esperado {
+				t.Fatalf("Retroativo = %v, esperava %v", obtido, caso.esperado)
+			}
+		})
+	}
+}
+
+func TestRetroativoRelogiosSemDefasagem(t *testing.T) {
+	if Retroativo(Sistema{}) {
+		t.Fatal("o relógio do sistema nunca é retroativo")
+	}
+	if Retroativo(Fixo{Momento: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}) {
+		t.Fatal("um relógio fixo sem defasagem medida não é retroativo")
+	}
+}
+
+func TestAvancarPreservaDiaCorrenteDoRelogioRetroativo(t *testing.T) {
+	agora := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+	base := NovoFixo(time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC), agora)
+
+	avancado := Avancar(base, 0, 1, 0)
+	if esperado := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC); !avancado.Agora().Equal(esperado) {
+		t.Fatalf("Agora = %v, esperava %v", avancado.Agora(), esperado)
+	}
+	if !Retroativo(avancado) {
+		t.Fatal("um mês depois da referência ainda é anterior ao dia corrente")
+	}
+	if Retroativo(Avancar(base, 0, 2, 0)) {
+		t.Fatal("o relógio avançado além do dia corrente não deve ser retroativo")
+	}
+	if Retroativo(Avancar(Sistema{}, 0, 3, 0)) {
+		t.Fatal("o relógio do sistema avançado aponta para o futuro")
+	}
+}
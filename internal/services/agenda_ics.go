@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AgendaICSService gera o feed iCalendar (.ics) com as datas com e de pagamento dos ativos
+type AgendaICSService struct {
+	dataCom *DataComService
+}
+
+// NewAgendaICSService cria um novo serviço de exportação iCalendar
+func NewAgendaICSService(dataCom *DataComService) *AgendaICSService {
+	return &AgendaICSService{
+		dataCom: dataCom,
+	}
+}
+
+// AtivoAgenda identifica um ativo incluído no feed
+type AtivoAgenda struct {
+	Ticker    string
+	TipoAtivo string // FII ou ACAO
+	Origem    string // Carteira ou Recomendado
+}
+
+// eventoICS representa um evento de dia inteiro do feed
+type eventoICS struct {
+	uid       string
+	data      time.Time
+	titulo    string
+	descricao string
+	alarmes   []alarmeICS
+}
+
+// alarmeICS representa um lembrete de um evento
+type alarmeICS struct {
+	diasAntes int
+	descricao string
+}
+
+// GerarICS analisa a data com de cada ativo e monta o feed com os eventos de data com
+// (com lembretes nas janelas de ALERTA e EVITAR) e de pagamento já anunciados.
+// Os UIDs dependem apenas do ticker, do tipo do evento e do mês de referência, de forma que
+// uma data com projetada que mude de dia atualize o evento existente em vez de duplicá-lo.
+func (s *AgendaICSService) GerarICS(ativos []AtivoAgenda, agora time.Time) string {
+	var eventos []eventoICS
+	vistos := make(map[string]bool)
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location())
+
+	// Analisar todos os ativos em paralelo; o laço abaixo reaproveita o cache
+	lote := make([]AtivoDataCom, 0, len(ativos))
+	for _, ativo := range ativos {
+		lote = append(lote, AtivoDataCom{Ticker: ativo.Ticker, TipoAtivo: ativo.TipoAtivo})
+	}
+	s.dataCom.AnalisarDataComTickers(lote)
+
+	for _, ativo := range ativos {
+		chaveAtivo := ativo.TipoAtivo + ativo.Ticker
+		if vistos[chaveAtivo] {
+			continue
+		}
+		vistos[chaveAtivo] = true
+
+		analise, err := s.dataCom.AnalisarDataComTicker(ativo.Ticker, ativo.TipoAtivo)
+		if err != nil {
+			log.Printf("Agenda: análise de data com indisponível para %s: %v", ativo.Ticker, err)
+			continue
+		}
+
+		// Evento da próxima data com
+		if !analise.ProximaDataCom.IsZero() && !analise.ProximaDataCom.Before(hoje) {
+			regra := s.dataCom.RegraDataCom(ativo.TipoAtivo)
+			eventos = append(eventos, eventoICS{
+				uid:    fmt.Sprintf("datacom-%s-%s@calculadora-investimentos", ativo.Ticker, analise.ProximaDataCom.Format("200601")),
+				data:   analise.ProximaDataCom,
+				titulo: fmt.Sprintf("Data com %s", ativo.Ticker),
+				descricao: fmt.Sprintf("%s (%s). Status atual: %s - %s",
+					ativo.Ticker, ativo.Origem, analise.StatusCompra, analise.MensagemStatus),
+				alarmes: []alarmeICS{
+					{
+						diasAntes: s.diasCorridosAntes(analise.ProximaDataCom, regra.DiasAlerta),
+						descricao: fmt.Sprintf("ALERTA: data com de %s em %d pregões", ativo.Ticker, regra.DiasAlerta),
+					},
+					{
+						diasAntes: s.diasCorridosAntes(analise.ProximaDataCom, regra.DiasEvitar),
+						descricao: fmt.Sprintf("EVITAR compra de %s: data com em %d pregões", ativo.Ticker, regra.DiasEvitar),
+					},
+				},
+			})
+		}
+
+		// Eventos de pagamento já anunciados
+		for _, div := range analise.UltimosDividendos {
+			pagamento := div.DataPagamentoParsed
+			if pagamento.IsZero() || pagamento.Before(hoje) {
+				continue
+			}
+
+			eventos = append(eventos, eventoICS{
+				uid:       fmt.Sprintf("pagamento-%s-%s-%s@calculadora-investimentos", ativo.Ticker, strings.ToLower(normalizarTipoProvento(div.Tipo)), div.DataParsed.Format("20060102")),
+				data:      pagamento,
+				titulo:    fmt.Sprintf("Pagamento %s %s", normalizarTipoProvento(div.Tipo), ativo.Ticker),
+				descricao: fmt.Sprintf("%s de R$ %s por cota (data com %s)", normalizarTipoProvento(div.Tipo), div.Valor, div.DataCom),
+			})
+		}
+	}
+
+	sort.SliceStable(eventos, func(i, j int) bool {
+		if !eventos[i].data.Equal(eventos[j].data) {
+			return eventos[i].data.Before(eventos[j].data)
+		}
+		return eventos[i].uid < eventos[j].uid
+	})
+
+	log.Printf("Agenda iCalendar gerada com %d eventos para %d ativos", len(eventos), len(vistos))
+
+	return montarICS(eventos, agora)
+}
+
+// diasCorridosAntes converte uma janela em pregões antes da data com para dias corridos,
+// que é a unidade usada pelos lembretes do iCalendar
+func (s *AgendaICSService) diasCorridosAntes(dataCom time.Time, pregoes int) int {
+	data := dataCom
+	for i := 0; i < pregoes; i++ {
+		data = s.dataCom.Calendario.DiaDePregaoAnterior(data)
+	}
+	return int(dataCom.Sub(data).Hours()/24 + 0.5)
+}
+
+// montarICS serializa os eventos no formato iCalendar (RFC 5545)
+func montarICS(eventos []eventoICS, agora time.Time) string {
+	var linhas []string
+	linhas = append(linhas,
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Calculadora de Investimentos//Datas Com//PT-BR",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Datas com e pagamentos",
+	)
+
+	dtstamp := agora.UTC().Format("20060102T150405Z")
+	for _, evento := range eventos {
+		linhas = append(linhas,
+			"BEGIN:VEVENT",
+			"UID:"+evento.uid,
+			"DTSTAMP:"+dtstamp,
+			"DTSTART;VALUE=DATE:"+evento.data.Format("20060102"),
+			"DTEND;VALUE=DATE:"+evento.data.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+escaparTextoICS(evento.titulo),
+			"DESCRIPTION:"+escaparTextoICS(evento.descricao),
+			"TRANSP:TRANSPARENT",
+		)
+		for _, alarme := range evento.alarmes {
+			linhas = append(linhas,
+				"BEGIN:VALARM",
+				"ACTION:DISPLAY",
+				fmt.Sprintf("TRIGGER:-P%dD", alarme.diasAntes),
+				"DESCRIPTION:"+escaparTextoICS(alarme.descricao),
+				"END:VALARM",
+			)
+		}
+		linhas = append(linhas, "END:VEVENT")
+	}
+	linhas = append(linhas, "END:VCALENDAR")
+
+	var ics strings.Builder
+	for _, linha := range linhas {
+		ics.WriteString(dobrarLinhaICS(linha))
+		ics.WriteString("\r\n")
+	}
+	return ics.String()
+}
+
+// escaparTextoICS escapa os caracteres especiais de valores de texto
+func escaparTextoICS(texto string) string {
+	substituto := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return substituto.Replace(texto)
+}
+
+// dobrarLinhaICS quebra linhas com mais de 75 octetos, sem dividir caracteres UTF-8
+func dobrarLinhaICS(linha string) string {
+	if len(linha) <= 75 {
+		return linha
+	}
+
+	var resultado strings.Builder
+	tamanho := 0
+	limite := 75
+	for _, r := range linha {
+		bytesRune := len(string(r))
+		if tamanho+bytesRune > limite {
+			resultado.WriteString("\r\n ")
+			tamanho = 0
+			limite = 74 // Linhas de continuação começam com um espaço
+		}
+		resultado.WriteRune(r)
+		tamanho += bytesRune
+	}
+	return resultado.String()
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
 	"log"
+	"sort"
 	"strconv"
 )
 
@@ -12,6 +14,11 @@ type Calculadora struct {
 	recomendacaoService *RecomendadoraService
 	otimizadoraService  *OtimizadoraService
 	dividendoService    *DividendoService
+	tributacaoService   *TributacaoService
+	emissorService      *EmissorService
+	setorService        *SetorService
+	precoTetoService    *PrecoTetoService
+	relogio             relogio.Relogio // Define a data de referência dos cálculos
 }
 
 // NewCalculadora cria uma nova instância do serviço de calculadora
@@ -20,152 +27,257 @@ func NewCalculadora(
 	recomendacaoService *RecomendadoraService,
 	otimizadoraService *OtimizadoraService,
 	dividendoService *DividendoService,
+	tributacaoService *TributacaoService,
+	emissorService *EmissorService,
+	setorService *SetorService,
+	precoTetoService *PrecoTetoService,
+	rel relogio.Relogio,
 ) *Calculadora {
 	return &Calculadora{
 		distribuicaoService: distribuicaoService,
 		recomendacaoService: recomendacaoService,
 		otimizadoraService:  otimizadoraService,
 		dividendoService:    dividendoService,
+		tributacaoService:   tributacaoService,
+		emissorService:      emissorService,
+		setorService:        setorService,
+		precoTetoService:    precoTetoService,
+		relogio:             rel,
 	}
 }
 
+// comRelogio retorna uma cópia da calculadora cujos serviços dependentes de data (data com,
+// preço-teto e janelas de proventos) usam o relógio informado. Os serviços originais não são
+// alterados, e os sem relógio continuam compartilhados.
+func (c *Calculadora) comRelogio(rel relogio.Relogio) *Calculadora {
+	copia := *c
+	copia.relogio = rel
+
+	// Os serviços compartilhados entre a calculadora, a recomendadora e a otimizadora continuam
+	// compartilhados nas cópias
+	copiasPrecoTeto := make(map[*PrecoTetoService]*PrecoTetoService)
+	precoTetoNoRelogio := func(original *PrecoTetoService) *PrecoTetoService {
+		if original == nil {
+			return nil
+		}
+		if servico, existe := copiasPrecoTeto[original]; existe {
+			return servico
+		}
+		servico := *original
+		servico.relogio = rel
+		copiasPrecoTeto[original] = &servico
+		return &servico
+	}
+	copiasDataCom := make(map[*DataComService]*DataComService)
+	dataComNoRelogio := func(original *DataComService) *DataComService {
+		if original == nil {
+			return nil
+		}
+		if servico, existe := copiasDataCom[original]; existe {
+			return servico
+		}
+		servico := *original
+		servico.Relogio = rel
+		copiasDataCom[original] = &servico
+		return &servico
+	}
+
+	copia.precoTetoService = precoTetoNoRelogio(c.precoTetoService)
+	if c.recomendacaoService != nil {
+		recomendadora := *c.recomendacaoService
+		recomendadora.dataComService = dataComNoRelogio(recomendadora.dataComService)
+		recomendadora.precoTetoService = precoTetoNoRelogio(recomendadora.precoTetoService)
+		copia.recomendacaoService = &recomendadora
+	}
+	if c.otimizadoraService != nil {
+		otimizadora := *c.otimizadoraService
+		otimizadora.dataComService = dataComNoRelogio(otimizadora.dataComService)
+		copia.otimizadoraService = &otimizadora
+	}
+
+	return &copia
+}
+
+// EntradasCalculo reúne as carteiras atuais e as listas de recomendados usadas no cálculo.
+// As carteiras e os recomendados de renda variável são indexados pelo nome da classe (Classe.Nome).
+type EntradasCalculo struct {
+	Carteiras         map[string][]models.Ativo
+	Recomendados      map[string][]models.AtivoRecomendado
+	CarteiraRendaFixa *models.CarteiraRendaFixa
+	// Aportes em renda fixa de meses anteriores do planejamento, ainda fora da carteira
+	RendaFixaPlanejada float64 // Destinados à classe de renda fixa
+	ReservaPlanejada   float64 // Destinados à reserva de emergência (liquidez diária)
+}
+
 // CalcularRecomendacoes calcula as recomendações de investimento
 func (c *Calculadora) CalcularRecomendacoes(
 	valorInvestimento float64,
 	tiposInvestimento models.TiposInvestimento,
-	carteiraFII *models.CarteiraDados,
-	carteiraAcao *models.CarteiraAcoes,
-	carteiraETF *models.CarteiraETFs,
-	carteiraRendaFixa *models.CarteiraRendaFixa,
-	recomendadosFII []models.FIIRecomendado,
-	recomendadosAcao []models.AcaoRecomendada,
-	recomendadosETF []models.ETFRecomendado,
+	entradas *EntradasCalculo,
+	metaReserva float64,
 ) (*models.TemplateDados, error) {
-	// Calcular valor total das carteiras
-	valorTotalCarteiraFII := c.calcularValorTotalCarteiraFII(carteiraFII)
-	valorTotalCarteiraAcao := c.calcularValorTotalCarteiraAcao(carteiraAcao)
-	valorTotalCarteiraETF := c.calcularValorTotalCarteiraETF(carteiraETF)
-	valorTotalCarteiraRendaFixa := c.calcularValorTotalCarteiraRendaFixa(carteiraRendaFixa)
-	valorTotalCarteira := valorTotalCarteiraFII + valorTotalCarteiraAcao + valorTotalCarteiraETF + valorTotalCarteiraRendaFixa
+	carteiraRendaFixa := entradas.CarteiraRendaFixa
+
+	// A reserva de emergência (renda fixa com liquidez diária até a meta) fica fora da carteira de investimentos
+	reserva := c.distribuicaoService.AvaliarReservaEmergencia(carteiraRendaFixa, entradas.ReservaPlanejada, metaReserva)
+
+	// Calcular o valor atual de cada classe
+	valoresAtuais := make(map[string]float64)
+	for _, classe := range ClassesRendaVariavel {
+		valoresAtuais[classe.Nome] = valorTotalAtivos(entradas.Carteiras[classe.Nome])
+	}
+	valoresAtuais[NomeRendaFixa] = c.calcularValorTotalCarteiraRendaFixa(carteiraRendaFixa) +
+		entradas.RendaFixaPlanejada + entradas.ReservaPlanejada - reserva.ValorAtual
+	valorTotalCarteira := somarClasses(valoresAtuais)
 
 	// Calcular a distribuição atual
-	distribuicaoAtual := c.distribuicaoService.CalcularDistribuicaoAtual(
-		valorTotalCarteiraFII,
-		valorTotalCarteiraAcao,
-		valorTotalCarteiraETF,
-		valorTotalCarteiraRendaFixa,
-		valorTotalCarteira,
-	)
+	distribuicaoAtual := c.distribuicaoService.CalcularDistribuicaoAtual(valoresAtuais, valorTotalCarteira)
 
 	// Calcular a distribuição ideal (nova distribuição)
 	distribuicaoIdeal := c.distribuicaoService.CalcularDistribuicaoIdeal(tiposInvestimento)
 
-	// Criar mapas com valores atuais
-	valoresAtuais := map[string]float64{
-		"FIIs":      valorTotalCarteiraFII,
-		"Ações":     valorTotalCarteiraAcao,
-		"ETFs":      valorTotalCarteiraETF,
-		"RendaFixa": valorTotalCarteiraRendaFixa,
-	}
+	// Ajustar a distribuição ideal à meta de exposição a dólar e ouro, quando configurada
+	fracoesEstrangeiras := c.distribuicaoService.FracoesEstrangeiras(entradas.Recomendados)
+	distribuicaoIdeal, metaCambialAtingivel := c.distribuicaoService.AplicarMetaExposicaoEstrangeira(
+		distribuicaoIdeal, fracoesEstrangeiras, c.distribuicaoService.Config.MetaExposicaoEstrangeira)
 
 	// Usar a nova função que prioriza por distância percentual
-	valorParaFII, valorParaAcao, valorParaETF, valorParaRendaFixa := c.distribuicaoService.DistribuirInvestimentoComPrioridade(
+	valoresPorClasse, valorParaReserva := c.distribuicaoService.DistribuirInvestimentoComPrioridade(
 		valorInvestimento,
 		valoresAtuais,
 		distribuicaoAtual, // Este já contém os percentuais atuais
 		distribuicaoIdeal,
 		valorTotalCarteira,
+		reserva.Meta-reserva.ValorAtual,
 	)
-
-	// Gerar recomendações
-	recomendacoesFII := c.recomendacaoService.GerarRecomendacoesFII(carteiraFII, recomendadosFII, valorParaFII, valorTotalCarteiraFII)
-	recomendacoesAcao := c.recomendacaoService.GerarRecomendacoesAcao(carteiraAcao, recomendadosAcao, valorParaAcao, valorTotalCarteiraAcao)
-	recomendacoesETF := c.recomendacaoService.GerarRecomendacoesETF(carteiraETF, recomendadosETF, valorParaETF, valorTotalCarteiraETF)
-
-	// Calcular valores totais para o template
-	valorTotalRecomendadoFII := 0.0
-	for _, rec := range recomendacoesFII {
-		valorTotalRecomendadoFII += rec.ValorCompra
+	registrarAporteReserva(reserva, valorParaReserva)
+
+	// Compras sugeridas em cada classe de renda variável
+	var classes []*ComprasClasse
+	for _, classe := range ClassesRendaVariavel {
+		classes = append(classes, &ComprasClasse{
+			Classe:       classe,
+			Recomendados: entradas.Recomendados[classe.Nome],
+			Carteira:     entradas.Carteiras[classe.Nome],
+		})
 	}
 
-	valorTotalRecomendadoAcao := 0.0
-	for _, rec := range recomendacoesAcao {
-		valorTotalRecomendadoAcao += rec.ValorCompra
-	}
+	// Analisar as datas com de todos os candidatos de uma vez (em paralelo e com cache)
+	c.recomendacaoService.PreCarregarDataCom(classes)
 
-	valorTotalRecomendadoETF := 0.0
-	for _, rec := range recomendacoesETF {
-		valorTotalRecomendadoETF += rec.ValorCompra
+	// Gerar recomendações
+	var inclinacoesPVPFII []models.InclinacaoPVP
+	for _, compras := range classes {
+		nome := compras.Classe.Nome
+		if compras.Classe.InclinarPorPVP {
+			compras.Recomendados, inclinacoesPVPFII = c.distribuicaoService.InclinarPesosPorPVP(compras.Recomendados, compras.Carteira)
+		}
+		if compras.Classe.AvaliarPrecoTeto {
+			compras.PrecosTeto = c.precoTetoService.AvaliarRecomendados(compras.Recomendados, compras.Carteira)
+		}
+		compras.Recomendacoes = c.recomendacaoService.GerarRecomendacoes(
+			compras.Classe, compras.Carteira, compras.Recomendados, compras.PrecosTeto, valoresPorClasse[nome], valoresAtuais[nome])
+		compras.ValorTotal = somarCompras(compras.Recomendacoes)
+		compras.ValorFuturo = valoresAtuais[nome] + valoresPorClasse[nome]
 	}
 
-	valorTotalRecomendadoFixa := valorParaRendaFixa
-
 	// Calcular o valor total recomendado
-	valorTotalRecomendado := valorTotalRecomendadoFII + valorTotalRecomendadoAcao + valorTotalRecomendadoETF + valorTotalRecomendadoFixa
-	valorSobra := valorInvestimento - valorTotalRecomendado
+	valoresRecomendados := map[string]float64{NomeRendaFixa: valoresPorClasse[NomeRendaFixa]}
+	for _, compras := range classes {
+		valoresRecomendados[compras.Classe.Nome] = compras.ValorTotal
+	}
+	valorSobra := valorInvestimento - valorParaReserva - somarClasses(valoresRecomendados)
 
 	// Otimizar as sobras
-	valorRestante := c.otimizadoraService.OtimizarSobras(
-		valorSobra,
-		recomendadosFII,
-		recomendadosAcao,
-		recomendadosETF,
-		&recomendacoesFII,
-		&recomendacoesAcao,
-		&recomendacoesETF,
-		&valorTotalRecomendadoFII,
-		&valorTotalRecomendadoAcao,
-		&valorTotalRecomendadoETF,
-	)
-
-	// Recalcular os totais após otimização
-	valorTotalRecomendado = valorTotalRecomendadoFII + valorTotalRecomendadoAcao + valorTotalRecomendadoETF + valorTotalRecomendadoFixa
+	valorRestante := c.otimizadoraService.OtimizarSobras(valorSobra, classes)
+
+	// Status de data com com as quantidades finais de cada compra
+	c.recomendacaoService.AvaliarComprasDataCom(classes)
+
+	// Valores recomendados após as sobras, carteiras finais e valores finais de cada classe
+	recomendacoes := make(map[string][]models.RecomendacaoCompra)
+	carteiraFinal := make(map[string][]models.AtivoCarteiraFinal)
+	for _, compras := range classes {
+		nome := compras.Classe.Nome
+		recomendacoes[nome] = compras.Recomendacoes
+		valoresRecomendados[nome] = compras.ValorTotal
+		carteiraFinal[nome] = c.recomendacaoService.ObterCarteiraFinal(
+			compras.Classe, compras.Carteira, compras.Recomendacoes, compras.Recomendados, valoresAtuais[nome], compras.ValorTotal)
+	}
 
 	// Calcula os percentuais de cada classe com base no valor total do investimento
-	percentualRecomendadoFII := (valorTotalRecomendadoFII / valorInvestimento) * 100
-	percentualRecomendadoAcao := (valorTotalRecomendadoAcao / valorInvestimento) * 100
-	percentualRecomendadoETF := (valorTotalRecomendadoETF / valorInvestimento) * 100
-	percentualRecomendadoFixa := (valorTotalRecomendadoFixa / valorInvestimento) * 100
+	percentuaisRecomendados := make(map[string]float64)
+	valoresFinais := make(map[string]float64)
+	for _, nome := range ClassesInvestimento {
+		percentuaisRecomendados[nome] = (valoresRecomendados[nome] / valorInvestimento) * 100
+		valoresFinais[nome] = valoresAtuais[nome] + valoresRecomendados[nome]
+	}
+	valorTotalFinal := somarClasses(valoresFinais)
 
-	// Obter carteira final de FIIs
-	carteiraFinalFII := c.recomendacaoService.ObterCarteiraFinalFII(carteiraFII, recomendacoesFII, recomendadosFII, valorTotalCarteiraFII, valorTotalRecomendadoFII)
+	// Calcular a distribuição final
+	distribuicaoFinal := make(map[string]float64)
+	for _, nome := range ClassesInvestimento {
+		distribuicaoFinal[nome] = (valoresFinais[nome] / valorTotalFinal) * 100
+	}
 
-	// Obter carteira final de ações
-	carteiraFinalAcao := c.recomendacaoService.ObterCarteiraFinalAcao(carteiraAcao, recomendacoesAcao, recomendadosAcao, valorTotalCarteiraAcao, valorTotalRecomendadoAcao)
+	carteiraFinalFII := carteiraFinal[ClasseFII.Nome]
+	carteiraFinalAcao := carteiraFinal[ClasseAcao.Nome]
+	valorTotalFinalFII := valoresFinais[ClasseFII.Nome]
+	valorTotalFinalAcao := valoresFinais[ClasseAcao.Nome]
 
-	// Obter carteira final de ETFs
-	carteiraFinalETF := c.recomendacaoService.ObterCarteiraFinalETF(carteiraETF, recomendacoesETF, recomendadosETF, valorTotalCarteiraETF, valorTotalRecomendadoETF)
+	// Classificar as ações da carteira final por setor e subsetor
+	for i := range carteiraFinalAcao {
+		classificacao := c.setorService.Classificacao(carteiraFinalAcao[i].Ticker)
+		carteiraFinalAcao[i].Setor = classificacao.Setor
+		carteiraFinalAcao[i].Subsetor = classificacao.Subsetor
+	}
 
-	valorTotalFinalFII := valorTotalCarteiraFII + valorTotalRecomendadoFII
-	valorTotalFinalAcao := valorTotalCarteiraAcao + valorTotalRecomendadoAcao
-	valorTotalFinalETF := valorTotalCarteiraETF + valorTotalRecomendadoETF
-	valorTotalFinalFixa := valorTotalCarteiraRendaFixa + valorTotalRecomendadoFixa
-	valorTotalFinal := valorTotalFinalFII + valorTotalFinalAcao + valorTotalFinalETF + valorTotalFinalFixa
+	// Resumir a exposição internacional (BDRs e ETFs de índices externos)
+	exposicaoInternacional := c.resumirExposicaoInternacional(carteiraFinal[ClasseBDR.Nome], carteiraFinal[ClasseETF.Nome], valorTotalFinal)
 
-	// Calcular a distribuição final
-	distribuicaoFinal := map[string]float64{
-		"FIIs":      (valorTotalFinalFII / valorTotalFinal) * 100,
-		"Ações":     (valorTotalFinalAcao / valorTotalFinal) * 100,
-		"ETFs":      (valorTotalFinalETF / valorTotalFinal) * 100,
-		"RendaFixa": (valorTotalFinalFixa / valorTotalFinal) * 100,
-	}
+	// Agregar a carteira final por emissor (tickers da mesma empresa e FIIs investidos por FoFs)
+	exposicaoEmissores := c.emissorService.AnalisarExposicaoEmissores(carteiraFinal, valorTotalFinal)
 
-	// Calcular DY médio ponderado e dividendos mensais totais para FIIs
-	dyPonderadoFII := 0.0
-	dividendosMensaisTotaisFII := 0.0
+	// Separar a carteira final entre reais, dólar e ouro
+	exposicaoCambial := c.distribuicaoService.AnalisarExposicaoCambial(carteiraFinal, valoresFinais[NomeRendaFixa], valorTotalFinal)
+	exposicaoCambial.MetaAtingivel = metaCambialAtingivel
+	exposicaoCambial.PercentualIdeal = exposicaoPrevista(distribuicaoIdeal, fracoesEstrangeiras)
 
+	// Calcular DY médio ponderado para FIIs
+	dyPonderadoFII := 0.0
 	for _, fii := range carteiraFinalFII {
 		dyPonderadoFII += fii.DY * (fii.ValorTotal / valorTotalFinalFII)
-		dividendosMensaisTotaisFII += fii.DividendosMensais
 	}
 
 	// Calcular DY médio ponderado para ações
 	dyPonderadoAcao := 0.0
-	dividendosAnuaisTotalAcao := 0.0
-
 	for _, acao := range carteiraFinalAcao {
 		dyPonderadoAcao += acao.DY * (acao.ValorTotal / valorTotalFinalAcao)
-		dividendosAnuaisTotalAcao += (acao.DY / 100.0) * acao.ValorTotal
+	}
+
+	// Estimar a renda bruta e líquida de IR de cada classe conforme o tipo de proventos que ela paga
+	rendaPorClasse := make(map[string]models.ResumoRendaClasse)
+	rendimentosFII := make(map[string][]models.FIICarteiraFinalComRendimento)
+	rendimentosAcao := make(map[string][]models.AcaoCarteiraFinalComRendimento)
+	for _, classe := range ClassesRendaVariavel {
+		switch classe.TipoProventos {
+		case "FII":
+			rendimentosFII[classe.Nome] = c.calcularRendimentosFIIs(carteiraFinal[classe.Nome])
+			rendaPorClasse[classe.Nome] = resumirRendaFIIs(rendimentosFII[classe.Nome])
+		case "ACAO":
+			rendimentosAcao[classe.Nome] = c.calcularRendimentosAcoes(carteiraFinal[classe.Nome])
+			rendaPorClasse[classe.Nome] = resumirRendaAcoes(rendimentosAcao[classe.Nome])
+		}
+	}
+	rendaTotal := somarRendas(rendaPorClasse)
+
+	// Separar dividendos e JCP das ações
+	carteiraFinalAcaoComRendimento := rendimentosAcao[ClasseAcao.Nome]
+	totalDividendosAnuaisAcao := 0.0
+	totalJCPAnuaisAcao := 0.0
+	for _, acao := range carteiraFinalAcaoComRendimento {
+		totalDividendosAnuaisAcao += acao.DividendosAnuais
+		totalJCPAnuaisAcao += acao.JCPAnuais
 	}
 
 	// Calcular resumos por tipo e segmento de FII
@@ -193,79 +305,63 @@ func (c *Calculadora) CalcularRecomendacoes(
 		segmentoFII[fii.Segmento]["dividendos"] += fii.DividendosMensais
 	}
 
-	// Calcular rendimentos dos FIIs usando o novo serviço
-	carteiraFinalFIIComRendimento := c.calcularRendimentosFIIs(carteiraFinalFII)
-
-	// Calcular totais de rendimentos
-	totalRendimentosMensaisFII := 0.0
-	for _, fii := range carteiraFinalFIIComRendimento {
-		totalRendimentosMensaisFII += fii.RendimentoMensal
-	}
-	totalRendimentosAnuaisFII := totalRendimentosMensaisFII * 12
-
-	// Calcular yield médio da carteira
-	yieldMedioCarteiraFII := 0.0
-	if valorTotalFinalFII > 0 {
-		yieldMedioCarteiraFII = (totalRendimentosAnuaisFII / valorTotalFinalFII) * 100
-	}
+	// Calcular resumos por setor e subsetor das ações
+	setorAcao, subsetorAcao := c.setorService.AnalisarSetores(carteiraFinalAcao, valorTotalFinalAcao)
 
 	// Preparar dados para o template
 	dados := &models.TemplateDados{
 		ValorInvestimento:                 valorInvestimento,
 		ValorTotalCarteira:                valorTotalCarteira,
-		ValorTotalCarteiraFII:             valorTotalCarteiraFII,
-		ValorTotalCarteiraAcao:            valorTotalCarteiraAcao,
-		ValorTotalCarteiraETF:             valorTotalCarteiraETF,
-		ValorTotalCarteiraRendaFixa:       valorTotalCarteiraRendaFixa,
-		ValorFuturoCarteira:               valorTotalCarteira + valorInvestimento,
-		RecomendacoesFII:                  recomendacoesFII,
-		RecomendacoesAcao:                 recomendacoesAcao,
-		RecomendacoesETF:                  recomendacoesETF,
-		ValorTotalRecomendadoFII:          valorTotalRecomendadoFII,
-		ValorTotalRecomendadoAcao:         valorTotalRecomendadoAcao,
-		ValorTotalRecomendadoETF:          valorTotalRecomendadoETF,
-		ValorTotalRecomendadoFixa:         valorTotalRecomendadoFixa,
-		PercentualRecomendadoFII:          percentualRecomendadoFII,
-		PercentualRecomendadoAcao:         percentualRecomendadoAcao,
-		PercentualRecomendadoETF:          percentualRecomendadoETF,
-		PercentualRecomendadoFixa:         percentualRecomendadoFixa,
+		ValorFuturoCarteira:               valorTotalCarteira + valorInvestimento - valorParaReserva,
+		ValoresCarteira:                   valoresAtuais,
+		ValoresRecomendados:               valoresRecomendados,
+		PercentuaisRecomendados:           percentuaisRecomendados,
+		ValoresFinais:                     valoresFinais,
+		Recomendacoes:                     recomendacoes,
+		CarteirasFinais:                   carteiraFinal,
 		ValorRestante:                     valorRestante,
-		CarteiraFinalFII:                  carteiraFinalFII,
-		CarteiraFinalAcao:                 carteiraFinalAcao,
-		CarteiraFinalETF:                  carteiraFinalETF,
-		ValorTotalFinalFII:                valorTotalFinalFII,
-		ValorTotalFinalAcao:               valorTotalFinalAcao,
-		ValorTotalFinalETF:                valorTotalFinalETF,
-		ValorTotalFinalFixa:               valorTotalFinalFixa,
 		ValorTotalFinal:                   valorTotalFinal,
 		DYMedioPonderadoFII:               dyPonderadoFII,
 		DYMedioPonderadoAcao:              dyPonderadoAcao,
-		DividendosMensaisTotaisFII:        dividendosMensaisTotaisFII,
-		DividendosAnuaisTotalFII:          dividendosMensaisTotaisFII * 12,
-		DividendosAnuaisTotalAcao:         dividendosAnuaisTotalAcao,
 		TipoFII:                           tipoFII,
 		SegmentoFII:                       segmentoFII,
+		SetorAcao:                         setorAcao,
+		SubsetorAcao:                      subsetorAcao,
+		InclinacoesPVPFII:                 inclinacoesPVPFII,
 		DistribuicaoAtual:                 distribuicaoAtual,
 		DistribuicaoFinal:                 distribuicaoFinal,
 		DistribuicaoIdeal:                 distribuicaoIdeal,
 		AtivosRendaFixa:                   carteiraRendaFixa.Data,
-		PercentualRendaFixaNoInvestimento: (valorTotalRecomendadoFixa / valorInvestimento) * 100,
+		PercentualRendaFixaNoInvestimento: percentuaisRecomendados[NomeRendaFixa],
 		// Novos campos
-		CarteiraFinalFIIComRendimento: carteiraFinalFIIComRendimento,
-		TotalRendimentosMensaisFII:    totalRendimentosMensaisFII,
-		TotalRendimentosAnuaisFII:     totalRendimentosAnuaisFII,
-		YieldMedioCarteiraFII:         yieldMedioCarteiraFII,
+		CarteiraFinalFIIComRendimento: rendimentosFII[ClasseFII.Nome],
+		EstimadorProventos:            DescricaoEstimador(c.dividendoService.Estimador),
+		// Proventos das ações
+		CarteiraFinalAcaoComRendimento: carteiraFinalAcaoComRendimento,
+		TotalDividendosAnuaisAcao:      totalDividendosAnuaisAcao,
+		TotalJCPAnuaisAcao:             totalJCPAnuaisAcao,
+		// Renda bruta e líquida de IR
+		RendaPorClasse:         rendaPorClasse,
+		RendaTotal:             rendaTotal,
+		ReservaEmergencia:      reserva,
+		ExposicaoInternacional: exposicaoInternacional,
+		ExposicaoCambial:       exposicaoCambial,
+		ExposicaoEmissores:     exposicaoEmissores,
 	}
+	preencherCamposPorClasse(dados)
 
 	return dados, nil
 }
 
-// calcularRendimentosFIIs calcula os rendimentos mensais dos FIIs
+// calcularRendimentosFIIs calcula os rendimentos mensais dos FIIs usando o estimador configurado
+// sobre o histórico de proventos. Sem histórico, usa o último dividendo informado pela API, exceto em
+// cálculos retroativos, em que esse dividendo seria posterior à data de referência.
 func (c *Calculadora) calcularRendimentosFIIs(carteiraFinal []models.FIICarteiraFinal) []models.FIICarteiraFinalComRendimento {
 	var carteiraComRendimento []models.FIICarteiraFinalComRendimento
 
-	log.Println("Iniciando cálculo de rendimentos dos FIIs...")
+	log.Printf("Iniciando cálculo de rendimentos dos FIIs (estimador: %s)...", c.dividendoService.Estimador)
 
+	hoje := c.relogio.Agora()
 	for _, fii := range carteiraFinal {
 		fiiComRendimento := models.FIICarteiraFinalComRendimento{
 			Ticker:     fii.Ticker,
@@ -281,60 +377,196 @@ func (c *Calculadora) calcularRendimentosFIIs(carteiraFinal []models.FIICarteira
 			PesoIdeal:  fii.PesoIdeal,
 		}
 
-		// Buscar o último dividendo
-		dividendo, err := c.dividendoService.ObterDividendoFII(fii.Ticker)
+		proventos, err := c.dividendoService.ObterHistoricoProventos(fii.Ticker, "FII")
 		if err != nil {
-			log.Printf("Erro ao obter dividendo para %s: %v. Usando valor 0", fii.Ticker, err)
-			fiiComRendimento.UltimoDividendo = 0
-			fiiComRendimento.RendimentoMensal = 0
-			fiiComRendimento.YieldOnCost = 0
+			log.Printf("Erro ao obter histórico de proventos para %s: %v", fii.Ticker, err)
+		}
+		proventos = proventosAte(proventos, hoje)
+
+		dividendoMensal, pagamentos, estimado := EstimarProventoMensal(proventos, c.dividendoService.Estimador, hoje)
+		if estimado {
+			fiiComRendimento.UltimoDividendo = proventos[0].Valor
+			fiiComRendimento.DividendoEstimado = dividendoMensal
+			fiiComRendimento.PagamentosUltimoAno = pagamentos
+			fiiComRendimento.Estimador = c.dividendoService.Estimador
+		} else if relogio.Retroativo(c.relogio) {
+			// O último dividendo da API é o atual, posterior à data de referência: sem estimativa
+			log.Printf("FII %s sem proventos até a data de referência. Usando valor 0", fii.Ticker)
+			fiiComRendimento.SemHistorico = true
 		} else {
-			fiiComRendimento.UltimoDividendo = dividendo
-			fiiComRendimento.RendimentoMensal = dividendo * float64(fii.Quantidade)
-
-			// Calcular Yield on Cost
-			if fii.Preco > 0 {
-				fiiComRendimento.YieldOnCost = (dividendo * 12 / fii.Preco) * 100
+			// Sem histórico recente: usar o último dividendo da API
+			dividendo, err := c.dividendoService.ObterDividendoFII(fii.Ticker)
+			if err != nil {
+				log.Printf("Erro ao obter dividendo para %s: %v. Usando valor 0", fii.Ticker, err)
+			} else {
+				fiiComRendimento.UltimoDividendo = dividendo
+				fiiComRendimento.DividendoEstimado = dividendo
+				fiiComRendimento.Estimador = EstimadorUltimo
 			}
+		}
+
+		fiiComRendimento.RendimentoMensal = fiiComRendimento.DividendoEstimado * float64(fii.Quantidade)
 
-			log.Printf("FII %s: %d cotas x R$ %.2f = R$ %.2f de rendimento mensal (YoC: %.2f%%)",
-				fii.Ticker, fii.Quantidade, dividendo, fiiComRendimento.RendimentoMensal, fiiComRendimento.YieldOnCost)
+		// Aplicar IR conforme a isenção do fundo
+		fiiComRendimento.IsentoIR = c.tributacaoService.FIIIsento(fii.Ticker)
+		fiiComRendimento.AliquotaIR = c.tributacaoService.AliquotaFII(fii.Ticker)
+		fiiComRendimento.RendimentoLiquido = ValorLiquido(fiiComRendimento.RendimentoMensal, fiiComRendimento.AliquotaIR)
+
+		// Calcular Yield on Cost
+		if fii.Preco > 0 {
+			fiiComRendimento.YieldOnCost = (fiiComRendimento.DividendoEstimado * 12 / fii.Preco) * 100
 		}
 
+		log.Printf("FII %s: %d cotas x R$ %.4f (%s) = R$ %.2f de rendimento mensal (YoC: %.2f%%)",
+			fii.Ticker, fii.Quantidade, fiiComRendimento.DividendoEstimado, fiiComRendimento.Estimador,
+			fiiComRendimento.RendimentoMensal, fiiComRendimento.YieldOnCost)
+
 		carteiraComRendimento = append(carteiraComRendimento, fiiComRendimento)
 	}
 
 	return carteiraComRendimento
 }
 
-// calcularValorTotalCarteiraFII calcula o valor total da carteira de FIIs
-func (c *Calculadora) calcularValorTotalCarteiraFII(carteira *models.CarteiraDados) float64 {
-	var total float64
-	for _, ativo := range carteira.Data {
-		total += ativo.CurrentPrice * float64(ativo.Quantity)
+// calcularRendimentosAcoes calcula os proventos anuais das ações usando o estimador configurado,
+// separadamente para dividendos e JCP e ajustado pela frequência de pagamento de cada um.
+// Sem histórico disponível, usa o DY informado como estimativa de dividendos, exceto em cálculos
+// retroativos, em que o DY informado seria posterior à data de referência.
+func (c *Calculadora) calcularRendimentosAcoes(carteiraFinal []models.AcaoCarteiraFinal) []models.AcaoCarteiraFinalComRendimento {
+	var carteiraComRendimento []models.AcaoCarteiraFinalComRendimento
+
+	log.Printf("Iniciando cálculo de proventos das ações (estimador: %s)...", c.dividendoService.Estimador)
+
+	hoje := c.relogio.Agora()
+	for _, acao := range carteiraFinal {
+		acaoComRendimento := models.AcaoCarteiraFinalComRendimento{
+			Ticker:     acao.Ticker,
+			Nome:       acao.Nome,
+			Preco:      acao.Preco,
+			DY:         acao.DY,
+			Quantidade: acao.Quantidade,
+			ValorTotal: acao.ValorTotal,
+		}
+
+		proventos, err := c.dividendoService.ObterHistoricoProventos(acao.Ticker, "ACAO")
+		if err != nil {
+			log.Printf("Erro ao obter proventos para %s: %v. Usando DY informado", acao.Ticker, err)
+		}
+		proventos = proventosAte(proventos, hoje)
+
+		dividendoMensal, pagamentosDividendos, temDividendos := EstimarProventoMensal(filtrarProventos(proventos, false), c.dividendoService.Estimador, hoje)
+		jcpMensal, pagamentosJCP, temJCP := EstimarProventoMensal(filtrarProventos(proventos, true), c.dividendoService.Estimador, hoje)
+
+		if temDividendos || temJCP {
+			acaoComRendimento.FonteHistorico = true
+			acaoComRendimento.Estimador = c.dividendoService.Estimador
+			acaoComRendimento.PagamentosUltimoAno = pagamentosDividendos + pagamentosJCP
+			acaoComRendimento.DividendosPorAcao12M = dividendoMensal * 12
+			acaoComRendimento.JCPPorAcao12M = jcpMensal * 12
+			acaoComRendimento.DividendosAnuais = acaoComRendimento.DividendosPorAcao12M * float64(acao.Quantidade)
+			acaoComRendimento.JCPAnuais = acaoComRendimento.JCPPorAcao12M * float64(acao.Quantidade)
+		} else if relogio.Retroativo(c.relogio) {
+			// O DY informado é o atual, posterior à data de referência: sem estimativa
+			acaoComRendimento.SemHistorico = true
+		} else {
+			acaoComRendimento.DividendosAnuais = (acao.DY / 100.0) * acao.ValorTotal
+		}
+
+		acaoComRendimento.UltimosProventos = proventos[:min(5, len(proventos))]
+		acaoComRendimento.RendimentoAnual = acaoComRendimento.DividendosAnuais + acaoComRendimento.JCPAnuais
+
+		// Aplicar IR: JCP tem retenção na fonte, dividendos seguem a alíquota configurada
+		acaoComRendimento.DividendosAnuaisLiquidos = ValorLiquido(acaoComRendimento.DividendosAnuais, c.tributacaoService.AliquotaProvento(models.TipoProventoDividendo))
+		acaoComRendimento.JCPAnuaisLiquidos = ValorLiquido(acaoComRendimento.JCPAnuais, c.tributacaoService.AliquotaProvento(models.TipoProventoJCP))
+		acaoComRendimento.RendimentoAnualLiquido = acaoComRendimento.DividendosAnuaisLiquidos + acaoComRendimento.JCPAnuaisLiquidos
+		if acao.ValorTotal > 0 {
+			acaoComRendimento.YieldOnCost = (acaoComRendimento.RendimentoAnual / acao.ValorTotal) * 100
+		}
+
+		log.Printf("Ação %s: %d ações, dividendos R$ %.2f + JCP R$ %.2f ao ano (histórico: %v)",
+			acao.Ticker, acao.Quantidade, acaoComRendimento.DividendosAnuais, acaoComRendimento.JCPAnuais, acaoComRendimento.FonteHistorico)
+
+		carteiraComRendimento = append(carteiraComRendimento, acaoComRendimento)
 	}
-	return total
+
+	return carteiraComRendimento
 }
 
-// calcularValorTotalCarteiraAcao calcula o valor total da carteira de ações
-func (c *Calculadora) calcularValorTotalCarteiraAcao(carteira *models.CarteiraAcoes) float64 {
-	var total float64
-	for _, ativo := range carteira.Data {
-		total += ativo.CurrentPrice * float64(ativo.Quantity)
+// resumirRendaFIIs soma a renda mensal bruta e líquida de IR dos FIIs
+func resumirRendaFIIs(fiis []models.FIICarteiraFinalComRendimento) models.ResumoRendaClasse {
+	mensalBruta, mensalLiquida := 0.0, 0.0
+	for _, fii := range fiis {
+		mensalBruta += fii.RendimentoMensal
+		mensalLiquida += fii.RendimentoLiquido
 	}
-	return total
+	return resumirRenda(mensalBruta, mensalLiquida)
 }
 
-// calcularValorTotalCarteiraETF calcula o valor total da carteira de ETFs
-func (c *Calculadora) calcularValorTotalCarteiraETF(carteira *models.CarteiraETFs) float64 {
-	var total float64
-	for _, ativo := range carteira.Data {
-		currentPrice, err := strconv.ParseFloat(ativo.CurrentPrice, 64)
-		if err == nil {
-			total += currentPrice * float64(ativo.Quantity)
+// resumirRendaAcoes soma os proventos anuais brutos e líquidos de IR das ações
+func resumirRendaAcoes(acoes []models.AcaoCarteiraFinalComRendimento) models.ResumoRendaClasse {
+	anualBruta, anualLiquida := 0.0, 0.0
+	for _, acao := range acoes {
+		anualBruta += acao.RendimentoAnual
+		anualLiquida += acao.RendimentoAnualLiquido
+	}
+	return resumirRenda(anualBruta/12, anualLiquida/12)
+}
+
+// somarRendas soma a renda de todas as classes, na ordem de ClassesInvestimento
+func somarRendas(rendaPorClasse map[string]models.ResumoRendaClasse) models.ResumoRendaClasse {
+	mensalBruta, mensalLiquida := 0.0, 0.0
+	for _, nome := range ClassesInvestimento {
+		mensalBruta += rendaPorClasse[nome].MensalBruta
+		mensalLiquida += rendaPorClasse[nome].MensalLiquida
+	}
+	return resumirRenda(mensalBruta, mensalLiquida)
+}
+
+// resumirRenda monta o resumo de renda bruta e líquida a partir dos valores mensais
+func resumirRenda(mensalBruta, mensalLiquida float64) models.ResumoRendaClasse {
+	return models.ResumoRendaClasse{
+		MensalBruta:   mensalBruta,
+		MensalLiquida: mensalLiquida,
+		AnualBruta:    mensalBruta * 12,
+		AnualLiquida:  mensalLiquida * 12,
+		ImpostoAnual:  (mensalBruta - mensalLiquida) * 12,
+	}
+}
+
+// resumirExposicaoInternacional soma, na carteira final, os BDRs e os ETFs com exposição ao dólar
+func (c *Calculadora) resumirExposicaoInternacional(carteiraFinalBDR, carteiraFinalETF []models.ETFCarteiraFinal, valorTotalFinal float64) *models.ExposicaoInternacional {
+	exposicao := &models.ExposicaoInternacional{}
+
+	adicionar := func(ativo models.ETFCarteiraFinal, classe string) {
+		exposicao.Ativos = append(exposicao.Ativos, models.AtivoInternacional{
+			Ticker:     ativo.Ticker,
+			Nome:       ativo.Nome,
+			Classe:     classe,
+			ValorTotal: ativo.ValorTotal,
+		})
+		exposicao.ValorTotal += ativo.ValorTotal
+	}
+	for _, bdr := range carteiraFinalBDR {
+		adicionar(bdr, "BDRs")
+		exposicao.ValorBDRs += bdr.ValorTotal
+	}
+	for _, etf := range carteiraFinalETF {
+		if c.distribuicaoService.MoedaAtivo(ClasseETF.Nome, etf.Ticker) == "USD" {
+			adicionar(etf, "ETFs")
+			exposicao.ValorETFs += etf.ValorTotal
 		}
 	}
-	return total
+
+	if valorTotalFinal > 0 {
+		exposicao.PercentualCarteira = exposicao.ValorTotal / valorTotalFinal * 100
+		for i := range exposicao.Ativos {
+			exposicao.Ativos[i].Percentual = exposicao.Ativos[i].ValorTotal / valorTotalFinal * 100
+		}
+	}
+	sort.Slice(exposicao.Ativos, func(i, j int) bool {
+		return exposicao.Ativos[i].ValorTotal > exposicao.Ativos[j].ValorTotal
+	})
+
+	return exposicao
 }
 
 // calcularValorTotalCarteiraRendaFixa calcula o valor total da carteira de renda fixa
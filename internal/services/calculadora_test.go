@@ -0,0 +1,128 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/cache"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"testing"
+	"time"
+)
+
+// calculadoraComProventos monta uma calculadora com o histórico de proventos já em cache,
+// de forma que o cálculo dos rendimentos não acesse a rede
+func calculadoraComProventos(t *testing.T, referencia time.Time, tipoAtivo, ticker string, proventos []models.ProventoHistorico) *Calculadora {
+	t.Helper()
+	cfg := config.Load()
+	cacheInstancia := cache.GetInstance(cfg.CacheDuracao, cfg.CacheLimpeza)
+	cacheInstancia.Set("proventos_"+tipoAtivo+"_"+ticker, proventos, time.Hour)
+	t.Cleanup(func() { cacheInstancia.Delete("proventos_" + tipoAtivo + "_" + ticker) })
+
+	return &Calculadora{
+		dividendoService:  NewDividendoService(cfg, cacheInstancia),
+		tributacaoService: NewTributacaoService(cfg),
+		relogio:           relogio.NovoFixo(referencia, referencia.AddDate(0, 0, 30)),
+	}
+}
+
+// proventosMensais gera proventos mensais, do mais recente para o mais antigo, com data com no dia 30
+func proventosMensais(ticker string, ultimo time.Time, valores ...float64) []models.ProventoHistorico {
+	var proventos []models.ProventoHistorico
+	for i, valor := range valores {
+		proventos = append(proventos, models.ProventoHistorico{
+			Ticker:  ticker,
+			Tipo:    models.TipoProventoRendimento,
+			DataCom: ultimo.AddDate(0, -i, 0),
+			Valor:   valor,
+		})
+	}
+	return proventos
+}
+
+func TestCalcularRendimentosFIIsIgnoraProventosAposReferencia(t *testing.T) {
+	referencia := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)
+	// O primeiro provento (data com em 30/09) ainda não era conhecido na data de referência
+	proventos := proventosMensais("TSTA11", time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC), 9.99, 1.10, 1.05, 1.00)
+	calculadora := calculadoraComProventos(t, referencia, "FII", "TSTA11", proventos)
+
+	resultado := calculadora.calcularRendimentosFIIs([]models.FIICarteiraFinal{{Ticker: "TSTA11", Preco: 100, Quantidade: 10, ValorTotal: 1000}})
+
+	if len(resultado) != 1 {
+		t.Fatalf("esperava 1 FII, obteve %d", len(resultado))
+	}
+	if resultado[0].UltimoDividendo != 1.10 {
+		t.Fatalf("último dividendo = %v, esperava 1.10 (anterior à referência)", resultado[0].UltimoDividendo)
+	}
+}
+
+func TestCalcularRendimentosAcoesIgnoraProventosAposReferencia(t *testing.T) {
+	referencia := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)
+	proventos := proventosMensais("TSTA3", time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC), 9.99, 0.50, 0.40)
+	calculadora := calculadoraComProventos(t, referencia, "ACAO", "TSTA3", proventos)
+
+	resultado := calculadora.calcularRendimentosAcoes([]models.AcaoCarteiraFinal{{Ticker: "TSTA3", Preco: 10, Quantidade: 100, ValorTotal: 1000}})
+
+	if len(resultado) != 1 {
+		t.Fatalf("esperava 1 ação, obteve %d", len(resultado))
+	}
+	ultimos := resultado[0].UltimosProventos
+	if len(ultimos) != 2 {
+		t.Fatalf("esperava 2 proventos até a referência, obteve %d", len(ultimos))
+	}
+	for _, provento := range ultimos {
+		if provento.DataCom.After(referencia) {
+			t.Fatalf("provento de %s é posterior à referência", provento.DataCom.Format("02/01/2006"))
+		}
+	}
+}
+
+func TestCalculoRetroativoSemProventosNaoUsaDadosAtuais(t *testing.T) {
+	referencia := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)
+	// O único provento conhecido é posterior à data de referência
+	proventos := proventosMensais("TSTB11", time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC), 1.10)
+	calculadora := calculadoraComProventos(t, referencia, "FII", "TSTB11", proventos)
+	calculadora.dividendoService.Cache.Set("proventos_ACAO_TSTB3", proventos, time.Hour)
+	t.Cleanup(func() { calculadora.dividendoService.Cache.Delete("proventos_ACAO_TSTB3") })
+
+	fiis := calculadora.calcularRendimentosFIIs([]models.FIICarteiraFinal{{Ticker: "TSTB11", Preco: 100, Quantidade: 10, ValorTotal: 1000}})
+	if !fiis[0].SemHistorico || fiis[0].RendimentoMensal != 0 {
+		t.Fatalf("FII: esperava sem histórico e rendimento zero, obteve %v e %v", fiis[0].SemHistorico, fiis[0].RendimentoMensal)
+	}
+
+	acoes := calculadora.calcularRendimentosAcoes([]models.AcaoCarteiraFinal{{Ticker: "TSTB3", Preco: 10, DY: 8, Quantidade: 100, ValorTotal: 1000}})
+	if !acoes[0].SemHistorico || acoes[0].RendimentoAnual != 0 {
+		t.Fatalf("ação: esperava sem histórico e rendimento zero, obteve %v e %v", acoes[0].SemHistorico, acoes[0].RendimentoAnual)
+	}
+}
+
+func TestComRelogioPropagaDataAosServicosSemAlterarOsOriginais(t *testing.T) {
+	cfg := config.Load()
+	original := relogio.Fixo{Momento: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}
+	dataCom := &DataComService{Relogio: original}
+	precoTeto := NewPrecoTetoService(cfg, nil, nil, original)
+	calculadora := &Calculadora{
+		recomendacaoService: NewRecomendadoraService(dataCom, nil, nil, precoTeto),
+		otimizadoraService:  NewOtimizadoraService(dataCom, nil, nil),
+		precoTetoService:    precoTeto,
+		relogio:             original,
+	}
+
+	mes := relogio.Avancar(original, 0, 2, 0)
+	copia := calculadora.comRelogio(mes)
+
+	if copia.relogio != relogio.Relogio(mes) || copia.precoTetoService.relogio != relogio.Relogio(mes) {
+		t.Fatal("a cópia da calculadora deve usar o relógio do mês")
+	}
+	if copia.recomendacaoService.dataComService.Relogio != relogio.Relogio(mes) {
+		t.Fatal("a recomendadora deve analisar a data com na data do mês")
+	}
+	if copia.recomendacaoService.dataComService != copia.otimizadoraService.dataComService {
+		t.Fatal("a recomendadora e a otimizadora devem continuar compartilhando o serviço de data com")
+	}
+	if copia.recomendacaoService.precoTetoService != copia.precoTetoService {
+		t.Fatal("a recomendadora deve usar o mesmo serviço de preço-teto da calculadora")
+	}
+	if calculadora.relogio != relogio.Relogio(original) || dataCom.Relogio != relogio.Relogio(original) || precoTeto.relogio != relogio.Relogio(original) {
+		t.Fatal("os serviços originais não devem ser alterados")
+	}
+}
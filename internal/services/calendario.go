@@ -0,0 +1,278 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"log"
+	"sort"
+	"time"
+)
+
+// CalendarioService monta o calendário de proventos esperados da carteira
+type CalendarioService struct {
+	Config     *config.Config
+	dividendos *DividendoService
+	tributacao *TributacaoService
+	dataCom    *DataComService
+}
+
+// NewCalendarioService cria um novo serviço de calendário de proventos
+func NewCalendarioService(cfg *config.Config, dividendos *DividendoService, tributacao *TributacaoService, dataCom *DataComService) *CalendarioService {
+	return &CalendarioService{
+		Config:     cfg,
+		dividendos: dividendos,
+		tributacao: tributacao,
+		dataCom:    dataCom,
+	}
+}
+
+// posicaoCalendario representa um ativo da carteira considerado no calendário
+type posicaoCalendario struct {
+	ticker     string
+	tipoAtivo  string
+	quantidade int
+}
+
+// GerarCalendario lista, para os próximos meses, as datas com e de pagamento esperadas de cada
+// FII e ação da carteira. Proventos já anunciados são usados como estão; os demais são projetados
+// a partir do padrão histórico de datas com e valores.
+func (s *CalendarioService) GerarCalendario(carteiraFII *models.CarteiraDados, carteiraAcao *models.CarteiraAcoes, meses int, hoje time.Time) *models.CalendarioProventos {
+	if meses <= 0 {
+		meses = s.Config.MesesCalendarioPadrao
+	}
+	if meses > s.Config.MesesCalendarioMaximo {
+		meses = s.Config.MesesCalendarioMaximo
+	}
+
+	inicio := time.Date(hoje.Year(), hoje.Month(), hoje.Day(), 0, 0, 0, 0, hoje.Location())
+	fim := inicio.AddDate(0, meses, 0)
+
+	var posicoes []posicaoCalendario
+	if carteiraFII != nil {
+		for _, ativo := range carteiraFII.Data {
+			if ativo.Quantity > 0 {
+				posicoes = append(posicoes, posicaoCalendario{ticker: ativo.TickerName, tipoAtivo: "FII", quantidade: ativo.Quantity})
+			}
+		}
+	}
+	if carteiraAcao != nil {
+		for _, ativo := range carteiraAcao.Data {
+			if ativo.Quantity > 0 {
+				posicoes = append(posicoes, posicaoCalendario{ticker: ativo.TickerName, tipoAtivo: "ACAO", quantidade: ativo.Quantity})
+			}
+		}
+	}
+
+	calendario := &models.CalendarioProventos{
+		Inicio: inicio,
+		Fim:    fim,
+	}
+
+	for _, posicao := range posicoes {
+		proventos, err := s.dividendos.ObterHistoricoProventos(posicao.ticker, posicao.tipoAtivo)
+		if err != nil {
+			log.Printf("Calendário: histórico indisponível para %s: %v", posicao.ticker, err)
+			continue
+		}
+
+		for _, evento := range s.eventosAtivo(posicao, proventos, inicio, fim) {
+			evento.ValorBruto = evento.ValorPorCota * float64(evento.Quantidade)
+			evento.ValorLiquido = ValorLiquido(evento.ValorBruto, s.aliquotaEvento(evento))
+			calendario.Eventos = append(calendario.Eventos, evento)
+		}
+	}
+
+	sort.SliceStable(calendario.Eventos, func(i, j int) bool {
+		if !calendario.Eventos[i].DataPagamento.Equal(calendario.Eventos[j].DataPagamento) {
+			return calendario.Eventos[i].DataPagamento.Before(calendario.Eventos[j].DataPagamento)
+		}
+		return calendario.Eventos[i].Ticker < calendario.Eventos[j].Ticker
+	})
+
+	// Montar a grade mensal pela data de pagamento (entrada de caixa)
+	indiceMes := make(map[string]int)
+	for i := 0; i < meses; i++ {
+		data := time.Date(inicio.Year(), inicio.Month()+time.Month(i), 1, 0, 0, 0, 0, inicio.Location())
+		indiceMes[data.Format("2006-01")] = i
+		calendario.Meses = append(calendario.Meses, models.MesCalendario{
+			Ano:    data.Year(),
+			Mes:    int(data.Month()),
+			Rotulo: data.Format("01/2006"),
+		})
+	}
+
+	for _, evento := range calendario.Eventos {
+		i, existe := indiceMes[evento.DataPagamento.Format("2006-01")]
+		if !existe {
+			continue
+		}
+		calendario.Meses[i].Eventos = append(calendario.Meses[i].Eventos, evento)
+		calendario.Meses[i].TotalBruto += evento.ValorBruto
+		calendario.Meses[i].TotalLiquido += evento.ValorLiquido
+		calendario.TotalBruto += evento.ValorBruto
+		calendario.TotalLiquido += evento.ValorLiquido
+	}
+
+	log.Printf("Calendário de proventos: %d eventos em %d meses (R$ %.2f brutos)",
+		len(calendario.Eventos), meses, calendario.TotalBruto)
+
+	return calendario
+}
+
+// eventosAtivo retorna os proventos anunciados e projetados de um ativo no período
+func (s *CalendarioService) eventosAtivo(posicao posicaoCalendario, proventos []models.ProventoHistorico, inicio, fim time.Time) []models.EventoProvento {
+	var eventos []models.EventoProvento
+	mesesAnunciados := make(map[string]bool)
+
+	// Proventos já anunciados que ainda serão pagos
+	for _, provento := range proventos {
+		pagamento := provento.DataPagamento
+		if pagamento.IsZero() {
+			pagamento = provento.DataCom.AddDate(0, 0, s.prazoPagamento(proventos, posicao.tipoAtivo))
+		}
+		if pagamento.Before(inicio) || !pagamento.Before(fim) {
+			continue
+		}
+
+		mesesAnunciados[provento.DataCom.Format("2006-01")] = true
+		eventos = append(eventos, novoEventoProvento(posicao, provento, provento.DataCom, pagamento, true))
+	}
+
+	// Datas com projetadas para os meses sem anúncio
+	prazo := s.prazoPagamento(proventos, posicao.tipoAtivo)
+	for _, projetado := range s.projetarProventos(posicao.tipoAtivo, proventos, inicio, fim) {
+		if mesesAnunciados[projetado.DataCom.Format("2006-01")] {
+			continue
+		}
+
+		pagamento := s.dataCom.ajustarParaDiaUtil(projetado.DataCom.AddDate(0, 0, prazo))
+		if !pagamento.Before(fim) {
+			continue
+		}
+		eventos = append(eventos, novoEventoProvento(posicao, projetado, projetado.DataCom, pagamento, false))
+	}
+
+	return eventos
+}
+
+// projetarProventos projeta as próximas datas com e valores a partir do histórico, seguindo o padrão
+// mensal dos FIIs (analisarPadraoMensal) ou o padrão de meses recorrentes das ações
+func (s *CalendarioService) projetarProventos(tipoAtivo string, proventos []models.ProventoHistorico, inicio, fim time.Time) []models.ProventoHistorico {
+	if len(proventos) == 0 {
+		return nil
+	}
+
+	dividendos := make([]Dividendo, len(proventos))
+	for i, provento := range proventos {
+		dividendos[i] = Dividendo{
+			Tipo:       provento.Tipo,
+			DataCom:    provento.DataCom.Format("02/01/2006"),
+			DataParsed: provento.DataCom,
+		}
+	}
+
+	var projetados []models.ProventoHistorico
+
+	if tipoAtivo == "FII" {
+		// Valor por pagamento conforme o estimador configurado
+		valorMensal, pagamentos, ok := EstimarProventoMensal(proventos, s.dividendos.Estimador, inicio)
+		if !ok {
+			return nil
+		}
+		valorPorPagamento := valorMensal * 12 / float64(pagamentos)
+
+		analise := &AnaliseDataCom{}
+		s.dataCom.analisarPadraoMensal(dividendos, analise, inicio)
+
+		dia := analise.DiaPagamentoComum
+		if dia == 0 {
+			dia = analise.ProximaDataCom.Day()
+		}
+
+		// Repetir a data com mês a mês, limitando o dia ao tamanho de cada mês
+		primeiroMes := time.Date(analise.ProximaDataCom.Year(), analise.ProximaDataCom.Month(), 1, 0, 0, 0, 0, inicio.Location())
+		for mes := primeiroMes; mes.Before(fim); mes = mes.AddDate(0, 1, 0) {
+			ultimoDia := mes.AddDate(0, 1, -1).Day()
+			dataCom := s.dataCom.ajustarParaDiaUtil(time.Date(mes.Year(), mes.Month(), min(dia, ultimoDia), 0, 0, 0, 0, mes.Location()))
+			if dataCom.Before(inicio) {
+				continue
+			}
+
+			projetados = append(projetados, models.ProventoHistorico{
+				Ticker:  proventos[0].Ticker,
+				Tipo:    models.TipoProventoRendimento,
+				DataCom: dataCom,
+				Valor:   valorPorPagamento,
+			})
+		}
+		return projetados
+	}
+
+	// Ações: repetir os proventos do último ano nos meses em que costumam ocorrer
+	recorrentes := mesesRecorrentes(dividendos)
+	ultimoAno := proventosUltimoAno(proventos, inicio)
+
+	for data := time.Date(inicio.Year(), inicio.Month(), 1, 0, 0, 0, 0, inicio.Location()); data.Before(fim); data = data.AddDate(0, 1, 0) {
+		dia, recorrente := recorrentes[data.Month()]
+		if !recorrente {
+			continue
+		}
+
+		dataCom := s.dataCom.ajustarParaDiaUtil(time.Date(data.Year(), data.Month(), dia, 0, 0, 0, 0, data.Location()))
+		if dataCom.Before(inicio) {
+			continue
+		}
+
+		for _, provento := range ultimoAno {
+			if provento.DataCom.Month() == data.Month() {
+				provento.DataCom = dataCom
+				projetados = append(projetados, provento)
+			}
+		}
+	}
+
+	return projetados
+}
+
+// prazoPagamento calcula a mediana de dias entre a data com e o pagamento no histórico
+func (s *CalendarioService) prazoPagamento(proventos []models.ProventoHistorico, tipoAtivo string) int {
+	var prazos []float64
+	for _, provento := range proventos {
+		if !provento.DataPagamento.IsZero() && provento.DataPagamento.After(provento.DataCom) {
+			prazos = append(prazos, provento.DataPagamento.Sub(provento.DataCom).Hours()/24)
+		}
+	}
+
+	if len(prazos) == 0 {
+		if tipoAtivo == "FII" {
+			return s.Config.PrazoPagamentoPadraoFII
+		}
+		return s.Config.PrazoPagamentoPadraoAcao
+	}
+
+	sort.Float64s(prazos)
+	return int(percentil(prazos, 50))
+}
+
+// aliquotaEvento retorna a alíquota de IR aplicada ao provento
+func (s *CalendarioService) aliquotaEvento(evento models.EventoProvento) float64 {
+	if evento.TipoAtivo == "FII" {
+		return s.tributacao.AliquotaFII(evento.Ticker)
+	}
+	return s.tributacao.AliquotaProvento(evento.Tipo)
+}
+
+// novoEventoProvento cria um evento do calendário a partir de um provento
+func novoEventoProvento(posicao posicaoCalendario, provento models.ProventoHistorico, dataCom, pagamento time.Time, anunciado bool) models.EventoProvento {
+	return models.EventoProvento{
+		Ticker:        posicao.ticker,
+		TipoAtivo:     posicao.tipoAtivo,
+		Tipo:          provento.Tipo,
+		JCP:           provento.JCP,
+		DataCom:       dataCom,
+		DataPagamento: pagamento,
+		ValorPorCota:  provento.Valor,
+		Quantidade:    posicao.quantidade,
+		Anunciado:     anunciado,
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/models"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Classes de renda variável. Uma nova classe que use o formato de carteira de uma existente
+// (como BDRs e ETFs de criptoativos, que usam o formato dos ETFs) precisa apenas de uma entrada aqui.
+var (
+	ClasseFII    = models.Classe{Codigo: "FII", Nome: "FIIs", Descricao: "FII", TipoDataCom: "FII", TipoProventos: "FII", SobrasSomenteComCompras: true, InclinarPorPVP: true, DividendosPorDY: true}
+	ClasseAcao   = models.Classe{Codigo: "ACAO", Nome: "Ações", Descricao: "Ação", TipoDataCom: "ACAO", TipoProventos: "ACAO", LimitarPorEmissor: true, LimitarPorSetor: true, AvaliarPrecoTeto: true}
+	ClasseETF    = models.Classe{Codigo: "ETF", Nome: "ETFs", Descricao: "ETF"}
+	ClasseBDR    = models.Classe{Codigo: "BDR", Nome: "BDRs", Descricao: "BDR", SobrasSomenteComCompras: true, Opcional: true}
+	ClasseCripto = models.Classe{Codigo: "CRIPTO", Nome: "Cripto", Descricao: "ETF de criptoativo", SobrasSomenteComCompras: true, Opcional: true}
+)
+
+// ClassesRendaVariavel lista as classes registradas, na ordem em que são avaliadas
+var ClassesRendaVariavel = []models.Classe{ClasseFII, ClasseAcao, ClasseETF, ClasseBDR, ClasseCripto}
+
+// NomeRendaFixa é a chave da renda fixa nos mapas indexados por classe (distribuição e valores)
+const NomeRendaFixa = "RendaFixa"
+
+// TiposInvestimentoPadrao retorna as classes usadas sem distribuição personalizada: a renda fixa e as
+// classes de renda variável que não são opcionais
+func TiposInvestimentoPadrao() models.TiposInvestimento {
+	tipos := models.TiposInvestimento{NomeRendaFixa: true}
+	for _, classe := range ClassesRendaVariavel {
+		if !classe.Opcional {
+			tipos[classe.Nome] = true
+		}
+	}
+	return tipos
+}
+
+// SelecionarClasses monta a seleção a partir dos nomes informados no formulário, ignorando os que
+// não correspondem a uma classe de ClassesInvestimento
+func SelecionarClasses(nomes []string) models.TiposInvestimento {
+	tipos := make(models.TiposInvestimento)
+	for _, nome := range nomes {
+		if contemTexto(ClassesInvestimento, nome) {
+			tipos[nome] = true
+		}
+	}
+	return tipos
+}
+
+// nomesClasses retorna os nomes das classes informadas, na mesma ordem
+func nomesClasses(classes []models.Classe) []string {
+	nomes := make([]string, 0, len(classes))
+	for _, classe := range classes {
+		nomes = append(nomes, classe.Nome)
+	}
+	return nomes
+}
+
+// somarClasses soma os valores por classe na ordem de ClassesInvestimento
+func somarClasses(valores map[string]float64) float64 {
+	total := 0.0
+	for _, nome := range ClassesInvestimento {
+		total += valores[nome]
+	}
+	return total
+}
+
+// preencherCamposPorClasse copia os valores, compras, carteiras finais e rendas indexados por classe
+// para os campos de cada classe usados pelos templates
+func preencherCamposPorClasse(dados *models.TemplateDados) {
+	dados.ValorTotalCarteiraFII = dados.ValoresCarteira[ClasseFII.Nome]
+	dados.ValorTotalCarteiraAcao = dados.ValoresCarteira[ClasseAcao.Nome]
+	dados.ValorTotalCarteiraETF = dados.ValoresCarteira[ClasseETF.Nome]
+	dados.ValorTotalCarteiraBDR = dados.ValoresCarteira[ClasseBDR.Nome]
+	dados.ValorTotalCarteiraCripto = dados.ValoresCarteira[ClasseCripto.Nome]
+	dados.ValorTotalCarteiraRendaFixa = dados.ValoresCarteira[NomeRendaFixa]
+
+	dados.RecomendacoesFII = dados.Recomendacoes[ClasseFII.Nome]
+	dados.RecomendacoesAcao = dados.Recomendacoes[ClasseAcao.Nome]
+	dados.RecomendacoesETF = dados.Recomendacoes[ClasseETF.Nome]
+	dados.RecomendacoesBDR = dados.Recomendacoes[ClasseBDR.Nome]
+	dados.RecomendacoesCripto = dados.Recomendacoes[ClasseCripto.Nome]
+
+	dados.ValorTotalRecomendadoFII = dados.ValoresRecomendados[ClasseFII.Nome]
+	dados.ValorTotalRecomendadoAcao = dados.ValoresRecomendados[ClasseAcao.Nome]
+	dados.ValorTotalRecomendadoETF = dados.ValoresRecomendados[ClasseETF.Nome]
+	dados.ValorTotalRecomendadoBDR = dados.ValoresRecomendados[ClasseBDR.Nome]
+	dados.ValorTotalRecomendadoCripto = dados.ValoresRecomendados[ClasseCripto.Nome]
+	dados.ValorTotalRecomendadoFixa = dados.ValoresRecomendados[NomeRendaFixa]
+
+	dados.PercentualRecomendadoFII = dados.PercentuaisRecomendados[ClasseFII.Nome]
+	dados.PercentualRecomendadoAcao = dados.PercentuaisRecomendados[ClasseAcao.Nome]
+	dados.PercentualRecomendadoETF = dados.PercentuaisRecomendados[ClasseETF.Nome]
+	dados.PercentualRecomendadoBDR = dados.PercentuaisRecomendados[ClasseBDR.Nome]
+	dados.PercentualRecomendadoCripto = dados.PercentuaisRecomendados[ClasseCripto.Nome]
+	dados.PercentualRecomendadoFixa = dados.PercentuaisRecomendados[NomeRendaFixa]
+
+	dados.CarteiraFinalFII = dados.CarteirasFinais[ClasseFII.Nome]
+	dados.CarteiraFinalAcao = dados.CarteirasFinais[ClasseAcao.Nome]
+	dados.CarteiraFinalETF = dados.CarteirasFinais[ClasseETF.Nome]
+	dados.CarteiraFinalBDR = dados.CarteirasFinais[ClasseBDR.Nome]
+	dados.CarteiraFinalCripto = dados.CarteirasFinais[ClasseCripto.Nome]
+
+	dados.ValorTotalFinalFII = dados.ValoresFinais[ClasseFII.Nome]
+	dados.ValorTotalFinalAcao = dados.ValoresFinais[ClasseAcao.Nome]
+	dados.ValorTotalFinalETF = dados.ValoresFinais[ClasseETF.Nome]
+	dados.ValorTotalFinalBDR = dados.ValoresFinais[ClasseBDR.Nome]
+	dados.ValorTotalFinalCripto = dados.ValoresFinais[ClasseCripto.Nome]
+	dados.ValorTotalFinalFixa = dados.ValoresFinais[NomeRendaFixa]
+
+	rendaFII := dados.RendaPorClasse[ClasseFII.Nome]
+	dados.TotalRendimentosMensaisFII = rendaFII.MensalBruta
+	dados.TotalRendimentosMensaisFIILiquido = rendaFII.MensalLiquida
+	dados.TotalRendimentosAnuaisFII = rendaFII.AnualBruta
+	if dados.ValorTotalFinalFII > 0 {
+		dados.YieldMedioCarteiraFII = rendaFII.AnualBruta / dados.ValorTotalFinalFII * 100
+	}
+
+	rendaAcao := dados.RendaPorClasse[ClasseAcao.Nome]
+	dados.DividendosAnuaisTotalAcao = rendaAcao.AnualBruta
+	dados.DividendosAnuaisTotalAcaoLiquido = rendaAcao.AnualLiquida
+}
+
+// ComprasClasse reúne, para uma classe de renda variável, os recomendados e as compras sugeridas no aporte
+type ComprasClasse struct {
+	Classe        models.Classe
+	Recomendados  []models.AtivoRecomendado
+	Recomendacoes []models.RecomendacaoCompra
+	ValorTotal    float64                     // Valor total das compras sugeridas
+	Carteira      []models.Ativo              // Posições atuais da classe
+	ValorFuturo   float64                     // Valor da classe após o aporte, base dos limites de concentração
+	PrecosTeto    map[string]models.PrecoTeto // Avaliação dos recomendados por ticker (ver Classe.AvaliarPrecoTeto)
+}
+
+// AtivosFII converte a carteira de FIIs do Investidor10 para o formato comum
+func AtivosFII(carteira *models.CarteiraDados) []models.Ativo {
+	ativos := make([]models.Ativo, 0, len(carteira.Data))
+	for _, ativo := range carteira.Data {
+		ativos = append(ativos, models.Ativo{
+			Ticker:             ativo.TickerName,
+			Segmento:           ativo.Segment,
+			Tipo:               ativo.FiiType,
+			Preco:              ativo.CurrentPrice,
+			Quantidade:         ativo.Quantity,
+			PercentualCarteira: ativo.PercentWallet,
+			DY:                 converterPercentual(ativo.DY),
+			PVP:                converterIndicador(ativo.PVP),
+		})
+	}
+	return ativos
+}
+
+// AtivosAcao converte a carteira de ações do Investidor10 para o formato comum
+func AtivosAcao(carteira *models.CarteiraAcoes) []models.Ativo {
+	ativos := make([]models.Ativo, 0, len(carteira.Data))
+	for _, ativo := range carteira.Data {
+		ativos = append(ativos, models.Ativo{
+			Ticker:             ativo.TickerName,
+			Preco:              ativo.CurrentPrice,
+			Quantidade:         ativo.Quantity,
+			PercentualCarteira: ativo.PercentWallet,
+			DY:                 converterPercentual(ativo.DY),
+			PVP:                converterIndicador(ativo.PVP),
+			PL:                 converterIndicador(ativo.PL),
+		})
+	}
+	return ativos
+}
+
+// AtivosETF converte a carteira de ETFs (também BDRs e criptoativos) do Investidor10 para o formato
+// comum. O preço atual vem como texto; quando não é numérico o ativo fica marcado como sem cotação.
+func AtivosETF(carteira *models.CarteiraETFs) []models.Ativo {
+	ativos := make([]models.Ativo, 0, len(carteira.Data))
+	for _, ativo := range carteira.Data {
+		preco, err := strconv.ParseFloat(ativo.CurrentPrice, 64)
+		ativos = append(ativos, models.Ativo{
+			Ticker:             ativo.TickerName,
+			Preco:              preco,
+			SemCotacao:         err != nil,
+			Quantidade:         ativo.Quantity,
+			PercentualCarteira: ativo.PercentWallet,
+		})
+	}
+	return ativos
+}
+
+// valorTotalAtivos calcula o valor de mercado das posições com cotação
+func valorTotalAtivos(ativos []models.Ativo) float64 {
+	var total float64
+	for _, ativo := range ativos {
+		if !ativo.SemCotacao {
+			total += ativo.Preco * float64(ativo.Quantidade)
+		}
+	}
+	return total
+}
+
+// somarCompras soma o valor das compras sugeridas
+func somarCompras(recomendacoes []models.RecomendacaoCompra) float64 {
+	total := 0.0
+	for _, rec := range recomendacoes {
+		total += rec.ValorCompra
+	}
+	return total
+}
+
+// limitarComprasPorGrupo reduz proporcionalmente as compras dos tickers de cada grupo (emissor, setor)
+// para que a posição atual somada às compras não ultrapasse o limite do grupo. Grupos com limite zero
+// não são limitados. O valor cortado fica para as sobras.
+func limitarComprasPorGrupo(carteira []models.Ativo, valorCompra map[string]float64, grupo func(string) string, limite func(string) float64) {
+	valorAtual := make(map[string]float64)
+	for _, ativo := range carteira {
+		if !ativo.SemCotacao {
+			valorAtual[grupo(ativo.Ticker)] += ativo.Preco * float64(ativo.Quantidade)
+		}
+	}
+
+	comprasPorGrupo := make(map[string]float64)
+	for ticker, valor := range valorCompra {
+		comprasPorGrupo[grupo(ticker)] += valor
+	}
+
+	for ticker, valor := range valorCompra {
+		chave := grupo(ticker)
+		limiteGrupo := limite(chave)
+		compras := comprasPorGrupo[chave]
+		if limiteGrupo > 0 && valorAtual[chave]+compras > limiteGrupo {
+			valorCompra[ticker] = valor * math.Max(0, limiteGrupo-valorAtual[chave]) / compras
+		}
+	}
+}
+
+// valorGrupo soma a posição atual e as compras sugeridas dos tickers do grupo
+func valorGrupo(chave string, grupo func(string) string, carteira []models.Ativo, recomendacoes []models.RecomendacaoCompra) float64 {
+	total := 0.0
+	for _, ativo := range carteira {
+		if !ativo.SemCotacao && grupo(ativo.Ticker) == chave {
+			total += ativo.Preco * float64(ativo.Quantidade)
+		}
+	}
+	for _, rec := range recomendacoes {
+		if grupo(rec.Ticker) == chave {
+			total += rec.ValorCompra
+		}
+	}
+	return total
+}
+
+// converterPercentual converte indicadores percentuais como o DY "8.5%" do Investidor10
+func converterPercentual(valor string) float64 {
+	numero, _ := strconv.ParseFloat(strings.TrimSuffix(valor, "%"), 64)
+	return numero
+}
+
+// converterIndicador converte indicadores com vírgula decimal, como o P/VP "0,98"
+func converterIndicador(valor string) float64 {
+	numero, _ := strconv.ParseFloat(strings.Replace(valor, ",", ".", -1), 64)
+	return numero
+}
@@ -1,52 +1,235 @@
 package services
 
 import (
+	"calculadora-investimentos/internal/b3"
+	"calculadora-investimentos/internal/cache"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/relogio"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// regraDataComPadrao é usada para classes sem regra configurada
+var regraDataComPadrao = config.RegraDataCom{
+	DiasNaoComprar: 0,
+	DiasEvitar:     2,
+	DiasAlerta:     5,
+	QuedaExData:    1.0,
+	PerdaToleravel: 0,
+}
+
 // DataComService gerencia a análise de datas com (ex-dividendo)
 type DataComService struct {
-	HTTPClient *http.Client
+	HTTPClient        *http.Client
+	Calendario        *b3.Calendario
+	Cache             *cache.Cache
+	Relogio           relogio.Relogio
+	Workers           int           // Análises executadas em paralelo em AnalisarDataComTickers
+	DuracaoCacheErros time.Duration // Falhas também são guardadas para não repetir o download
+	Regras            map[string]config.RegraDataCom
+	UsarPerdaEsperada bool
+	Tributacao        *TributacaoService
+}
+
+// Análises em andamento, compartilhadas por todas as instâncias do serviço: os handlers criam um
+// DataComService por requisição, e requisições simultâneas devem aguardar o mesmo download
+var (
+	analisesEmAndamentoMu sync.Mutex
+	analisesEmAndamento   = make(map[string]*analiseEmAndamento)
+)
+
+// analiseEmAndamento permite que chamadas simultâneas para o mesmo ticker aguardem um único download
+type analiseEmAndamento struct {
+	pronto  chan struct{}
+	analise *AnaliseDataCom
+	err     error
+}
+
+// resultadoAnaliseDataCom é o valor guardado no cache (análise ou erro)
+type resultadoAnaliseDataCom struct {
+	analise *AnaliseDataCom
+	err     error
 }
 
 // NewDataComService cria um novo serviço de análise de data com
-func NewDataComService() *DataComService {
+func NewDataComService(cfg *config.Config, cacheInstance *cache.Cache, rel relogio.Relogio) *DataComService {
+	limitador := ObterLimitadorPorHost(cfg.IntervaloRequisicoesPorHost)
 	return &DataComService{
 		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: limitador.Transporte(nil),
 		},
+		Calendario:        b3.GetInstance(cfg.DiretorioFeriados),
+		Cache:             cacheInstance,
+		Relogio:           rel,
+		Workers:           cfg.WorkersDataCom,
+		DuracaoCacheErros: cfg.CacheDuracaoErroDataCom,
+		Regras:            cfg.RegrasDataCom,
+		UsarPerdaEsperada: cfg.UsarPerdaEsperadaDataCom,
+		Tributacao:        NewTributacaoService(cfg),
 	}
 }
 
+// AtivoDataCom identifica um ativo a ser analisado em lote
+type AtivoDataCom struct {
+	Ticker    string
+	TipoAtivo string // FII ou ACAO
+}
+
+// ResultadoDataCom é o resultado da análise de um ativo em lote
+type ResultadoDataCom struct {
+	Analise *AnaliseDataCom
+	Err     error
+}
+
 // Dividendo representa um pagamento de dividendo histórico
 type Dividendo struct {
-	Tipo          string
-	DataCom       string
-	DataPagamento string
-	Valor         string
-	DataParsed    time.Time
+	Tipo                string
+	DataCom             string
+	DataPagamento       string
+	Valor               string
+	DataParsed          time.Time // Data com interpretada
+	DataPagamentoParsed time.Time // Zero quando o pagamento ainda não foi definido
+	ValorDecimal        float64
 }
 
 // AnaliseDataCom contém a análise de quando comprar um ativo
 type AnaliseDataCom struct {
 	ProximaDataCom    time.Time
-	DiasAteDataCom    int
+	DiasAteDataCom    int    // Dias de pregão até a data com
 	StatusCompra      string // "SEGURO", "ALERTA", "EVITAR", "NAO_COMPRAR"
 	MensagemStatus    string
 	UltimosDividendos []Dividendo
 	PadraoMensal      bool
 	DiaPagamentoComum int
+	TipoAtivo         string
+	// Perda esperada ao comprar antes da data com (por cota)
+	ProventoBrutoPorCota   float64 // Provento anunciado para a próxima data com ou, sem anúncio, o último pago
+	ProventoLiquidoPorCota float64 // Provento após o IR
+	QuedaExDataPorCota     float64 // Queda típica do preço na data ex
+	PerdaEsperadaPorCota   float64 // Queda típica menos o provento líquido recebido
 }
 
-// AnalisarDataComTicker analisa a data com de um ticker específico
+// RegraDataCom retorna a regra de status de compra configurada para o tipo de ativo
+func (s *DataComService) RegraDataCom(tipoAtivo string) config.RegraDataCom {
+	if regra, existe := s.Regras[tipoAtivo]; existe {
+		return regra
+	}
+	return regraDataComPadrao
+}
+
+// AnalisarDataComTicker analisa a data com de um ticker específico.
+// O resultado é guardado em cache até o fim do dia de referência do relógio, e chamadas
+// simultâneas para o mesmo ticker compartilham um único download da página.
 func (s *DataComService) AnalisarDataComTicker(ticker string, tipoAtivo string) (*AnaliseDataCom, error) {
+	agora := s.Relogio.Agora()
+	chave := chaveAnaliseDataCom(ticker, tipoAtivo, agora)
+
+	if valor, found := s.Cache.Get(chave); found {
+		log.Printf("Cache HIT para análise de data com de %s", ticker)
+		resultado := valor.(resultadoAnaliseDataCom)
+		return resultado.analise, resultado.err
+	}
+
+	analisesEmAndamentoMu.Lock()
+	if andamento, existe := analisesEmAndamento[chave]; existe {
+		analisesEmAndamentoMu.Unlock()
+		<-andamento.pronto
+		return andamento.analise, andamento.err
+	}
+	// Outra chamada pode ter concluído a análise entre a consulta ao cache e o bloqueio
+	if valor, found := s.Cache.Get(chave); found {
+		analisesEmAndamentoMu.Unlock()
+		resultado := valor.(resultadoAnaliseDataCom)
+		return resultado.analise, resultado.err
+	}
+	andamento := &analiseEmAndamento{pronto: make(chan struct{})}
+	analisesEmAndamento[chave] = andamento
+	analisesEmAndamentoMu.Unlock()
+
+	andamento.analise, andamento.err = s.analisarDataComTicker(ticker, tipoAtivo, agora)
+
+	duracao := tempoAteFimDoDia(agora)
+	if andamento.err != nil && s.DuracaoCacheErros < duracao {
+		duracao = s.DuracaoCacheErros
+	}
+	if duracao > 0 {
+		s.Cache.Set(chave, resultadoAnaliseDataCom{analise: andamento.analise, err: andamento.err}, duracao)
+	}
+
+	analisesEmAndamentoMu.Lock()
+	delete(analisesEmAndamento, chave)
+	analisesEmAndamentoMu.Unlock()
+	close(andamento.pronto)
+
+	return andamento.analise, andamento.err
+}
+
+// AnalisarDataComTickers analisa vários ativos em paralelo, limitado ao número de workers
+// configurado. Tickers repetidos são analisados uma única vez.
+func (s *DataComService) AnalisarDataComTickers(ativos []AtivoDataCom) map[string]ResultadoDataCom {
+	resultados := make(map[string]ResultadoDataCom)
+	var pendentes []AtivoDataCom
+	vistos := make(map[string]bool)
+	for _, ativo := range ativos {
+		if ativo.TipoAtivo == "ETF" || vistos[ativo.Ticker] {
+			continue
+		}
+		vistos[ativo.Ticker] = true
+		pendentes = append(pendentes, ativo)
+	}
+
+	workers := s.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pendentes) {
+		workers = len(pendentes)
+	}
+
+	fila := make(chan AtivoDataCom)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ativo := range fila {
+				analise, err := s.AnalisarDataComTicker(ativo.Ticker, ativo.TipoAtivo)
+				mu.Lock()
+				resultados[ativo.Ticker] = ResultadoDataCom{Analise: analise, Err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, ativo := range pendentes {
+		fila <- ativo
+	}
+	close(fila)
+	wg.Wait()
+
+	return resultados
+}
+
+// chaveAnaliseDataCom monta a chave de cache da análise de um ticker no dia
+func chaveAnaliseDataCom(ticker, tipoAtivo string, dia time.Time) string {
+	return fmt.Sprintf("datacom_%s_%s_%s", tipoAtivo, strings.ToUpper(ticker), dia.Format("2006-01-02"))
+}
+
+// tempoAteFimDoDia retorna quanto falta para a meia-noite seguinte
+func tempoAteFimDoDia(agora time.Time) time.Duration {
+	amanha := time.Date(agora.Year(), agora.Month(), agora.Day()+1, 0, 0, 0, 0, agora.Location())
+	return amanha.Sub(agora)
+}
+
+// analisarDataComTicker baixa o histórico de proventos e calcula a análise na data informada (sem cache)
+func (s *DataComService) analisarDataComTicker(ticker string, tipoAtivo string, hoje time.Time) (*AnaliseDataCom, error) {
 	log.Printf("=== Iniciando análise de data com para %s (tipo: %s) ===", ticker, tipoAtivo)
 
 	// Buscar histórico de dividendos
@@ -58,29 +241,29 @@ func (s *DataComService) AnalisarDataComTicker(ticker string, tipoAtivo string)
 
 	log.Printf("Dividendos encontrados para %s: %d", ticker, len(dividendos))
 
+	// Em análises retroativas, as datas com posteriores à referência ainda não eram conhecidas
+	if relogio.Retroativo(s.Relogio) {
+		dividendos = dividendosAte(dividendos, hoje)
+		log.Printf("Análise retroativa em %s: %d dividendos considerados", hoje.Format("02/01/2006"), len(dividendos))
+	}
+
 	if len(dividendos) == 0 {
 		log.Printf("Nenhum dividendo encontrado para %s", ticker)
 		return &AnaliseDataCom{
+			TipoAtivo:      tipoAtivo,
 			StatusCompra:   "SEGURO",
 			MensagemStatus: "Sem histórico de dividendos disponível",
 		}, nil
 	}
 
-	// Parsear datas
-	for i := range dividendos {
-		if data, err := time.Parse("02/01/2006", dividendos[i].DataCom); err == nil {
-			dividendos[i].DataParsed = data
-		}
-	}
-
 	// Ordenar por data (mais recente primeiro)
 	sort.Slice(dividendos, func(i, j int) bool {
 		return dividendos[i].DataParsed.After(dividendos[j].DataParsed)
 	})
 
-	hoje := time.Now()
 	analise := &AnaliseDataCom{
 		UltimosDividendos: dividendos[:min(5, len(dividendos))],
+		TipoAtivo:         tipoAtivo,
 	}
 
 	// NOVA LÓGICA: Buscar a próxima data com que já está definida
@@ -102,7 +285,7 @@ func (s *DataComService) AnalisarDataComTicker(ticker string, tipoAtivo string)
 		// Usar a data com já anunciada
 		proximaDataComUtil := s.ajustarParaDiaUtil(*proximaDataCom)
 		analise.ProximaDataCom = proximaDataComUtil
-		analise.DiasAteDataCom = int(proximaDataComUtil.Sub(hoje).Hours() / 24)
+		analise.DiasAteDataCom = s.Calendario.DiasDePregaoEntre(hoje, proximaDataComUtil)
 		log.Printf("Usando data com já anunciada: %s (ajustada para dia útil: %s)",
 			proximaDataCom.Format("02/01/2006"),
 			proximaDataComUtil.Format("02/01/2006"))
@@ -126,14 +309,31 @@ func (s *DataComService) AnalisarDataComTicker(ticker string, tipoAtivo string)
 		}
 	}
 
-	// Definir status de compra
+	// Estimar quanto se perde ao comprar antes da data com e definir o status de compra
+	s.estimarPerdaEsperada(analise, ticker, dividendos, proximaDataCom)
 	s.definirStatusCompra(analise)
 
 	return analise, nil
 }
 
+// dividendosAte retorna os dividendos com data com até a data informada
+func dividendosAte(dividendos []Dividendo, data time.Time) []Dividendo {
+	var resultado []Dividendo
+	for _, div := range dividendos {
+		if !div.DataParsed.After(data) {
+			resultado = append(resultado, div)
+		}
+	}
+	return resultado
+}
+
 // extrairDividendos busca o histórico de dividendos do ativo
 func (s *DataComService) extrairDividendos(ticker, tipoAtivo string) ([]Dividendo, error) {
+	return buscarDividendosInvestidor10(s.HTTPClient, ticker, tipoAtivo)
+}
+
+// buscarDividendosInvestidor10 extrai a tabela de proventos da página do ativo no Investidor10
+func buscarDividendosInvestidor10(client *http.Client, ticker, tipoAtivo string) ([]Dividendo, error) {
 	var url string
 
 	// Construir URL baseado no tipo
@@ -156,7 +356,7 @@ func (s *DataComService) extrairDividendos(ticker, tipoAtivo string) ([]Dividend
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
 
-	resp, err := s.HTTPClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("Erro ao fazer request: %v", err)
 		return nil, err
@@ -169,77 +369,13 @@ func (s *DataComService) extrairDividendos(ticker, tipoAtivo string) ([]Dividend
 		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	dividendos, err := extrairDividendosHTML(resp.Body)
 	if err != nil {
-		log.Printf("Erro ao ler body: %v", err)
-		return nil, err
-	}
-
-	content := string(body)
-	log.Printf("Tamanho do conteúdo recebido: %d bytes", len(content))
-
-	var dividendos []Dividendo
-
-	// Melhorar o padrão para capturar diferentes formatos
-	// Padrão 1: Tabela padrão com Tipo | Data COM | Pagamento | Valor
-	tablePattern := `(?s)<tr[^>]*>.*?<td[^>]*>\s*(Dividendos?|JCP|JSCP|Rendimentos?)\s*</td>.*?<td[^>]*>\s*(\d{2}/\d{2}/\d{4})\s*</td>.*?<td[^>]*>\s*(\d{2}/\d{2}/\d{4})\s*</td>.*?<td[^>]*>\s*([\d,]+)\s*</td>.*?</tr>`
-	re := regexp.MustCompile(tablePattern)
-	matches := re.FindAllStringSubmatch(content, -1)
-
-	// Se não encontrou com o primeiro padrão, tenta outro
-	if len(matches) == 0 {
-		// Padrão 2: Buscar dividendos com padrão mais flexível
-		blockPattern := `(?s)(Dividendos?|JCP|JSCP|Rendimentos?)[^0-9]{0,50}(\d{2}/\d{2}/\d{4})[^0-9]{0,50}(\d{2}/\d{2}/\d{4})[^0-9]{0,200}([\d,]+)`
-		re = regexp.MustCompile(blockPattern)
-		matches = re.FindAllStringSubmatch(content, -1)
-	}
-
-	log.Printf("Matches encontrados: %d", len(matches))
-
-	seen := make(map[string]bool)
-	for _, match := range matches {
-		if len(match) >= 5 {
-			key := match[2] + match[3]
-			if !seen[key] {
-				seen[key] = true
-				div := Dividendo{
-					Tipo:          match[1],
-					DataCom:       match[2],
-					DataPagamento: match[3],
-					Valor:         match[4],
-				}
-				dividendos = append(dividendos, div)
-				log.Printf("%s - Dividendo encontrado: %+v", ticker, div)
-			}
-		}
+		log.Printf("Erro ao extrair tabela de dividendos de %s: %v", ticker, err)
+		return nil, fmt.Errorf("erro ao extrair dividendos de %s: %w", ticker, err)
 	}
 
-	// Se ainda não encontrou, tenta um padrão mais específico para JSCP
-	if len(dividendos) == 0 {
-		// Padrão 3: Específico para JSCP com vírgula no valor
-		jscpPattern := `JSCP\s+(\d{2}/\d{2}/\d{4})\s+(\d{2}/\d{2}/\d{4})\s+(0,\d+)`
-		re = regexp.MustCompile(jscpPattern)
-		matches = re.FindAllStringSubmatch(content, -1)
-
-		for _, match := range matches {
-			if len(match) >= 4 {
-				key := match[1] + match[2]
-				if !seen[key] {
-					seen[key] = true
-					div := Dividendo{
-						Tipo:          "JSCP",
-						DataCom:       match[1],
-						DataPagamento: match[2],
-						Valor:         match[3],
-					}
-					dividendos = append(dividendos, div)
-					log.Printf("%s - JSCP encontrado: %+v", ticker, div)
-				}
-			}
-		}
-	}
-
-	log.Printf("Total de dividendos únicos encontrados para %s: %d", ticker, len(dividendos))
+	log.Printf("Total de dividendos encontrados para %s: %d", ticker, len(dividendos))
 	return dividendos, nil
 }
 
@@ -275,20 +411,37 @@ func (s *DataComService) analisarPadraoMensal(dividendos []Dividendo, analise *A
 	proximaDataCom := s.calcularProximaDataComMensal(ultimaDataCom, diaComum, hoje)
 	proximaDataComUtil := s.ajustarParaDiaUtil(proximaDataCom)
 	analise.ProximaDataCom = proximaDataComUtil
-	analise.DiasAteDataCom = int(proximaDataComUtil.Sub(hoje).Hours() / 24)
+	analise.DiasAteDataCom = s.Calendario.DiasDePregaoEntre(hoje, proximaDataComUtil)
 }
 
-// analisarPadraoTrimestral analisa ações que pagam trimestral/semestralmente (usado apenas quando não há datas futuras)
-func (s *DataComService) analisarPadraoTrimestral(dividendos []Dividendo, analise *AnaliseDataCom, hoje time.Time) {
-	// Mapear padrão de pagamento por mês
+// mesesRecorrentes mapeia os meses em que o ativo costuma ter data com (ao menos duas
+// ocorrências no histórico) para o dia médio da data com nesse mês
+func mesesRecorrentes(dividendos []Dividendo) map[time.Month]int {
 	padraoMes := make(map[time.Month][]int)
-
 	for _, div := range dividendos {
 		mes := div.DataParsed.Month()
 		dia := div.DataParsed.Day()
 		padraoMes[mes] = append(padraoMes[mes], dia)
 	}
 
+	recorrentes := make(map[time.Month]int)
+	for mes, dias := range padraoMes {
+		if len(dias) >= 2 {
+			soma := 0
+			for _, d := range dias {
+				soma += d
+			}
+			recorrentes[mes] = soma / len(dias)
+		}
+	}
+	return recorrentes
+}
+
+// analisarPadraoTrimestral analisa ações que pagam trimestral/semestralmente (usado apenas quando não há datas futuras)
+func (s *DataComService) analisarPadraoTrimestral(dividendos []Dividendo, analise *AnaliseDataCom, hoje time.Time) {
+	// Mapear padrão de pagamento por mês
+	padraoMes := mesesRecorrentes(dividendos)
+
 	// Encontrar próximo mês provável de pagamento
 	var proximaDataCom *time.Time
 
@@ -297,14 +450,7 @@ func (s *DataComService) analisarPadraoTrimestral(dividendos []Dividendo, analis
 		mesTestado := hoje.AddDate(0, i, 0).Month()
 		anoTestado := hoje.AddDate(0, i, 0).Year()
 
-		if dias, existe := padraoMes[mesTestado]; existe && len(dias) >= 2 {
-			// Calcular dia médio
-			soma := 0
-			for _, d := range dias {
-				soma += d
-			}
-			diaMedia := soma / len(dias)
-
+		if diaMedia, existe := padraoMes[mesTestado]; existe {
 			dataTestada := time.Date(anoTestado, mesTestado, diaMedia, 0, 0, 0, 0, hoje.Location())
 			if dataTestada.After(hoje) {
 				proximaDataCom = &dataTestada
@@ -316,13 +462,13 @@ func (s *DataComService) analisarPadraoTrimestral(dividendos []Dividendo, analis
 	if proximaDataCom != nil {
 		proximaDataComUtil := s.ajustarParaDiaUtil(*proximaDataCom)
 		analise.ProximaDataCom = proximaDataComUtil
-		analise.DiasAteDataCom = int(proximaDataComUtil.Sub(hoje).Hours() / 24)
+		analise.DiasAteDataCom = s.Calendario.DiasDePregaoEntre(hoje, proximaDataComUtil)
 	} else {
 		// Se não encontrou padrão, usar última data + 3 meses
 		proximaDataCom := dividendos[0].DataParsed.AddDate(0, 3, 0)
 		proximaDataComUtil := s.ajustarParaDiaUtil(proximaDataCom)
 		analise.ProximaDataCom = proximaDataComUtil
-		analise.DiasAteDataCom = int(proximaDataComUtil.Sub(hoje).Hours() / 24)
+		analise.DiasAteDataCom = s.Calendario.DiasDePregaoEntre(hoje, proximaDataComUtil)
 	}
 }
 
@@ -352,125 +498,114 @@ func (s *DataComService) calcularProximaDataComMensal(ultimaData time.Time, diaC
 	return proximaData
 }
 
-// ehFeriado verifica se uma data é feriado nacional brasileiro
-func (s *DataComService) ehFeriado(data time.Time) bool {
-	// Feriados fixos (dia/mês)
-	feriadosFixos := []string{
-		"01/01", // Ano Novo
-		"21/04", // Tiradentes
-		"01/05", // Dia do Trabalho
-		"07/09", // Independência do Brasil
-		"12/10", // Nossa Senhora Aparecida
-		"02/11", // Finados
-		"15/11", // Proclamação da República
-		"25/12", // Natal
-	}
-
-	dataStr := data.Format("02/01")
-	for _, feriado := range feriadosFixos {
-		if dataStr == feriado {
-			return true
-		}
-	}
-
-	// Feriados móveis (precisamos calcular baseado no ano)
-	ano := data.Year()
-
-	// Calcular Páscoa (algoritmo de Gauss)
-	pascoa := calcularPascoa(ano)
-
-	// Feriados baseados na Páscoa
-	carnaval := pascoa.AddDate(0, 0, -47)       // 47 dias antes da Páscoa
-	sextaFeiraSanta := pascoa.AddDate(0, 0, -2) // 2 dias antes da Páscoa
-	corpusChristi := pascoa.AddDate(0, 0, 60)   // 60 dias depois da Páscoa
-
-	// Verificar se a data é um dos feriados móveis
-	if data.Format("02/01/2006") == carnaval.Format("02/01/2006") ||
-		data.Format("02/01/2006") == sextaFeiraSanta.Format("02/01/2006") ||
-		data.Format("02/01/2006") == corpusChristi.Format("02/01/2006") {
-		return true
-	}
-
-	return false
-}
-
-// calcularPascoa calcula a data da Páscoa para um determinado ano
-func calcularPascoa(ano int) time.Time {
-	// Algoritmo de Gauss para calcular a Páscoa
-	a := ano % 19
-	b := ano / 100
-	c := ano % 100
-	d := b / 4
-	e := b % 4
-	f := (b + 8) / 25
-	g := (b - f + 1) / 3
-	h := (19*a + b - d - g + 15) % 30
-	i := c / 4
-	k := c % 4
-	l := (32 + 2*e + 2*i - h - k) % 7
-	m := (a + 11*h + 22*l) / 451
-	mes := (h + l - 7*m + 114) / 31
-	dia := ((h + l - 7*m + 114) % 31) + 1
-
-	return time.Date(ano, time.Month(mes), dia, 0, 0, 0, 0, time.UTC)
+// ajustarParaDiaUtil ajusta a data para o dia de pregão anterior, se não houver pregão na data
+func (s *DataComService) ajustarParaDiaUtil(data time.Time) time.Time {
+	return s.Calendario.DiaDePregaoOuAnterior(data)
 }
 
-// ajustarParaDiaUtil ajusta a data para dia útil, considerando fins de semana e feriados
-func (s *DataComService) ajustarParaDiaUtil(data time.Time) time.Time {
-	// Loop para continuar ajustando até encontrar um dia útil
-	for {
-		ajustou := false
-
-		// Se cai no sábado, voltar para sexta
-		if data.Weekday() == time.Saturday {
-			data = data.AddDate(0, 0, -1)
-			ajustou = true
-		}
+// estimarPerdaEsperada calcula a perda por cota de quem compra antes da data com: o preço
+// costuma cair na data ex por uma fração do provento, enquanto o investidor recebe o provento
+// líquido de IR. Usa os proventos da data com anunciada ou, sem anúncio, os da última data com.
+func (s *DataComService) estimarPerdaEsperada(analise *AnaliseDataCom, ticker string, dividendos []Dividendo, dataAnunciada *time.Time) {
+	referencia := dividendos[0].DataParsed
+	if dataAnunciada != nil {
+		referencia = *dataAnunciada
+	}
 
-		// Se cai no domingo, voltar para sexta
-		if data.Weekday() == time.Sunday {
-			data = data.AddDate(0, 0, -2)
-			ajustou = true
+	for _, div := range dividendos {
+		if !div.DataParsed.Equal(referencia) {
+			continue
 		}
 
-		// Se é feriado, voltar um dia
-		if s.ehFeriado(data) {
-			data = data.AddDate(0, 0, -1)
-			ajustou = true
+		aliquota := s.Tributacao.AliquotaProvento(normalizarTipoProvento(div.Tipo))
+		if analise.TipoAtivo == "FII" {
+			aliquota = s.Tributacao.AliquotaFII(ticker)
 		}
 
-		// Se não precisou ajustar nada, encontramos um dia útil
-		if !ajustou {
-			break
-		}
+		analise.ProventoBrutoPorCota += div.ValorDecimal
+		analise.ProventoLiquidoPorCota += ValorLiquido(div.ValorDecimal, aliquota)
 	}
 
-	return data
+	regra := s.RegraDataCom(analise.TipoAtivo)
+	analise.QuedaExDataPorCota = analise.ProventoBrutoPorCota * regra.QuedaExData
+	analise.PerdaEsperadaPorCota = analise.QuedaExDataPorCota - analise.ProventoLiquidoPorCota
 }
 
-// definirStatusCompra define o status e mensagem baseado nos dias até a data com
+// definirStatusCompra define o status e a mensagem com base nos pregões até a data com e nas
+// janelas configuradas para a classe do ativo. A mensagem inclui a regra que levou ao status.
 func (s *DataComService) definirStatusCompra(analise *AnaliseDataCom) {
 	dias := analise.DiasAteDataCom
+	regra := s.RegraDataCom(analise.TipoAtivo)
+	data := analise.ProximaDataCom.Format("02/01/2006")
 
+	if analise.ProximaDataCom.IsZero() {
+		analise.StatusCompra = "SEGURO"
+		analise.MensagemStatus = "✅ Sem data com prevista - Compra liberada"
+		return
+	}
 	if dias < 0 {
 		analise.StatusCompra = "SEGURO"
 		analise.MensagemStatus = "✅ Data com já passou - Compra liberada"
-	} else if dias == 0 {
+		return
+	}
+
+	switch {
+	case dias <= regra.DiasNaoComprar:
 		analise.StatusCompra = "NAO_COMPRAR"
-		analise.MensagemStatus = "🚫 HOJE É A DATA COM - NÃO COMPRAR!"
-	} else if dias <= 2 {
+		if dias == 0 {
+			analise.MensagemStatus = "🚫 HOJE É A DATA COM - NÃO COMPRAR!"
+		} else {
+			analise.MensagemStatus = fmt.Sprintf("🚫 NÃO COMPRAR - Data com em %d pregões (%s)", dias, data)
+		}
+		analise.MensagemStatus += fmt.Sprintf(". Regra %s: não comprar até %d pregões antes da data com", analise.TipoAtivo, regra.DiasNaoComprar)
+	case dias <= regra.DiasEvitar:
 		analise.StatusCompra = "EVITAR"
-		analise.MensagemStatus = fmt.Sprintf("🚫 EVITE COMPRAR - Data com em %d dias (%s)",
-			dias, analise.ProximaDataCom.Format("02/01/2006"))
-	} else if dias <= 5 {
+		analise.MensagemStatus = fmt.Sprintf("🚫 EVITE COMPRAR - Data com em %d pregões (%s). Regra %s: evitar até %d pregões antes",
+			dias, data, analise.TipoAtivo, regra.DiasEvitar)
+	case dias <= regra.DiasAlerta:
 		analise.StatusCompra = "ALERTA"
-		analise.MensagemStatus = fmt.Sprintf("⚠️ ALERTA - Data com em %d dias (%s). Considere aguardar",
-			dias, analise.ProximaDataCom.Format("02/01/2006"))
-	} else {
+		analise.MensagemStatus = fmt.Sprintf("⚠️ ALERTA - Data com em %d pregões (%s). Considere aguardar. Regra %s: alerta até %d pregões antes",
+			dias, data, analise.TipoAtivo, regra.DiasAlerta)
+	default:
 		analise.StatusCompra = "SEGURO"
-		analise.MensagemStatus = fmt.Sprintf("✅ SEGURO - %d dias até a data com (%s)",
-			dias, analise.ProximaDataCom.Format("02/01/2006"))
+		analise.MensagemStatus = fmt.Sprintf("✅ SEGURO - %d pregões até a data com (%s), acima da janela de alerta de %d pregões",
+			dias, data, regra.DiasAlerta)
+	}
+
+	if s.UsarPerdaEsperada && analise.StatusCompra != "SEGURO" && analise.ProventoBrutoPorCota > 0 {
+		analise.MensagemStatus += ". " + descreverPerdaEsperada(analise)
+	}
+}
+
+// AvaliarCompra define o status de compra para uma quantidade do ativo. Com a opção de perda
+// esperada ativa, uma compra dentro da janela é liberada quando a perda total (perda por cota
+// vezes a quantidade) não passa da perda tolerável da classe; caso contrário, vale o status por pregões.
+func (s *DataComService) AvaliarCompra(analise *AnaliseDataCom, quantidade int) (string, string) {
+	if !s.UsarPerdaEsperada || analise.StatusCompra == "SEGURO" || analise.ProventoBrutoPorCota <= 0 {
+		return analise.StatusCompra, analise.MensagemStatus
 	}
+
+	regra := s.RegraDataCom(analise.TipoAtivo)
+	perdaTotal := analise.PerdaEsperadaPorCota * float64(quantidade)
+	if perdaTotal <= regra.PerdaToleravel {
+		return "SEGURO", fmt.Sprintf("✅ LIBERADO - Data com em %d pregões (%s), mas a perda esperada para %d cotas é de %s, dentro do limite de %s. %s",
+			analise.DiasAteDataCom, analise.ProximaDataCom.Format("02/01/2006"), quantidade,
+			formatarReais(perdaTotal), formatarReais(regra.PerdaToleravel), descreverPerdaEsperada(analise))
+	}
+
+	return analise.StatusCompra, fmt.Sprintf("%s. Perda esperada para %d cotas: %s (limite de %s)",
+		analise.MensagemStatus, quantidade, formatarReais(perdaTotal), formatarReais(regra.PerdaToleravel))
+}
+
+// descreverPerdaEsperada explica a composição da perda esperada por cota
+func descreverPerdaEsperada(analise *AnaliseDataCom) string {
+	return fmt.Sprintf("Perda esperada de %s/cota: queda típica de %s na data ex contra provento líquido de %s",
+		formatarReais(analise.PerdaEsperadaPorCota), formatarReais(analise.QuedaExDataPorCota), formatarReais(analise.ProventoLiquidoPorCota))
+}
+
+// formatarReais formata um valor em reais no padrão brasileiro (ex: R$ 1,25)
+func formatarReais(valor float64) string {
+	return "R$ " + strings.Replace(fmt.Sprintf("%.2f", valor), ".", ",", 1)
 }
 
 // min retorna o menor entre dois inteiros
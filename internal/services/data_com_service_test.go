@@ -0,0 +1,68 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/cache"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/relogio"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// transporteFixture responde a qualquer requisição com a página de fixture, contando os downloads
+type transporteFixture struct {
+	pagina     string
+	requisicao atomic.Int32
+}
+
+func (t *transporteFixture) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requisicao.Add(1)
+	time.Sleep(50 * time.Millisecond) // Mantém o download em andamento enquanto as outras chamadas chegam
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(t.pagina)),
+		Request:    req,
+	}, nil
+}
+
+func TestAnalisarDataComTickerCompartilhaDownloadEntreInstancias(t *testing.T) {
+	pagina, err := os.ReadFile(filepath.Join("testdata", "extrator_dividendos", "fii_hglg11.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transporte := &transporteFixture{pagina: string(pagina)}
+
+	cfg := config.Load()
+	cacheInstancia := cache.GetInstance(cfg.CacheDuracao, cfg.CacheLimpeza)
+	rel := relogio.Fixo{Momento: time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)}
+
+	// Cada requisição HTTP cria o próprio serviço, como em NewHandlers
+	var servicos []*DataComService
+	for i := 0; i < 4; i++ {
+		servico := NewDataComService(cfg, cacheInstancia, rel)
+		servico.HTTPClient = &http.Client{Transport: transporte}
+		servicos = append(servicos, servico)
+	}
+
+	var wg sync.WaitGroup
+	for _, servico := range servicos {
+		wg.Add(1)
+		go func(servico *DataComService) {
+			defer wg.Done()
+			if _, err := servico.AnalisarDataComTicker("HGLG11", "FII"); err != nil {
+				t.Errorf("erro na análise: %v", err)
+			}
+		}(servico)
+	}
+	wg.Wait()
+
+	if downloads := transporte.requisicao.Load(); downloads != 1 {
+		t.Fatalf("esperava 1 download compartilhado, obteve %d", downloads)
+	}
+}
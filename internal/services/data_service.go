@@ -5,6 +5,7 @@ import (
 	"calculadora-investimentos/internal/api"
 	"calculadora-investimentos/internal/config"
 	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/utils"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -110,9 +111,91 @@ func (s *DataService) CarregarRecomendadosAcao() ([]models.AcaoRecomendada, erro
 	return recomendados, scanner.Err()
 }
 
+// CarregarSetoresAcoes carrega a classificação setorial das ações do arquivo. Cada linha traz o ticker
+// (ou a raiz de 4 letras, que vale para todas as classes de ações da empresa), o setor e o subsetor.
+func (s *DataService) CarregarSetoresAcoes() (map[string]models.SetorAcao, error) {
+	nomeArquivo := filepath.Join(s.Config.DataDir, "setores_acoes.txt")
+	file, err := os.Open(nomeArquivo)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	setores := make(map[string]models.SetorAcao)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		campos := strings.Split(scanner.Text(), "\t")
+		if len(campos) == 3 {
+			setores[strings.ToUpper(strings.TrimSpace(campos[0]))] = models.SetorAcao{
+				Setor:    strings.TrimSpace(campos[1]),
+				Subsetor: strings.TrimSpace(campos[2]),
+			}
+		}
+	}
+
+	return setores, scanner.Err()
+}
+
+// CarregarComposicaoFoFs carrega a composição dos fundos de fundos do arquivo. Cada linha traz o FoF,
+// o FII investido e o percentual do patrimônio do FoF aplicado nele; linhas iniciadas por "#" são
+// comentários. A parcela não listada continua atribuída ao próprio FoF. Uma linha inválida devolve
+// erro, para que um erro de digitação não remova a exposição sem aviso.
+func (s *DataService) CarregarComposicaoFoFs() (map[string]map[string]float64, error) {
+	nomeArquivo := filepath.Join(s.Config.DataDir, "composicao_fofs.txt")
+	file, err := os.Open(nomeArquivo)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	composicao := make(map[string]map[string]float64)
+	scanner := bufio.NewScanner(file)
+
+	for numero := 1; scanner.Scan(); numero++ {
+		linha := strings.TrimSpace(scanner.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+
+		campos := strings.Split(linha, "\t")
+		if len(campos) != 3 {
+			return nil, fmt.Errorf("%s, linha %d: esperava FoF, FII e percentual separados por tabulação", nomeArquivo, numero)
+		}
+		percentual, err := strconv.ParseFloat(strings.Replace(strings.TrimSuffix(strings.TrimSpace(campos[2]), "%"), ",", ".", -1), 64)
+		if err != nil || percentual <= 0 || percentual > 100 {
+			return nil, fmt.Errorf("%s, linha %d: percentual inválido %q para o FoF %s", nomeArquivo, numero, campos[2], campos[0])
+		}
+
+		fof := strings.ToUpper(strings.TrimSpace(campos[0]))
+		if composicao[fof] == nil {
+			composicao[fof] = make(map[string]float64)
+		}
+		composicao[fof][strings.ToUpper(strings.TrimSpace(campos[1]))] = percentual
+	}
+
+	return composicao, scanner.Err()
+}
+
 // CarregarRecomendadosETF carrega as recomendações de ETFs do arquivo
 func (s *DataService) CarregarRecomendadosETF() ([]models.ETFRecomendado, error) {
-	nomeArquivo := filepath.Join(s.Config.DataDir, "recomendados_etfs.txt")
+	return s.carregarRecomendadosTickers("recomendados_etfs.txt")
+}
+
+// CarregarRecomendadosBDR carrega as recomendações de BDRs do arquivo
+func (s *DataService) CarregarRecomendadosBDR() ([]models.ETFRecomendado, error) {
+	return s.carregarRecomendadosTickers("recomendados_bdrs.txt")
+}
+
+// CarregarRecomendadosCripto carrega as recomendações de ETFs de criptoativos do arquivo
+func (s *DataService) CarregarRecomendadosCripto() ([]models.ETFRecomendado, error) {
+	return s.carregarRecomendadosTickers("recomendados_cripto.txt")
+}
+
+// carregarRecomendadosTickers lê um arquivo de recomendações com ticker, nome e peso ideal
+// separados por tabulação, obtendo o preço de cada ticker via API
+func (s *DataService) carregarRecomendadosTickers(arquivo string) ([]models.ETFRecomendado, error) {
+	nomeArquivo := filepath.Join(s.Config.DataDir, arquivo)
 	file, err := os.Open(nomeArquivo)
 	if err != nil {
 		return nil, err
@@ -150,6 +233,47 @@ func (s *DataService) CarregarRecomendadosETF() ([]models.ETFRecomendado, error)
 	return recomendados, scanner.Err()
 }
 
+// CarregarRecomendadosRendaFixa carrega os títulos de renda fixa recomendados do arquivo.
+// Colunas: nome, tipo, emissor, indexador, taxa, vencimento, liquidez, aplicação mínima e peso ideal.
+func (s *DataService) CarregarRecomendadosRendaFixa() ([]models.RendaFixaRecomendada, error) {
+	nomeArquivo := filepath.Join(s.Config.DataDir, "recomendados_rendafixa.txt")
+	file, err := os.Open(nomeArquivo)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var recomendados []models.RendaFixaRecomendada
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		linha := scanner.Text()
+		campos := strings.Split(linha, "\t")
+
+		if len(campos) == 9 {
+			taxa, _ := utils.ProcessarValorMonetario(strings.TrimSuffix(campos[4], "%"))
+			aplicacaoMinima, _ := utils.ProcessarValorMonetario(campos[7])
+			pesoIdeal, _ := strconv.ParseFloat(strings.Replace(strings.TrimSuffix(campos[8], "%"), ",", ".", -1), 64)
+
+			titulo := models.RendaFixaRecomendada{
+				Nome:            campos[0],
+				Tipo:            campos[1],
+				Emissor:         campos[2],
+				Indexador:       strings.ToUpper(campos[3]),
+				Taxa:            taxa,
+				Vencimento:      campos[5],
+				LiquidezDiaria:  strings.EqualFold(campos[6], "Diária"),
+				AplicacaoMinima: aplicacaoMinima,
+				PesoIdeal:       pesoIdeal,
+			}
+
+			recomendados = append(recomendados, titulo)
+		}
+	}
+
+	return recomendados, scanner.Err()
+}
+
 // ObterCarteiraAtualFII obtém a carteira atual de FIIs via API
 func (s *DataService) ObterCarteiraAtualFII() (*models.CarteiraDados, error) {
 	url := fmt.Sprintf("https://investidor10.com.br/api/carteiras/datatable/ativos/%s/Fii?draw=1", s.Config.IDInvestidor10)
@@ -222,6 +346,56 @@ func (s *DataService) ObterCarteiraAtualETF() (*models.CarteiraETFs, error) {
 	return &carteira, nil
 }
 
+// ObterCarteiraAtualBDR obtém a carteira atual de BDRs via API
+func (s *DataService) ObterCarteiraAtualBDR() (*models.CarteiraETFs, error) {
+	url := fmt.Sprintf("https://investidor10.com.br/api/carteiras/datatable/ativos/%s/Bdr?draw=1", s.Config.IDInvestidor10)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var carteira models.CarteiraETFs
+	err = json.Unmarshal(body, &carteira)
+	if err != nil {
+		return nil, err
+	}
+
+	return &carteira, nil
+}
+
+// SepararCarteiraCripto separa da carteira de ETFs os ETFs de criptoativos (os configurados e os recomendados),
+// retornando a carteira de ETFs sem eles e a carteira de criptoativos
+func (s *DataService) SepararCarteiraCripto(carteiraETF *models.CarteiraETFs, recomendadosCripto []models.ETFRecomendado) (*models.CarteiraETFs, *models.CarteiraETFs) {
+	tickersCripto := make(map[string]bool)
+	for _, ticker := range s.Config.TickersCripto {
+		tickersCripto[ticker] = true
+	}
+	for _, rec := range recomendadosCripto {
+		tickersCripto[rec.Ticker] = true
+	}
+
+	etfs := &models.CarteiraETFs{Draw: carteiraETF.Draw, Data: []models.AtivoETF{}}
+	cripto := &models.CarteiraETFs{Draw: carteiraETF.Draw, Data: []models.AtivoETF{}}
+	for _, ativo := range carteiraETF.Data {
+		if tickersCripto[ativo.TickerName] {
+			cripto.Data = append(cripto.Data, ativo)
+		} else {
+			etfs.Data = append(etfs.Data, ativo)
+		}
+	}
+	etfs.Total = len(etfs.Data)
+	cripto.Total = len(cripto.Data)
+
+	return etfs, cripto
+}
+
 // ObterCarteiraAtualRendaFixa obtém a carteira atual de Renda Fixa via API
 func (s *DataService) ObterCarteiraAtualRendaFixa() (*models.CarteiraRendaFixa, error) {
 	url := fmt.Sprintf("https://investidor10.com.br/api/carteiras/datatable/outrosativos/%s/fixed?draw=1", s.Config.IDInvestidor10)
@@ -11,97 +11,58 @@ import (
 
 // DistribuidoraService gerencia a distribuição de ativos na carteira
 type DistribuidoraService struct {
-	Config *config.Config
+	Config      *config.Config
+	indicadores FonteIndicadores
 }
 
-// NewDistribuidoraService cria um novo serviço de distribuição
-func NewDistribuidoraService(cfg *config.Config) *DistribuidoraService {
+// NewDistribuidoraService cria um novo serviço de distribuição. A fonte de indicadores fornece o
+// P/VP dos FIIs recomendados fora da carteira para a inclinação por P/VP.
+func NewDistribuidoraService(cfg *config.Config, indicadores FonteIndicadores) *DistribuidoraService {
 	return &DistribuidoraService{
-		Config: cfg,
+		Config:      cfg,
+		indicadores: indicadores,
 	}
 }
 
+// ClassesInvestimento lista as classes de ativos na ordem em que são exibidas: as classes de renda
+// variável registradas, seguidas da renda fixa
+var ClassesInvestimento = append(nomesClasses(ClassesRendaVariavel), NomeRendaFixa)
+
 // CalcularDistribuicaoAtual calcula a distribuição atual da carteira
-func (s *DistribuidoraService) CalcularDistribuicaoAtual(
-	valorFII, valorAcao, valorETF, valorRendaFixa, valorTotal float64,
-) map[string]float64 {
+func (s *DistribuidoraService) CalcularDistribuicaoAtual(valoresAtuais map[string]float64, valorTotal float64) map[string]float64 {
 	distribuicao := make(map[string]float64)
 
-	if valorTotal > 0 {
-		distribuicao["FIIs"] = (valorFII / valorTotal) * 100
-		distribuicao["Ações"] = (valorAcao / valorTotal) * 100
-		distribuicao["ETFs"] = (valorETF / valorTotal) * 100
-		distribuicao["RendaFixa"] = (valorRendaFixa / valorTotal) * 100
-	} else {
-		distribuicao["FIIs"] = 0
-		distribuicao["Ações"] = 0
-		distribuicao["ETFs"] = 0
-		distribuicao["RendaFixa"] = 0
+	for _, classe := range ClassesInvestimento {
+		if valorTotal > 0 {
+			distribuicao[classe] = (valoresAtuais[classe] / valorTotal) * 100
+		} else {
+			distribuicao[classe] = 0
+		}
 	}
 
 	return distribuicao
 }
 
-// CalcularDistribuicaoIdeal calcula a distribuição ideal com base nos tipos selecionados
+// CalcularDistribuicaoIdeal calcula a distribuição ideal com base nos tipos selecionados,
+// redistribuindo proporcionalmente os percentuais padrão das classes selecionadas
 func (s *DistribuidoraService) CalcularDistribuicaoIdeal(tiposInvestimento models.TiposInvestimento) map[string]float64 {
-	distribuicaoIdeal := map[string]float64{
-		"FIIs":      0.0,
-		"Ações":     0.0,
-		"ETFs":      0.0,
-		"RendaFixa": 0.0,
-	}
-
-	// Contar quantos tipos foram selecionados
-	tiposSelecionados := 0
-	if tiposInvestimento.FIIs {
-		tiposSelecionados++
-	}
-	if tiposInvestimento.Acoes {
-		tiposSelecionados++
-	}
-	if tiposInvestimento.ETFs {
-		tiposSelecionados++
-	}
-	if tiposInvestimento.RendaFixa {
-		tiposSelecionados++
+	distribuicaoIdeal := make(map[string]float64)
+
+	// Calcular o total dos percentuais padrão dos tipos selecionados
+	percentuaisPadrao := s.Config.DistribuicaoIdeal
+	totalSelecionado := 0.0
+	for _, classe := range ClassesInvestimento {
+		distribuicaoIdeal[classe] = 0.0
+		if tiposInvestimento[classe] {
+			totalSelecionado += percentuaisPadrao[classe]
+		}
 	}
 
-	// Distribuir os percentuais proporcionalmente
-	if tiposSelecionados > 0 {
-		// Usar a distribuição ideal da configuração
-		percentuaisPadrao := s.Config.DistribuicaoIdeal
-
-		// Se todos os tipos estão selecionados, usar os percentuais padrão
-		if tiposSelecionados == 4 {
-			distribuicaoIdeal = percentuaisPadrao
-		} else {
-			// Calcular o total dos percentuais padrão dos tipos selecionados
-			totalSelecionado := 0.0
-			if tiposInvestimento.FIIs {
-				totalSelecionado += percentuaisPadrao["FIIs"]
-			}
-			if tiposInvestimento.Acoes {
-				totalSelecionado += percentuaisPadrao["Ações"]
-			}
-			if tiposInvestimento.ETFs {
-				totalSelecionado += percentuaisPadrao["ETFs"]
-			}
-			if tiposInvestimento.RendaFixa {
-				totalSelecionado += percentuaisPadrao["RendaFixa"]
-			}
-
-			// Distribuir proporcionalmente
-			if tiposInvestimento.FIIs {
-				distribuicaoIdeal["FIIs"] = (percentuaisPadrao["FIIs"] / totalSelecionado) * 100.0
-			}
-			if tiposInvestimento.Acoes {
-				distribuicaoIdeal["Ações"] = (percentuaisPadrao["Ações"] / totalSelecionado) * 100.0
-			}
-			if tiposInvestimento.ETFs {
-				distribuicaoIdeal["ETFs"] = (percentuaisPadrao["ETFs"] / totalSelecionado) * 100.0
-			}
-			if tiposInvestimento.RendaFixa {
-				distribuicaoIdeal["RendaFixa"] = (percentuaisPadrao["RendaFixa"] / totalSelecionado) * 100.0
+	// Distribuir proporcionalmente
+	if totalSelecionado > 0 {
+		for _, classe := range ClassesInvestimento {
+			if tiposInvestimento[classe] {
+				distribuicaoIdeal[classe] = (percentuaisPadrao[classe] / totalSelecionado) * 100.0
 			}
 		}
 	}
@@ -120,134 +81,21 @@ type ClassePrioridade struct {
 	PrioridadeScore     float64 // Score combinado para priorização
 }
 
-// DistribuirInvestimento distribui o valor do investimento entre as classes de ativos
-// VERSÃO MELHORADA: Considera tanto distância percentual quanto valor absoluto
-func (s *DistribuidoraService) DistribuirInvestimento(
-	valorInvestimento, valorTotalFalta, valorFaltaFII, valorFaltaAcao, valorFaltaETF, valorFaltaRendaFixa float64,
-	distribuicaoIdeal map[string]float64,
-) (float64, float64, float64, float64) {
-
-	// Precisamos receber também os valores atuais e percentuais atuais
-	// Por ora, vamos usar uma abordagem simplificada baseada nos valores faltantes
-
-	// Criar estrutura para análise de prioridade
-	classes := []ClassePrioridade{
-		{
-			Nome:            "FIIs",
-			ValorFalta:      valorFaltaFII,
-			PercentualIdeal: distribuicaoIdeal["FIIs"],
-		},
-		{
-			Nome:            "Ações",
-			ValorFalta:      valorFaltaAcao,
-			PercentualIdeal: distribuicaoIdeal["Ações"],
-		},
-		{
-			Nome:            "ETFs",
-			ValorFalta:      valorFaltaETF,
-			PercentualIdeal: distribuicaoIdeal["ETFs"],
-		},
-		{
-			Nome:            "RendaFixa",
-			ValorFalta:      valorFaltaRendaFixa,
-			PercentualIdeal: distribuicaoIdeal["RendaFixa"],
-		},
-	}
-
-	// Log das informações
-	log.Printf("=== ANÁLISE DE PRIORIZAÇÃO ===")
-	log.Printf("Valor a investir: R$ %.2f", valorInvestimento)
-
-	// Se o investimento é suficiente para cobrir todas as faltas
-	if valorTotalFalta <= valorInvestimento {
-		log.Printf("Investimento suficiente para atingir distribuição ideal!")
-
-		valorParaFII := valorFaltaFII
-		valorParaAcao := valorFaltaAcao
-		valorParaETF := valorFaltaETF
-		valorParaRendaFixa := valorFaltaRendaFixa
-
-		// Distribuir sobra proporcionalmente
-		sobra := valorInvestimento - valorTotalFalta
-		if sobra > 0 {
-			log.Printf("Sobra após atingir ideal: R$ %.2f", sobra)
-
-			if distribuicaoIdeal["FIIs"] > 0 {
-				valorParaFII += sobra * (distribuicaoIdeal["FIIs"] / 100)
-			}
-			if distribuicaoIdeal["Ações"] > 0 {
-				valorParaAcao += sobra * (distribuicaoIdeal["Ações"] / 100)
-			}
-			if distribuicaoIdeal["ETFs"] > 0 {
-				valorParaETF += sobra * (distribuicaoIdeal["ETFs"] / 100)
-			}
-			if distribuicaoIdeal["RendaFixa"] > 0 {
-				valorParaRendaFixa += sobra * (distribuicaoIdeal["RendaFixa"] / 100)
-			}
-		}
-
-		log.Printf("Distribuição final:")
-		log.Printf("  FIIs: R$ %.2f", valorParaFII)
-		log.Printf("  Ações: R$ %.2f", valorParaAcao)
-		log.Printf("  ETFs: R$ %.2f", valorParaETF)
-		log.Printf("  Renda Fixa: R$ %.2f", valorParaRendaFixa)
-
-		return valorParaFII, valorParaAcao, valorParaETF, valorParaRendaFixa
-	}
-
-	// Investimento insuficiente: alocar proporcionalmente às faltas
-	log.Printf("Investimento insuficiente - distribuindo proporcionalmente às necessidades")
-
-	// Filtrar apenas classes que precisam de investimento
-	var classesNecessitando []ClassePrioridade
-	totalNecessario := 0.0
-
-	for _, classe := range classes {
-		if classe.ValorFalta > 0 {
-			classesNecessitando = append(classesNecessitando, classe)
-			totalNecessario += classe.ValorFalta
-		}
-	}
-
-	// Distribuir proporcionalmente
-	valorParaFII := 0.0
-	valorParaAcao := 0.0
-	valorParaETF := 0.0
-	valorParaRendaFixa := 0.0
-
-	for _, classe := range classesNecessitando {
-		// Proporcional ao quanto cada classe precisa
-		proporcao := classe.ValorFalta / totalNecessario
-		valorAlocado := valorInvestimento * proporcao
-
-		log.Printf("%s precisa R$ %.2f (%.1f%% do total necessário) - alocando R$ %.2f",
-			classe.Nome, classe.ValorFalta, proporcao*100, valorAlocado)
-
-		switch classe.Nome {
-		case "FIIs":
-			valorParaFII = valorAlocado
-		case "Ações":
-			valorParaAcao = valorAlocado
-		case "ETFs":
-			valorParaETF = valorAlocado
-		case "RendaFixa":
-			valorParaRendaFixa = valorAlocado
-		}
-	}
-
-	log.Printf("================================")
-
-	return valorParaFII, valorParaAcao, valorParaETF, valorParaRendaFixa
-}
-
-// DistribuirInvestimentoComPrioridade - NOVA FUNÇÃO que recebe mais informações
+// DistribuirInvestimentoComPrioridade - NOVA FUNÇÃO que recebe mais informações.
+// O que falta para a reserva de emergência é completado primeiro; o restante é dividido entre as classes.
+// Retorna o valor destinado a cada classe e o valor destinado à reserva de emergência.
 func (s *DistribuidoraService) DistribuirInvestimentoComPrioridade(
 	valorInvestimento float64,
 	valoresAtuais map[string]float64,
 	percentuaisAtuais map[string]float64,
 	distribuicaoIdeal map[string]float64,
 	valorTotalCarteira float64,
-) (float64, float64, float64, float64) {
+	faltaReserva float64,
+) (map[string]float64, float64) {
+
+	// Completar a reserva de emergência antes de investir nas classes
+	valorParaReserva := math.Min(math.Max(0, faltaReserva), valorInvestimento)
+	valorInvestimento -= valorParaReserva
 
 	valorTotalFuturo := valorTotalCarteira + valorInvestimento
 
@@ -285,6 +133,9 @@ func (s *DistribuidoraService) DistribuirInvestimentoComPrioridade(
 
 	// Log detalhado
 	log.Printf("=== PRIORIZAÇÃO POR DISTÂNCIA PERCENTUAL ===")
+	if valorParaReserva > 0 {
+		log.Printf("Reserva de emergência: R$ %.2f", valorParaReserva)
+	}
 	log.Printf("Investimento: R$ %.2f", valorInvestimento)
 	for i, c := range classes {
 		log.Printf("%d. %s: Atual %.2f%% → Ideal %.2f%% (distância: %.2f%%) - Falta R$ %.2f",
@@ -292,10 +143,10 @@ func (s *DistribuidoraService) DistribuirInvestimentoComPrioridade(
 	}
 
 	// Alocar recursos
-	valorParaFII := 0.0
-	valorParaAcao := 0.0
-	valorParaETF := 0.0
-	valorParaRendaFixa := 0.0
+	valoresPorClasse := make(map[string]float64)
+	for _, classe := range ClassesInvestimento {
+		valoresPorClasse[classe] = 0.0
+	}
 	valorRestante := valorInvestimento
 
 	// Primeira passada: alocar priorizando maior distância percentual
@@ -305,17 +156,7 @@ func (s *DistribuidoraService) DistribuirInvestimentoComPrioridade(
 		}
 
 		valorAlocar := math.Min(classe.ValorFalta, valorRestante)
-
-		switch classe.Nome {
-		case "FIIs":
-			valorParaFII = valorAlocar
-		case "Ações":
-			valorParaAcao = valorAlocar
-		case "ETFs":
-			valorParaETF = valorAlocar
-		case "RendaFixa":
-			valorParaRendaFixa = valorAlocar
-		}
+		valoresPorClasse[classe.Nome] = valorAlocar
 
 		log.Printf("Alocando R$ %.2f para %s", valorAlocar, classe.Nome)
 		valorRestante -= valorAlocar
@@ -330,11 +171,56 @@ func (s *DistribuidoraService) DistribuirInvestimentoComPrioridade(
 	}
 
 	log.Printf("Distribuição final:")
-	log.Printf("  FIIs: R$ %.2f", valorParaFII)
-	log.Printf("  Ações: R$ %.2f", valorParaAcao)
-	log.Printf("  ETFs: R$ %.2f", valorParaETF)
-	log.Printf("  Renda Fixa: R$ %.2f", valorParaRendaFixa)
+	for _, classe := range ClassesInvestimento {
+		log.Printf("  %s: R$ %.2f", classe, valoresPorClasse[classe])
+	}
 	log.Printf("============================================")
 
-	return valorParaFII, valorParaAcao, valorParaETF, valorParaRendaFixa
+	return valoresPorClasse, valorParaReserva
+}
+
+// MetaReservaEmergencia retorna a meta da reserva de emergência: o valor fixo configurado ou, sem ele,
+// os meses configurados sobre a despesa mensal informada (ou a padrão)
+func (s *DistribuidoraService) MetaReservaEmergencia(despesaMensal float64) float64 {
+	if s.Config.ReservaEmergenciaValor > 0 {
+		return s.Config.ReservaEmergenciaValor
+	}
+	if despesaMensal <= 0 {
+		despesaMensal = s.Config.DespesaMensal
+	}
+	return math.Max(0, s.Config.ReservaEmergenciaMeses*despesaMensal)
+}
+
+// AvaliarReservaEmergencia separa, da renda fixa com liquidez diária (incluindo os aportes planejados
+// na reserva ainda fora da carteira), a parte que compõe a reserva de emergência (até a meta).
+// O aporte é preenchido após a distribuição do investimento.
+func (s *DistribuidoraService) AvaliarReservaEmergencia(carteira *models.CarteiraRendaFixa, reservaPlanejada, meta float64) *models.ReservaEmergencia {
+	reserva := &models.ReservaEmergencia{Meta: meta}
+	if meta <= 0 {
+		return reserva
+	}
+
+	valorLiquido := reservaPlanejada
+	if carteira != nil {
+		for _, ativo := range carteira.Data {
+			if ativo.DailyLiquidity == 1 {
+				valor, _ := converterNumero(ativo.EquityTotal)
+				valorLiquido += valor
+			}
+		}
+	}
+	reserva.ValorAtual = math.Min(valorLiquido, meta)
+	registrarAporteReserva(reserva, 0)
+
+	return reserva
+}
+
+// registrarAporteReserva soma o aporte à reserva e atualiza a situação em relação à meta
+func registrarAporteReserva(reserva *models.ReservaEmergencia, valor float64) {
+	reserva.Aporte += valor
+	reserva.ValorFinal = reserva.ValorAtual + reserva.Aporte
+	if reserva.Meta > 0 {
+		reserva.Percentual = reserva.ValorFinal / reserva.Meta * 100
+	}
+	reserva.Completa = reserva.ValorFinal >= reserva.Meta-0.01
 }
@@ -1,25 +1,41 @@
 package services
 
 import (
+	"calculadora-investimentos/internal/cache"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
-// DividendoService gerencia a busca de dividendos dos FIIs
+// FonteProventos fornece o histórico de proventos de FIIs e ações
+type FonteProventos interface {
+	ObterHistoricoProventos(ticker string, tipoAtivo string) ([]models.ProventoHistorico, error)
+}
+
+// DividendoService gerencia a busca de dividendos de FIIs e ações
 type DividendoService struct {
-	HTTPClient *http.Client
+	HTTPClient   *http.Client
+	Cache        *cache.Cache
+	DuracaoCache time.Duration
+	Estimador    string // Estimador usado para projetar os proventos a partir do histórico
 }
 
 // NewDividendoService cria um novo serviço de dividendos
-func NewDividendoService() *DividendoService {
+func NewDividendoService(cfg *config.Config, cacheInstance *cache.Cache) *DividendoService {
 	return &DividendoService{
 		HTTPClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		Cache:        cacheInstance,
+		DuracaoCache: cfg.CacheDuracaoProventos,
+		Estimador:    cfg.EstimadorProventos,
 	}
 }
 
@@ -41,6 +57,13 @@ type DividendoHistorico struct {
 
 // ObterTickerID busca o ticker_id do FII
 func (s *DividendoService) ObterTickerID(ticker string) (int, error) {
+	cacheKey := fmt.Sprintf("ticker_id_%s", ticker)
+	if s.Cache != nil {
+		if cached, found := s.Cache.Get(cacheKey); found {
+			return cached.(int), nil
+		}
+	}
+
 	url := fmt.Sprintf("https://investidor10.com.br/api/fii/searchquery/%s/", ticker)
 
 	resp, err := s.HTTPClient.Get(url)
@@ -68,6 +91,11 @@ func (s *DividendoService) ObterTickerID(ticker string) (int, error) {
 		return 0, fmt.Errorf("nenhum resultado encontrado para ticker %s", ticker)
 	}
 
+	// O ticker_id não muda, então pode ficar em cache pelo mesmo período do histórico
+	if s.Cache != nil {
+		s.Cache.Set(cacheKey, results[0].TickerID, s.DuracaoCache)
+	}
+
 	return results[0].TickerID, nil
 }
 
@@ -123,45 +151,72 @@ func (s *DividendoService) ObterDividendoFII(ticker string) (float64, error) {
 	dividendo, err := s.ObterUltimoDividendo(tickerID)
 	if err != nil {
 		log.Printf("Erro ao obter dividendo para %s (id: %d): %v", ticker, tickerID, err)
-		return 0, err
+
+		// Tentar o histórico completo de proventos antes de desistir
+		proventos, errHistorico := s.ObterHistoricoProventos(ticker, "FII")
+		if errHistorico != nil || len(proventos) == 0 {
+			return 0, err
+		}
+		return proventos[0].Valor, nil
 	}
 
 	return dividendo, nil
 }
 
-// CalcularRendimentosCarteira calcula os rendimentos mensais de todos os FIIs da carteira
-func (s *DividendoService) CalcularRendimentosCarteira(fiis []FIIComRendimento) []FIIComRendimento {
-	for i := range fiis {
-		// Buscar o último dividendo para cada FII
-		dividendo, err := s.ObterDividendoFII(fiis[i].Ticker)
-		if err != nil {
-			log.Printf("Erro ao obter dividendo para %s: %v. Usando valor 0", fiis[i].Ticker, err)
-			fiis[i].UltimoDividendo = 0
-			fiis[i].RendimentoMensal = 0
-		} else {
-			fiis[i].UltimoDividendo = dividendo
-			fiis[i].RendimentoMensal = dividendo * float64(fiis[i].Quantidade)
-			log.Printf("FII %s: %d cotas x R$ %.2f = R$ %.2f de rendimento mensal",
-				fiis[i].Ticker, fiis[i].Quantidade, dividendo, fiis[i].RendimentoMensal)
+// ObterHistoricoProventos busca o histórico completo de proventos de um FII ou ação (com cache).
+// Os proventos são retornados do mais recente para o mais antigo, com JCP sinalizado separadamente.
+func (s *DividendoService) ObterHistoricoProventos(ticker string, tipoAtivo string) ([]models.ProventoHistorico, error) {
+	cacheKey := fmt.Sprintf("proventos_%s_%s", tipoAtivo, ticker)
+	if s.Cache != nil {
+		if cached, found := s.Cache.Get(cacheKey); found {
+			log.Printf("Cache HIT para proventos de %s", ticker)
+			return cached.([]models.ProventoHistorico), nil
+		}
+	}
+
+	dividendos, err := buscarDividendosInvestidor10(s.HTTPClient, ticker, tipoAtivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar proventos de %s: %w", ticker, err)
+	}
+
+	var proventos []models.ProventoHistorico
+	for _, div := range dividendos {
+		if div.ValorDecimal <= 0 {
+			log.Printf("Valor de provento inválido para %s: %s", ticker, div.Valor)
+			continue
 		}
 
-		// Pequena pausa para não sobrecarregar a API
-		time.Sleep(100 * time.Millisecond)
+		tipo := normalizarTipoProvento(div.Tipo)
+		proventos = append(proventos, models.ProventoHistorico{
+			Ticker:        ticker,
+			Tipo:          tipo,
+			DataCom:       div.DataParsed,
+			DataPagamento: div.DataPagamentoParsed,
+			Valor:         div.ValorDecimal,
+			JCP:           tipo == models.TipoProventoJCP,
+		})
 	}
 
-	return fiis
+	sort.Slice(proventos, func(i, j int) bool {
+		return proventos[i].DataCom.After(proventos[j].DataCom)
+	})
+
+	if s.Cache != nil {
+		s.Cache.Set(cacheKey, proventos, s.DuracaoCache)
+	}
+	log.Printf("Histórico de proventos de %s: %d pagamentos", ticker, len(proventos))
+
+	return proventos, nil
 }
 
-// FIIComRendimento representa um FII com informações de rendimento
-type FIIComRendimento struct {
-	Ticker           string
-	Nome             string
-	Segmento         string
-	Tipo             string
-	Preco            float64
-	Quantidade       int
-	ValorTotal       float64
-	Peso             float64
-	UltimoDividendo  float64
-	RendimentoMensal float64
+// normalizarTipoProvento converte o tipo exibido no Investidor10 para um dos tipos padronizados
+func normalizarTipoProvento(tipo string) string {
+	switch strings.ToUpper(strings.TrimSpace(tipo)) {
+	case "JCP", "JSCP":
+		return models.TipoProventoJCP
+	case "RENDIMENTO", "RENDIMENTOS":
+		return models.TipoProventoRendimento
+	default:
+		return models.TipoProventoDividendo
+	}
 }
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// EmissorService identifica a empresa por trás de cada ticker, para que ações ON, PN e units
+// da mesma companhia (e FIIs mantidos por meio de FoFs) sejam avaliados em conjunto
+type EmissorService struct {
+	Config         *config.Config
+	ComposicaoFoFs map[string]map[string]float64 // FoF -> FII investido -> % do patrimônio do FoF
+	// Erro ao carregar data/composicao_fofs.txt, exibido no relatório de exposição
+	ErroComposicaoFoFs error
+}
+
+// NewEmissorService cria um novo serviço de emissores. O erro de carga da composição dos FoFs, se
+// houver, é informado no relatório de exposição em vez de desativar a exposição via FoFs sem aviso.
+func NewEmissorService(cfg *config.Config, composicaoFoFs map[string]map[string]float64, erroComposicao error) *EmissorService {
+	return &EmissorService{Config: cfg, ComposicaoFoFs: composicaoFoFs, ErroComposicaoFoFs: erroComposicao}
+}
+
+// Emissor retorna a empresa emissora do ticker: a configurada para o ticker, a configurada para a
+// raiz de 4 letras ou, na falta de ambas, a própria raiz (TAEE3, TAEE4 e TAEE11 compartilham TAEE)
+func (s *EmissorService) Emissor(ticker string) string {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if emissor, existe := s.Config.EmissorPorTicker[ticker]; existe {
+		return emissor
+	}
+
+	raiz := ticker
+	if len(raiz) > 4 {
+		raiz = raiz[:4]
+	}
+	if emissor, existe := s.Config.EmissorPorTicker[raiz]; existe {
+		return emissor
+	}
+	return raiz
+}
+
+// LimiteEmissor retorna o valor máximo (R$) por emissor na classe, dado o valor da classe após o aporte.
+// Zero indica que a classe não tem limite.
+func (s *EmissorService) LimiteEmissor(classe models.Classe, valorTotalFuturo float64) float64 {
+	if !classe.LimitarPorEmissor || s.Config.LimiteConcentracaoEmissor <= 0 {
+		return 0
+	}
+	return s.Config.LimiteConcentracaoEmissor / 100 * valorTotalFuturo
+}
+
+// AnalisarExposicaoEmissores agrega a carteira final de FIIs, ações e BDRs por emissor. O valor dos
+// FoFs com composição cadastrada em data/composicao_fofs.txt é repassado aos FIIs investidos; o restante fica com o próprio FoF.
+func (s *EmissorService) AnalisarExposicaoEmissores(
+	carteirasFinais map[string][]models.AtivoCarteiraFinal,
+	valorTotalFinal float64,
+) *models.ExposicaoEmissores {
+	resultado := &models.ExposicaoEmissores{LimiteAcoes: s.Config.LimiteConcentracaoEmissor, AvisoFoFs: s.avisoFoFs()}
+
+	indices := make(map[string]int)
+	exposicaoDe := func(emissor string) *models.ExposicaoEmissor {
+		i, existe := indices[emissor]
+		if !existe {
+			i = len(resultado.Emissores)
+			indices[emissor] = i
+			resultado.Emissores = append(resultado.Emissores, models.ExposicaoEmissor{Emissor: emissor})
+		}
+		return &resultado.Emissores[i]
+	}
+
+	valorTotalAcoes := 0.0
+	for _, classe := range []string{ClasseFII.Nome, ClasseAcao.Nome, ClasseBDR.Nome} {
+		for _, ativo := range carteirasFinais[classe] {
+			valorDireto := ativo.ValorTotal
+
+			// Repassa a parcela investida em outros FIIs aos respectivos emissores
+			if classe == ClasseFII.Nome {
+				for fii, percentual := range s.ComposicaoFoFs[strings.ToUpper(ativo.Ticker)] {
+					valorIndireto := ativo.ValorTotal * percentual / 100
+					exposicaoDe(s.Emissor(fii)).ValorIndireto += valorIndireto
+					valorDireto -= valorIndireto
+				}
+			}
+
+			exposicao := exposicaoDe(s.Emissor(ativo.Ticker))
+			exposicao.ValorDireto += valorDireto
+			if !contemTexto(exposicao.Tickers, ativo.Ticker) {
+				exposicao.Tickers = append(exposicao.Tickers, ativo.Ticker)
+			}
+			if classe == ClasseAcao.Nome {
+				exposicao.ValorAcoes += ativo.ValorTotal
+				valorTotalAcoes += ativo.ValorTotal
+			}
+		}
+	}
+
+	for i := range resultado.Emissores {
+		exposicao := &resultado.Emissores[i]
+		exposicao.ValorTotal = exposicao.ValorDireto + exposicao.ValorIndireto
+		if valorTotalFinal > 0 {
+			exposicao.Percentual = exposicao.ValorTotal / valorTotalFinal * 100
+		}
+		if valorTotalAcoes > 0 {
+			exposicao.PercentualAcoes = exposicao.ValorAcoes / valorTotalAcoes * 100
+		}
+		exposicao.AcimaDoLimite = resultado.LimiteAcoes > 0 && exposicao.PercentualAcoes > resultado.LimiteAcoes+0.01
+		sort.Strings(exposicao.Tickers)
+	}
+
+	sort.Slice(resultado.Emissores, func(i, j int) bool {
+		return resultado.Emissores[i].ValorTotal > resultado.Emissores[j].ValorTotal
+	})
+	return resultado
+}
+
+// avisoFoFs explica por que a exposição via FoFs está desativada; vazio quando há composição cadastrada
+func (s *EmissorService) avisoFoFs() string {
+	switch {
+	case s.ErroComposicaoFoFs != nil && !errors.Is(s.ErroComposicaoFoFs, fs.ErrNotExist):
+		return fmt.Sprintf("Composição dos FoFs inválida (%v): a exposição via FoFs está desativada até a correção do arquivo.", s.ErroComposicaoFoFs)
+	case len(s.ComposicaoFoFs) == 0:
+		return "Nenhum FoF cadastrado em data/composicao_fofs.txt: a exposição via FoFs está desativada e cada FoF aparece como emissor próprio."
+	}
+	return ""
+}
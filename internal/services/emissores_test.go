@@ -0,0 +1,80 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExposicaoEmissoresRepassaFoFAosFIIsInvestidos(t *testing.T) {
+	cfg := config.Load()
+	cfg.DataDir = t.TempDir()
+	arquivo := "# FoF de exemplo\nKFOF11\tHGLG11\t25%\nKFOF11\tXPML11\t10,5%\n"
+	if err := os.WriteFile(filepath.Join(cfg.DataDir, "composicao_fofs.txt"), []byte(arquivo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	composicao, err := NewDataService(cfg, nil).CarregarComposicaoFoFs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	servico := NewEmissorService(cfg, composicao, nil)
+
+	exposicao := servico.AnalisarExposicaoEmissores(map[string][]models.AtivoCarteiraFinal{
+		ClasseFII.Nome: {
+			{Ticker: "KFOF11", ValorTotal: 1000},
+			{Ticker: "HGLG11", ValorTotal: 500},
+		},
+	}, 1500)
+
+	esperados := map[string]struct{ direto, indireto float64 }{
+		"KFOF": {645, 0},
+		"HGLG": {500, 250},
+		"XPML": {0, 105},
+	}
+	if len(exposicao.Emissores) != len(esperados) {
+		t.Fatalf("esperava %d emissores, obteve %+v", len(esperados), exposicao.Emissores)
+	}
+	for _, emissor := range exposicao.Emissores {
+		esperado, existe := esperados[emissor.Emissor]
+		if !existe {
+			t.Fatalf("emissor inesperado %s", emissor.Emissor)
+		}
+		if math.Abs(emissor.ValorDireto-esperado.direto) > 1e-9 || math.Abs(emissor.ValorIndireto-esperado.indireto) > 1e-9 {
+			t.Errorf("%s: esperava direto %v e indireto %v, obteve %v e %v",
+				emissor.Emissor, esperado.direto, esperado.indireto, emissor.ValorDireto, emissor.ValorIndireto)
+		}
+	}
+}
+
+func TestCarregarComposicaoFoFsRejeitaPercentualInvalido(t *testing.T) {
+	cfg := config.Load()
+	cfg.DataDir = t.TempDir()
+	arquivo := "KFOF11\tHGLG11\t25%\nKFOF11\tXPML11\t1O%\n"
+	if err := os.WriteFile(filepath.Join(cfg.DataDir, "composicao_fofs.txt"), []byte(arquivo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	composicao, err := NewDataService(cfg, nil).CarregarComposicaoFoFs()
+	if err == nil || !strings.Contains(err.Error(), "linha 2") {
+		t.Fatalf("esperava erro na linha 2, obteve %v", err)
+	}
+
+	exposicao := NewEmissorService(cfg, composicao, err).AnalisarExposicaoEmissores(map[string][]models.AtivoCarteiraFinal{
+		ClasseFII.Nome: {{Ticker: "KFOF11", ValorTotal: 1000}},
+	}, 1000)
+	if !strings.Contains(exposicao.AvisoFoFs, "inválida") {
+		t.Fatalf("esperava aviso de composição inválida no relatório, obteve %q", exposicao.AvisoFoFs)
+	}
+}
+
+func TestExposicaoEmissoresAvisaSemComposicaoDeFoFs(t *testing.T) {
+	exposicao := NewEmissorService(config.Load(), nil, nil).AnalisarExposicaoEmissores(nil, 0)
+	if !strings.Contains(exposicao.AvisoFoFs, "desativada") {
+		t.Fatalf("esperava aviso de exposição via FoFs desativada, obteve %q", exposicao.AvisoFoFs)
+	}
+}
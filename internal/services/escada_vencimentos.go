@@ -0,0 +1,67 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// MontarEscadaVencimentos agrupa por ano de vencimento os títulos sem liquidez diária e separa
+// o valor disponível diariamente do valor travado até o vencimento
+func (s *RendaFixaService) MontarEscadaVencimentos(posicoes []models.PosicaoRendaFixa) *models.EscadaVencimentos {
+	escada := &models.EscadaVencimentos{
+		PercentualMinimo: s.Config.PercentualMinimoLiquidezDiaria,
+		ReservaMinima:    s.Config.ReservaMinimaLiquidezDiaria,
+	}
+
+	indices := make(map[int]int)
+	valorTotal := 0.0
+	for _, posicao := range posicoes {
+		valorTotal += posicao.ValorAtual
+
+		// Títulos vencidos já estão disponíveis para resgate
+		if posicao.LiquidezDiaria || posicao.Vencido {
+			escada.ValorLiquidezDiaria += posicao.ValorAtual
+			continue
+		}
+
+		vencimento, err := time.Parse("02/01/2006", posicao.DataVencimento)
+		if err != nil {
+			escada.ValorSemVencimento += posicao.ValorAtual
+			continue
+		}
+		escada.ValorTravado += posicao.ValorAtual
+
+		i, existe := indices[vencimento.Year()]
+		if !existe {
+			i = len(escada.Degraus)
+			indices[vencimento.Year()] = i
+			escada.Degraus = append(escada.Degraus, models.DegrauVencimento{Ano: vencimento.Year()})
+		}
+		degrau := &escada.Degraus[i]
+		degrau.Quantidade++
+		degrau.ValorAtual += posicao.ValorAtual
+		degrau.ValorVencimento += posicao.ValorLiquidoVencimento
+	}
+
+	sort.Slice(escada.Degraus, func(i, j int) bool {
+		return escada.Degraus[i].Ano < escada.Degraus[j].Ano
+	})
+
+	if valorTotal > 0 {
+		escada.PercentualLiquidezDiaria = escada.ValorLiquidezDiaria / valorTotal * 100
+		for i := range escada.Degraus {
+			escada.Degraus[i].Percentual = escada.Degraus[i].ValorAtual / valorTotal * 100
+		}
+	}
+	escada.DeficitLiquidez = math.Max(0, s.metaLiquidez(valorTotal)-escada.ValorLiquidezDiaria)
+
+	return escada
+}
+
+// metaLiquidez retorna o valor mínimo em liquidez diária para uma carteira de renda fixa com o
+// valor total informado: o maior entre o percentual mínimo e a reserva mínima configurados
+func (s *RendaFixaService) metaLiquidez(valorTotal float64) float64 {
+	return math.Max(s.Config.PercentualMinimoLiquidezDiaria/100*valorTotal, s.Config.ReservaMinimaLiquidezDiaria)
+}
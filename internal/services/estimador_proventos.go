@@ -0,0 +1,119 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/models"
+	"sort"
+	"time"
+)
+
+// Estimadores de proventos disponíveis
+const (
+	EstimadorUltimo    = "ultimo"    // Último pagamento, repetido na frequência observada
+	EstimadorMedia12M  = "media12m"  // Soma dos últimos 12 meses dividida por 12
+	EstimadorMediana   = "mediana"   // Mediana dos pagamentos dos últimos 12 meses
+	EstimadorPonderado = "ponderado" // Média ponderada dando mais peso aos pagamentos recentes
+)
+
+// DescricoesEstimadores contém o nome de exibição de cada estimador
+var DescricoesEstimadores = map[string]string{
+	EstimadorUltimo:    "Último pagamento",
+	EstimadorMedia12M:  "Média dos últimos 12 meses",
+	EstimadorMediana:   "Mediana dos últimos 12 meses",
+	EstimadorPonderado: "Média ponderada (pagamentos recentes pesam mais)",
+}
+
+// DescricaoEstimador retorna o nome de exibição do estimador, ou o próprio identificador se for desconhecido
+func DescricaoEstimador(estimador string) string {
+	if descricao, existe := DescricoesEstimadores[estimador]; existe {
+		return descricao
+	}
+	return estimador
+}
+
+// EstimarProventoMensal estima o provento mensal equivalente por cota/ação a partir do histórico.
+// O valor por pagamento é calculado pelo estimador e ajustado pela quantidade de pagamentos
+// observados nos últimos 12 meses, o que permite tratar FIIs mensais e ações trimestrais ou
+// semestrais da mesma forma. Retorna false se não houver pagamentos no período.
+func EstimarProventoMensal(proventos []models.ProventoHistorico, estimador string, referencia time.Time) (float64, int, bool) {
+	ultimoAno := proventosUltimoAno(proventos, referencia)
+	if len(ultimoAno) == 0 {
+		return 0, 0, false
+	}
+
+	// Garantir a ordem do mais recente para o mais antigo
+	sort.Slice(ultimoAno, func(i, j int) bool {
+		return ultimoAno[i].DataCom.After(ultimoAno[j].DataCom)
+	})
+
+	pagamentosPorAno := len(ultimoAno)
+	valorPorPagamento := 0.0
+
+	switch estimador {
+	case EstimadorUltimo:
+		valorPorPagamento = ultimoAno[0].Valor
+
+	case EstimadorMediana:
+		valores := make([]float64, len(ultimoAno))
+		for i, provento := range ultimoAno {
+			valores[i] = provento.Valor
+		}
+		sort.Float64s(valores)
+		valorPorPagamento = percentil(valores, 50)
+
+	case EstimadorPonderado:
+		// Pesos lineares: o pagamento mais recente tem peso n, o mais antigo peso 1
+		somaPonderada := 0.0
+		somaPesos := 0.0
+		for i, provento := range ultimoAno {
+			peso := float64(len(ultimoAno) - i)
+			somaPonderada += provento.Valor * peso
+			somaPesos += peso
+		}
+		valorPorPagamento = somaPonderada / somaPesos
+
+	default: // EstimadorMedia12M
+		soma := 0.0
+		for _, provento := range ultimoAno {
+			soma += provento.Valor
+		}
+		valorPorPagamento = soma / float64(pagamentosPorAno)
+	}
+
+	return valorPorPagamento * float64(pagamentosPorAno) / 12, pagamentosPorAno, true
+}
+
+// proventosUltimoAno retorna os proventos com data com nos 12 meses anteriores à data de referência
+func proventosUltimoAno(proventos []models.ProventoHistorico, referencia time.Time) []models.ProventoHistorico {
+	umAnoAtras := referencia.AddDate(-1, 0, 0)
+
+	var resultado []models.ProventoHistorico
+	for _, provento := range proventos {
+		if provento.DataCom.After(umAnoAtras) && !provento.DataCom.After(referencia) {
+			resultado = append(resultado, provento)
+		}
+	}
+	return resultado
+}
+
+// proventosAte mantém apenas os proventos com data com até a data informada, descartando os que
+// ainda não tinham sido anunciados na data de referência
+func proventosAte(proventos []models.ProventoHistorico, data time.Time) []models.ProventoHistorico {
+	var resultado []models.ProventoHistorico
+	for _, provento := range proventos {
+		if !provento.DataCom.After(data) {
+			resultado = append(resultado, provento)
+		}
+	}
+	return resultado
+}
+
+// filtrarProventos separa os proventos em JCP ou demais tipos
+func filtrarProventos(proventos []models.ProventoHistorico, jcp bool) []models.ProventoHistorico {
+	var resultado []models.ProventoHistorico
+	for _, provento := range proventos {
+		if provento.JCP == jcp {
+			resultado = append(resultado, provento)
+		}
+	}
+	return resultado
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Moedas da análise de exposição cambial, na ordem em que são exibidas
+var moedasExposicao = []string{"BRL", "USD", "OURO"}
+
+// MoedaAtivo retorna a exposição cambial do ativo: a configurada para o ticker ou, na falta dela,
+// a da classe. Ativos sem configuração são considerados em reais.
+func (s *DistribuidoraService) MoedaAtivo(classe, ticker string) string {
+	if moeda, existe := s.Config.MoedaPorTicker[strings.ToUpper(strings.TrimSpace(ticker))]; existe {
+		return moeda
+	}
+	if moeda, existe := s.Config.MoedaPorClasse[classe]; existe {
+		return moeda
+	}
+	return "BRL"
+}
+
+// FracoesEstrangeiras estima, para cada classe, a fração exposta a dólar e ouro. Classes com lista de
+// recomendados usam os pesos ideais dos tickers; as demais seguem a moeda configurada para a classe.
+func (s *DistribuidoraService) FracoesEstrangeiras(recomendados map[string][]models.AtivoRecomendado) map[string]float64 {
+	fracoes := make(map[string]float64)
+	for _, classe := range ClassesInvestimento {
+		pesoTotal, pesoEstrangeiro := 0.0, 0.0
+		for _, rec := range recomendados[classe] {
+			pesoTotal += rec.PesoIdeal
+			if s.MoedaAtivo(classe, rec.Ticker) != "BRL" {
+				pesoEstrangeiro += rec.PesoIdeal
+			}
+		}
+
+		if pesoTotal > 0 {
+			fracoes[classe] = pesoEstrangeiro / pesoTotal
+		} else if s.MoedaAtivo(classe, "") != "BRL" {
+			fracoes[classe] = 1
+		}
+	}
+	return fracoes
+}
+
+// AplicarMetaExposicaoEstrangeira escala as classes com exposição estrangeira para que a distribuição
+// ideal atinja a meta, redistribuindo a diferença proporcionalmente entre as classes em reais.
+// Sem meta, a distribuição é mantida. Retorna também se a meta cabe nas classes selecionadas.
+func (s *DistribuidoraService) AplicarMetaExposicaoEstrangeira(distribuicaoIdeal, fracoes map[string]float64, meta float64) (map[string]float64, bool) {
+	if meta <= 0 {
+		return distribuicaoIdeal, true
+	}
+
+	// Participação das classes com exposição estrangeira e parcela estrangeira prevista
+	somaEstrangeiras := 0.0
+	for classe, percentual := range distribuicaoIdeal {
+		if fracoes[classe] > 0 {
+			somaEstrangeiras += percentual
+		}
+	}
+	prevista := exposicaoPrevista(distribuicaoIdeal, fracoes)
+	if prevista == 0 {
+		return distribuicaoIdeal, false
+	}
+	// Sem classes em reais para ceder espaço, a distribuição não pode mudar
+	if somaEstrangeiras >= 100 {
+		return distribuicaoIdeal, math.Abs(prevista-meta) < 0.01
+	}
+
+	// Fator que leva a exposição prevista à meta, limitado para que as classes estrangeiras não passem de 100%
+	atingivel := true
+	fator := meta / prevista
+	if somaEstrangeiras*fator > 100 {
+		fator = 100 / somaEstrangeiras
+		atingivel = false
+	}
+	fatorReais := (100 - somaEstrangeiras*fator) / (100 - somaEstrangeiras)
+
+	ajustada := make(map[string]float64, len(distribuicaoIdeal))
+	for classe, percentual := range distribuicaoIdeal {
+		if fracoes[classe] > 0 {
+			ajustada[classe] = percentual * fator
+		} else {
+			ajustada[classe] = percentual * fatorReais
+		}
+	}
+	return ajustada, atingivel
+}
+
+// AnalisarExposicaoCambial separa a carteira final de cada classe entre reais, dólar e ouro.
+// A renda fixa é considerada integralmente em reais.
+func (s *DistribuidoraService) AnalisarExposicaoCambial(
+	carteirasFinais map[string][]models.AtivoCarteiraFinal,
+	valorFinalRendaFixa, valorTotalFinal float64,
+) *models.ExposicaoCambial {
+	valores := map[string]float64{"BRL": valorFinalRendaFixa}
+	for classe, carteira := range carteirasFinais {
+		for _, ativo := range carteira {
+			valores[s.MoedaAtivo(classe, ativo.Ticker)] += ativo.ValorTotal
+		}
+	}
+
+	// Moedas configuradas fora da lista padrão aparecem ao final
+	moedas := append([]string{}, moedasExposicao...)
+	for moeda := range valores {
+		if !contemTexto(moedas, moeda) {
+			moedas = append(moedas, moeda)
+		}
+	}
+	sort.Strings(moedas[len(moedasExposicao):])
+
+	exposicao := &models.ExposicaoCambial{Meta: s.Config.MetaExposicaoEstrangeira, MetaAtingivel: true}
+	for _, moeda := range moedas {
+		item := models.ExposicaoMoeda{Moeda: moeda, Valor: valores[moeda]}
+		if valorTotalFinal > 0 {
+			item.Percentual = item.Valor / valorTotalFinal * 100
+		}
+		exposicao.Moedas = append(exposicao.Moedas, item)
+		if moeda != "BRL" {
+			exposicao.ValorEstrangeiro += item.Valor
+		}
+	}
+	if valorTotalFinal > 0 {
+		exposicao.PercentualEstrangeiro = exposicao.ValorEstrangeiro / valorTotalFinal * 100
+	}
+	return exposicao
+}
+
+// exposicaoPrevista calcula a exposição estrangeira implícita na distribuição ideal
+func exposicaoPrevista(distribuicaoIdeal, fracoes map[string]float64) float64 {
+	total := 0.0
+	for classe, percentual := range distribuicaoIdeal {
+		total += percentual * fracoes[classe]
+	}
+	return total
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Erros retornados pelo extrator da tabela de dividendos
+var (
+	ErrTabelaDividendosNaoEncontrada = errors.New("tabela de dividendos não encontrada na página")
+	ErrLinhaDividendoInvalida        = errors.New("linha da tabela de dividendos inválida")
+)
+
+// colunasDividendos guarda o índice de cada coluna da tabela de dividendos
+type colunasDividendos struct {
+	tipo      int
+	dataCom   int
+	pagamento int
+	valor     int
+}
+
+// extrairDividendosHTML localiza a tabela de dividendos pelo texto do cabeçalho (Tipo, Data Com,
+// Pagamento e Valor) e converte cada linha em um Dividendo com data e valor já interpretados.
+// Linhas repetidas (mesma data com e mesmo pagamento) aparecem uma única vez.
+// Retorna ErrTabelaDividendosNaoEncontrada se nenhuma tabela tiver esse cabeçalho e
+// ErrLinhaDividendoInvalida se alguma linha não puder ser interpretada. Uma linha inválida
+// invalida o ticker inteiro de propósito: ela indica que o layout da página mudou, e ignorá-la
+// poderia descartar justamente a data com mais recente, que define o status de compra.
+func extrairDividendosHTML(r io.Reader) ([]Dividendo, error) {
+	documento, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao interpretar HTML: %w", err)
+	}
+
+	for _, tabela := range buscarElementos(documento, "table") {
+		colunas, ok := identificarColunasDividendos(tabela)
+		if !ok {
+			continue
+		}
+		return lerLinhasDividendos(tabela, colunas)
+	}
+
+	return nil, ErrTabelaDividendosNaoEncontrada
+}
+
+// identificarColunasDividendos verifica se a tabela tem o cabeçalho esperado e mapeia as colunas
+func identificarColunasDividendos(tabela *html.Node) (colunasDividendos, bool) {
+	colunas := colunasDividendos{tipo: -1, dataCom: -1, pagamento: -1, valor: -1}
+
+	for _, linha := range buscarElementos(tabela, "tr") {
+		cabecalhos := filhosElemento(linha, "th")
+		if len(cabecalhos) == 0 {
+			continue
+		}
+
+		for i, th := range cabecalhos {
+			switch texto := normalizarCabecalho(textoNo(th)); {
+			case texto == "tipo":
+				colunas.tipo = i
+			case strings.HasPrefix(texto, "data com"):
+				colunas.dataCom = i
+			case strings.HasPrefix(texto, "pagamento") || strings.HasPrefix(texto, "data de pagamento") || strings.HasPrefix(texto, "data pagamento"):
+				colunas.pagamento = i
+			case strings.HasPrefix(texto, "valor"):
+				colunas.valor = i
+			}
+		}
+		break
+	}
+
+	ok := colunas.tipo >= 0 && colunas.dataCom >= 0 && colunas.pagamento >= 0 && colunas.valor >= 0
+	return colunas, ok
+}
+
+// lerLinhasDividendos converte as linhas de dados da tabela em dividendos
+func lerLinhasDividendos(tabela *html.Node, colunas colunasDividendos) ([]Dividendo, error) {
+	maiorIndice := max(colunas.tipo, colunas.dataCom, colunas.pagamento, colunas.valor)
+
+	var dividendos []Dividendo
+	vistos := make(map[string]bool)
+	for numero, linha := range buscarElementos(tabela, "tr") {
+		celulas := filhosElemento(linha, "td")
+		if len(celulas) == 0 {
+			continue // Linha de cabeçalho
+		}
+		if len(celulas) <= maiorIndice {
+			continue // Linhas de aviso (ex: "nenhum provento") ocupam uma única célula
+		}
+
+		tipo := textoNo(celulas[colunas.tipo])
+		dataComTexto := textoNo(celulas[colunas.dataCom])
+		pagamentoTexto := textoNo(celulas[colunas.pagamento])
+		valorTexto := textoNo(celulas[colunas.valor])
+
+		chave := dataComTexto + pagamentoTexto
+		if vistos[chave] {
+			continue
+		}
+		vistos[chave] = true
+
+		dataCom, err := time.Parse("02/01/2006", dataComTexto)
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d, data com %q", ErrLinhaDividendoInvalida, numero+1, dataComTexto)
+		}
+
+		// Pagamento ainda não definido aparece como "-"
+		var pagamento time.Time
+		if pagamentoTexto != "" && pagamentoTexto != "-" {
+			pagamento, err = time.Parse("02/01/2006", pagamentoTexto)
+			if err != nil {
+				return nil, fmt.Errorf("%w: linha %d, pagamento %q", ErrLinhaDividendoInvalida, numero+1, pagamentoTexto)
+			}
+		}
+
+		valor, err := converterValorDecimal(valorTexto)
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d, valor %q", ErrLinhaDividendoInvalida, numero+1, valorTexto)
+		}
+
+		dividendos = append(dividendos, Dividendo{
+			Tipo:                tipo,
+			DataCom:             dataComTexto,
+			DataPagamento:       pagamentoTexto,
+			Valor:               valorTexto,
+			DataParsed:          dataCom,
+			DataPagamentoParsed: pagamento,
+			ValorDecimal:        valor,
+		})
+	}
+
+	return dividendos, nil
+}
+
+// converterValorDecimal converte valores no formato brasileiro (ex: "R$ 1.234,56") para float64
+func converterValorDecimal(texto string) (float64, error) {
+	texto = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(texto), "R$"))
+	texto = strings.ReplaceAll(texto, ".", "")
+	texto = strings.ReplaceAll(texto, ",", ".")
+	return strconv.ParseFloat(texto, 64)
+}
+
+// normalizarCabecalho deixa o texto do cabeçalho em minúsculas, sem acentos e espaços extras
+func normalizarCabecalho(texto string) string {
+	substituto := strings.NewReplacer("á", "a", "ã", "a", "â", "a", "é", "e", "ê", "e", "í", "i", "ó", "o", "õ", "o", "ô", "o", "ú", "u", "ç", "c")
+	return substituto.Replace(strings.Join(strings.Fields(strings.ToLower(texto)), " "))
+}
+
+// buscarElementos retorna, em ordem de documento, todos os elementos descendentes com a tag informada
+func buscarElementos(no *html.Node, tag string) []*html.Node {
+	var elementos []*html.Node
+	var visitar func(*html.Node)
+	visitar = func(atual *html.Node) {
+		for filho := atual.FirstChild; filho != nil; filho = filho.NextSibling {
+			if filho.Type == html.ElementNode && filho.Data == tag {
+				elementos = append(elementos, filho)
+			}
+			visitar(filho)
+		}
+	}
+	visitar(no)
+	return elementos
+}
+
+// filhosElemento retorna os filhos diretos do nó com a tag informada
+func filhosElemento(no *html.Node, tag string) []*html.Node {
+	var filhos []*html.Node
+	for filho := no.FirstChild; filho != nil; filho = filho.NextSibling {
+		if filho.Type == html.ElementNode && filho.Data == tag {
+			filhos = append(filhos, filho)
+		}
+	}
+	return filhos
+}
+
+// textoNo retorna o texto do nó e de seus descendentes, com espaços normalizados
+func textoNo(no *html.Node) string {
+	var partes []string
+	var visitar func(*html.Node)
+	visitar = func(atual *html.Node) {
+		if atual.Type == html.TextNode {
+			partes = append(partes, atual.Data)
+		}
+		for filho := atual.FirstChild; filho != nil; filho = filho.NextSibling {
+			visitar(filho)
+		}
+	}
+	visitar(no)
+	return strings.Join(strings.Fields(strings.Join(partes, " ")), " ")
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func extrairFixture(t *testing.T, nome string) ([]Dividendo, error) {
+	t.Helper()
+	arquivo, err := os.Open(filepath.Join("testdata", "extrator_dividendos", nome))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer arquivo.Close()
+	return extrairDividendosHTML(arquivo)
+}
+
+func dataBR(texto string) time.Time {
+	valor, _ := time.Parse("02/01/2006", texto)
+	return valor
+}
+
+func conferirDividendos(t *testing.T, obtidos, esperados []Dividendo) {
+	t.Helper()
+	if len(obtidos) != len(esperados) {
+		t.Fatalf("esperava %d dividendos, obteve %d: %+v", len(esperados), len(obtidos), obtidos)
+	}
+	for i, esperado := range esperados {
+		if obtidos[i] != esperado {
+			t.Errorf("linha %d: esperava %+v, obteve %+v", i+1, esperado, obtidos[i])
+		}
+	}
+}
+
+func TestExtrairDividendosFII(t *testing.T) {
+	dividendos, err := extrairFixture(t, "fii_hglg11.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conferirDividendos(t, dividendos, []Dividendo{
+		{Tipo: "Dividendos", DataCom: "30/09/2026", DataPagamento: "14/10/2026", Valor: "1,10000000",
+			DataParsed: dataBR("30/09/2026"), DataPagamentoParsed: dataBR("14/10/2026"), ValorDecimal: 1.1},
+		{Tipo: "Dividendos", DataCom: "29/08/2026", DataPagamento: "14/09/2026", Valor: "1,10000000",
+			DataParsed: dataBR("29/08/2026"), DataPagamentoParsed: dataBR("14/09/2026"), ValorDecimal: 1.1},
+		{Tipo: "Rendimento", DataCom: "31/07/2026", DataPagamento: "14/08/2026", Valor: "1,05000000",
+			DataParsed: dataBR("31/07/2026"), DataPagamentoParsed: dataBR("14/08/2026"), ValorDecimal: 1.05},
+	})
+}
+
+func TestExtrairDividendosAcao(t *testing.T) {
+	dividendos, err := extrairFixture(t, "acao_itsa4.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conferirDividendos(t, dividendos, []Dividendo{
+		{Tipo: "JSCP", DataCom: "18/11/2026", DataPagamento: "-", Valor: "0,02000000",
+			DataParsed: dataBR("18/11/2026"), ValorDecimal: 0.02},
+		{Tipo: "JSCP", DataCom: "01/09/2026", DataPagamento: "01/10/2026", Valor: "0,02000000",
+			DataParsed: dataBR("01/09/2026"), DataPagamentoParsed: dataBR("01/10/2026"), ValorDecimal: 0.02},
+		{Tipo: "Dividendos", DataCom: "20/08/2026", DataPagamento: "30/09/2026", Valor: "R$ 1.234,56",
+			DataParsed: dataBR("20/08/2026"), DataPagamentoParsed: dataBR("30/09/2026"), ValorDecimal: 1234.56},
+	})
+}
+
+func TestExtrairDividendosSemTabela(t *testing.T) {
+	_, err := extrairFixture(t, "sem_tabela.html")
+	if !errors.Is(err, ErrTabelaDividendosNaoEncontrada) {
+		t.Fatalf("esperava ErrTabelaDividendosNaoEncontrada, obteve %v", err)
+	}
+}
+
+func TestExtrairDividendosLinhaInvalida(t *testing.T) {
+	_, err := extrairFixture(t, "linha_invalida.html")
+	if !errors.Is(err, ErrLinhaDividendoInvalida) {
+		t.Fatalf("esperava ErrLinhaDividendoInvalida, obteve %v", err)
+	}
+}
+
+func TestExtrairDividendosIgnoraLinhasRepetidas(t *testing.T) {
+	pagina := `<table>
+		<tr><th>Tipo</th><th>Data Com</th><th>Pagamento</th><th>Valor</th></tr>
+		<tr><td>Dividendos</td><td>30/09/2026</td><td>14/10/2026</td><td>1,10</td></tr>
+		<tr><td>Dividendos</td><td>30/09/2026</td><td>14/10/2026</td><td>1,10</td></tr>
+		<tr><td>Dividendos</td><td>29/08/2026</td><td>14/09/2026</td><td>1,10</td></tr>
+	</table>`
+
+	dividendos, err := extrairDividendosHTML(strings.NewReader(pagina))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dividendos) != 2 {
+		t.Fatalf("esperava 2 dividendos sem repetição, obteve %d", len(dividendos))
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// toleranciaFGC evita que diferenças de centavos marquem uma exposição como acima do limite
+const toleranciaFGC = 0.01
+
+// conglomerado retorna o conglomerado financeiro do emissor. Emissores que não constam no
+// mapeamento configurado formam seu próprio conglomerado.
+func (s *RendaFixaService) conglomerado(emissor string) string {
+	emissor = strings.TrimSpace(emissor)
+	for nome, conglomerado := range s.Config.ConglomeradoPorEmissor {
+		if strings.EqualFold(nome, emissor) {
+			return conglomerado
+		}
+	}
+	return emissor
+}
+
+// cobertoFGC indica se o tipo do título conta com a garantia do FGC
+func (s *RendaFixaService) cobertoFGC(tipo string) bool {
+	tipoNormalizado := normalizarCabecalho(tipo)
+	for _, coberto := range s.Config.TiposCobertosFGC {
+		if strings.HasPrefix(tipoNormalizado, normalizarCabecalho(coberto)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalisarFGC agrega as posições cobertas pelo FGC por conglomerado financeiro e sinaliza as
+// exposições acima da garantia, hoje ou no vencimento, e o teto global de garantias
+func (s *RendaFixaService) AnalisarFGC(posicoes []models.PosicaoRendaFixa) *models.AnaliseFGC {
+	analise := &models.AnaliseFGC{LimiteGlobal: s.Config.LimiteFGCGlobal}
+
+	indices := make(map[string]int)
+	for _, posicao := range posicoes {
+		if !posicao.CobertoFGC {
+			analise.ValorNaoCoberto += posicao.ValorAtual
+			continue
+		}
+
+		i, existe := indices[posicao.Conglomerado]
+		if !existe {
+			i = len(analise.Exposicoes)
+			indices[posicao.Conglomerado] = i
+			analise.Exposicoes = append(analise.Exposicoes, models.ExposicaoFGC{
+				Conglomerado: posicao.Conglomerado,
+				Limite:       s.Config.LimiteFGCPorConglomerado,
+			})
+		}
+
+		exposicao := &analise.Exposicoes[i]
+		exposicao.ValorAtual += posicao.ValorAtual
+		exposicao.ValorVencimento += posicao.ValorBrutoVencimento
+		if emissor := strings.TrimSpace(posicao.Emissor); !contemTexto(exposicao.Emissores, emissor) {
+			exposicao.Emissores = append(exposicao.Emissores, emissor)
+		}
+		analise.TotalCoberto += posicao.ValorAtual
+	}
+
+	for i := range analise.Exposicoes {
+		exposicao := &analise.Exposicoes[i]
+		exposicao.AcimaDoLimite = exposicao.ValorAtual > exposicao.Limite+toleranciaFGC
+		exposicao.AcimaNoVencimento = exposicao.ValorVencimento > exposicao.Limite+toleranciaFGC
+		if exposicao.AcimaDoLimite {
+			exposicao.Excedente = exposicao.ValorAtual - exposicao.Limite
+		}
+	}
+	analise.AcimaLimiteGlobal = analise.TotalCoberto > analise.LimiteGlobal+toleranciaFGC
+
+	sort.Slice(analise.Exposicoes, func(i, j int) bool {
+		return analise.Exposicoes[i].ValorAtual > analise.Exposicoes[j].ValorAtual
+	})
+
+	return analise
+}
+
+// capacidadeFGC retorna quanto ainda pode ser aplicado em cada conglomerado já presente na carteira
+// e no teto global sem ultrapassar a garantia. Conglomerados ausentes têm o limite inteiro disponível.
+func (s *RendaFixaService) capacidadeFGC(carteira *models.CarteiraRendaFixa) (map[string]float64, float64) {
+	porConglomerado := make(map[string]float64)
+	global := s.Config.LimiteFGCGlobal
+	if carteira == nil {
+		return porConglomerado, global
+	}
+
+	for _, ativo := range carteira.Data {
+		if !s.cobertoFGC(ativo.InvestmentType) {
+			continue
+		}
+		valor, _ := converterNumero(ativo.EquityTotal)
+		conglomerado := s.conglomerado(ativo.Emitter)
+		if _, existe := porConglomerado[conglomerado]; !existe {
+			porConglomerado[conglomerado] = s.Config.LimiteFGCPorConglomerado
+		}
+		porConglomerado[conglomerado] -= valor
+		global -= valor
+	}
+
+	for conglomerado, capacidade := range porConglomerado {
+		porConglomerado[conglomerado] = math.Max(0, capacidade)
+	}
+	return porConglomerado, math.Max(0, global)
+}
+
+// limitarExposicaoFGC reduz a alocação dos títulos cobertos que levariam um conglomerado (ou o total
+// coberto) além da garantia do FGC e redistribui o excedente, pelos pesos ideais, entre os títulos
+// ainda não limitados. O que não couber em nenhum título deixa de ser alocado.
+// Retorna os títulos cuja alocação foi limitada.
+func (s *RendaFixaService) limitarExposicaoFGC(
+	alocacao map[int]float64,
+	recomendados []models.RendaFixaRecomendada,
+	candidatos []int,
+	capacidades map[string]float64,
+	capacidadeGlobal float64,
+) map[int]bool {
+	limitados := make(map[int]bool)
+
+	capacidade := func(conglomerado string) float64 {
+		if valor, existe := capacidades[conglomerado]; existe {
+			return valor
+		}
+		return s.Config.LimiteFGCPorConglomerado
+	}
+
+	// Cada rodada limita ao menos um título novo ou encerra, então o número de rodadas é limitado
+	for rodada := 0; rodada <= len(candidatos); rodada++ {
+		excedente := 0.0
+		reduzir := func(indices []int, total, limite float64) {
+			fator := limite / total
+			for _, i := range indices {
+				excedente += alocacao[i] * (1 - fator)
+				alocacao[i] *= fator
+				limitados[i] = true
+			}
+		}
+
+		porConglomerado := make(map[string][]int)
+		var cobertos []int
+		for _, i := range candidatos {
+			if s.cobertoFGC(recomendados[i].Tipo) {
+				conglomerado := s.conglomerado(recomendados[i].Emissor)
+				porConglomerado[conglomerado] = append(porConglomerado[conglomerado], i)
+				cobertos = append(cobertos, i)
+			}
+		}
+
+		for conglomerado, indices := range porConglomerado {
+			if total := somarAlocacao(alocacao, indices); total > capacidade(conglomerado)+toleranciaFGC {
+				reduzir(indices, total, capacidade(conglomerado))
+			}
+		}
+		if total := somarAlocacao(alocacao, cobertos); total > capacidadeGlobal+toleranciaFGC {
+			reduzir(cobertos, total, capacidadeGlobal)
+		}
+
+		if excedente <= toleranciaFGC {
+			break
+		}
+
+		pesoLivre := 0.0
+		for _, i := range candidatos {
+			if !limitados[i] {
+				pesoLivre += recomendados[i].PesoIdeal
+			}
+		}
+		if pesoLivre == 0 {
+			break
+		}
+		for _, i := range candidatos {
+			if !limitados[i] {
+				alocacao[i] += excedente * recomendados[i].PesoIdeal / pesoLivre
+			}
+		}
+	}
+
+	return limitados
+}
+
+// comportaNoFGC indica se o valor cabe na garantia restante do conglomerado do título e no teto global
+func (s *RendaFixaService) comportaNoFGC(titulo models.RendaFixaRecomendada, valor float64, capacidades map[string]float64, capacidadeGlobal float64) bool {
+	capacidade, existe := capacidades[s.conglomerado(titulo.Emissor)]
+	if !existe {
+		capacidade = s.Config.LimiteFGCPorConglomerado
+	}
+	return valor <= capacidade+toleranciaFGC && valor <= capacidadeGlobal+toleranciaFGC
+}
+
+// somarAlocacao soma a alocação dos títulos informados
+func somarAlocacao(alocacao map[int]float64, indices []int) float64 {
+	total := 0.0
+	for _, i := range indices {
+		total += alocacao[i]
+	}
+	return total
+}
+
+// contemTexto indica se o texto já está na lista
+func contemTexto(lista []string, texto string) bool {
+	for _, item := range lista {
+		if item == texto {
+			return true
+		}
+	}
+	return false
+}
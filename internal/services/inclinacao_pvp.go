@@ -0,0 +1,172 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/models"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+// InclinarPesosPorPVP ajusta os pesos ideais dos FIIs pelo P/VP relativo à mediana do segmento:
+// fundos descontados ganham peso e fundos com ágio perdem, dentro do limite configurado. O P/VP vem
+// da carteira do Investidor10 para os fundos em carteira e da fonte de indicadores para os demais;
+// fundos cujo P/VP não pôde ser obtido ficam "sem P/VP" e mantêm o peso.
+// Os pesos dos fundos inclinados são renormalizados para manter a soma original deles, sem que nenhum
+// ultrapasse o limite. Os recomendados informados não são alterados; sem inclinação configurada, são
+// retornados como estão.
+func (s *DistribuidoraService) InclinarPesosPorPVP(recomendados []models.AtivoRecomendado, carteira []models.Ativo) ([]models.AtivoRecomendado, []models.InclinacaoPVP) {
+	if s.Config.InclinacaoPVPFII <= 0 {
+		return recomendados, nil
+	}
+
+	pvpPorTicker := s.pvpRecomendados(recomendados, carteira)
+
+	// Mediana do P/VP dos recomendados de cada segmento
+	pvpsPorSegmento := make(map[string][]float64)
+	for _, rec := range recomendados {
+		if pvp, existe := pvpPorTicker[rec.Ticker]; existe {
+			pvpsPorSegmento[rec.Segmento] = append(pvpsPorSegmento[rec.Segmento], pvp)
+		}
+	}
+	medianas := make(map[string]float64)
+	for segmento, pvps := range pvpsPorSegmento {
+		sort.Float64s(pvps)
+		medianas[segmento] = percentil(pvps, 50)
+	}
+
+	// Fator bruto de cada fundo com P/VP; os demais ficam fora da inclinação
+	inclinacoes := make([]models.InclinacaoPVP, len(recomendados))
+	fatores := make(map[int]float64)
+	for i, rec := range recomendados {
+		inclinacoes[i] = models.InclinacaoPVP{
+			Ticker:          rec.Ticker,
+			Segmento:        rec.Segmento,
+			PVP:             pvpPorTicker[rec.Ticker],
+			MedianaSegmento: medianas[rec.Segmento],
+			PesoOriginal:    rec.PesoIdeal,
+		}
+		if inclinacoes[i].PVP > 0 && inclinacoes[i].MedianaSegmento > 0 {
+			inclinacoes[i].Desvio = (inclinacoes[i].PVP/inclinacoes[i].MedianaSegmento - 1) * 100
+			fatores[i] = math.Max(0, 1-s.Config.InclinacaoPVPFII/100*inclinacoes[i].Desvio)
+		}
+	}
+
+	limite := s.Config.LimiteInclinacaoPVPFII / 100
+	minimo, maximo := 0.0, math.Inf(1)
+	if limite > 0 {
+		minimo, maximo = math.Max(0, 1-limite), 1+limite
+	}
+	fatores = renormalizarFatores(recomendados, fatores, minimo, maximo)
+
+	ajustados := make([]models.AtivoRecomendado, len(recomendados))
+	for i, rec := range recomendados {
+		ajustados[i] = rec
+		if fator, existe := fatores[i]; existe {
+			ajustados[i].PesoIdeal = rec.PesoIdeal * fator
+		}
+		inclinacoes[i].PesoAjustado = ajustados[i].PesoIdeal
+		inclinacoes[i].Explicacao = explicarInclinacaoPVP(inclinacoes[i])
+	}
+	return ajustados, inclinacoes
+}
+
+// pvpRecomendados retorna o P/VP de cada recomendado: o da carteira do Investidor10 para os fundos em
+// carteira e o da fonte de indicadores para os demais. Fundos sem P/VP positivo ficam fora do mapa.
+func (s *DistribuidoraService) pvpRecomendados(recomendados []models.AtivoRecomendado, carteira []models.Ativo) map[string]float64 {
+	pvpPorTicker := make(map[string]float64)
+	for _, ativo := range carteira {
+		if ativo.PVP > 0 {
+			pvpPorTicker[ativo.Ticker] = ativo.PVP
+		}
+	}
+
+	if s.indicadores == nil {
+		return pvpPorTicker
+	}
+	for _, rec := range recomendados {
+		if _, existe := pvpPorTicker[rec.Ticker]; existe {
+			continue
+		}
+		indicadores, err := s.indicadores.GetIndicadores(rec.Ticker)
+		if err != nil {
+			log.Printf("Erro ao obter P/VP de %s para a inclinação: %v", rec.Ticker, err)
+			continue
+		}
+		if indicadores.PVP > 0 {
+			pvpPorTicker[rec.Ticker] = indicadores.PVP
+		}
+	}
+	return pvpPorTicker
+}
+
+// renormalizarFatores escala os fatores para que os fundos inclinados mantenham a soma original dos
+// pesos. Fundos que a escala levaria para fora de [minimo, maximo] ficam presos no limite e a escala é
+// recalculada apenas sobre os demais, até que nenhum fundo livre ultrapasse o limite.
+func renormalizarFatores(recomendados []models.AtivoRecomendado, fatores map[int]float64, minimo, maximo float64) map[int]float64 {
+	somaOriginal := 0.0
+	for i := range fatores {
+		somaOriginal += recomendados[i].PesoIdeal
+	}
+
+	presos := make(map[int]float64)
+	escala := 1.0
+	for len(presos) < len(fatores) {
+		restante, somaLivre := somaOriginal, 0.0
+		for i, fator := range fatores {
+			if preso, existe := presos[i]; existe {
+				restante -= recomendados[i].PesoIdeal * preso
+			} else {
+				somaLivre += recomendados[i].PesoIdeal * fator
+			}
+		}
+		if somaLivre <= 0 {
+			break
+		}
+		escala = restante / somaLivre
+
+		alterado := false
+		for i, fator := range fatores {
+			if _, existe := presos[i]; existe {
+				continue
+			}
+			if fator*escala > maximo {
+				presos[i] = maximo
+				alterado = true
+			} else if fator*escala < minimo {
+				presos[i] = minimo
+				alterado = true
+			}
+		}
+		if !alterado {
+			break
+		}
+	}
+
+	resultado := make(map[int]float64, len(fatores))
+	for i, fator := range fatores {
+		if preso, existe := presos[i]; existe {
+			resultado[i] = preso
+		} else {
+			resultado[i] = math.Max(minimo, math.Min(maximo, fator*escala))
+		}
+	}
+	return resultado
+}
+
+// explicarInclinacaoPVP descreve o motivo do ajuste de peso do fundo
+func explicarInclinacaoPVP(inclinacao models.InclinacaoPVP) string {
+	pesos := fmt.Sprintf("peso de %.2f%% para %.2f%%", inclinacao.PesoOriginal, inclinacao.PesoAjustado)
+	switch {
+	case inclinacao.PVP == 0:
+		return fmt.Sprintf("sem P/VP (indisponível na carteira e na BrAPI): peso mantido em %.2f%%", inclinacao.PesoOriginal)
+	case math.Abs(inclinacao.Desvio) < 0.005:
+		return fmt.Sprintf("P/VP %.2f igual à mediana do segmento: %s apenas pela renormalização", inclinacao.PVP, pesos)
+	case inclinacao.Desvio < 0:
+		return fmt.Sprintf("P/VP %.2f com desconto de %.1f%% sobre a mediana do segmento (%.2f): %s",
+			inclinacao.PVP, -inclinacao.Desvio, inclinacao.MedianaSegmento, pesos)
+	default:
+		return fmt.Sprintf("P/VP %.2f com ágio de %.1f%% sobre a mediana do segmento (%.2f): %s",
+			inclinacao.PVP, inclinacao.Desvio, inclinacao.MedianaSegmento, pesos)
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/api"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestInclinarPesosPorPVPRespeitaLimiteAposRenormalizar(t *testing.T) {
+	cfg := config.Load()
+	cfg.InclinacaoPVPFII = 2
+	cfg.LimiteInclinacaoPVPFII = 30
+	servico := NewDistribuidoraService(cfg, &indicadoresFalsos{})
+
+	recomendados := []models.AtivoRecomendado{
+		{Ticker: "HGLG11", Segmento: "Logístico", PesoIdeal: 30},
+		{Ticker: "BTLG11", Segmento: "Logístico", PesoIdeal: 5},
+		{Ticker: "XPLG11", Segmento: "Logístico", PesoIdeal: 5},
+		{Ticker: "GARE11", Segmento: "Logístico", PesoIdeal: 10},
+	}
+	// GARE11 não está na carteira nem tem P/VP na fonte de indicadores
+	carteira := []models.Ativo{
+		{Ticker: "HGLG11", PVP: 0.8},
+		{Ticker: "BTLG11", PVP: 1.0},
+		{Ticker: "XPLG11", PVP: 1.2},
+	}
+
+	ajustados, inclinacoes := servico.InclinarPesosPorPVP(recomendados, carteira)
+
+	soma := 0.0
+	for i, rec := range ajustados {
+		soma += rec.PesoIdeal
+		fator := rec.PesoIdeal / recomendados[i].PesoIdeal
+		if fator < 0.7-1e-9 || fator > 1.3+1e-9 {
+			t.Errorf("%s: peso variou %.1f%%, acima do limite de 30%%", rec.Ticker, (fator-1)*100)
+		}
+	}
+	if math.Abs(soma-50) > 1e-9 {
+		t.Fatalf("esperava manter a soma dos pesos em 50, obteve %v", soma)
+	}
+
+	// O ágio de 20% de XPLG11 fica preso no limite e a renormalização recai sobre os demais fundos inclinados
+	if math.Abs(ajustados[2].PesoIdeal-3.5) > 1e-9 {
+		t.Errorf("XPLG11: esperava peso 3,5 no limite, obteve %v", ajustados[2].PesoIdeal)
+	}
+	if ajustados[3].PesoIdeal != 10 || !strings.HasPrefix(inclinacoes[3].Explicacao, "sem P/VP") {
+		t.Errorf("GARE11: esperava peso mantido e sem P/VP, obteve %v (%s)", ajustados[3].PesoIdeal, inclinacoes[3].Explicacao)
+	}
+}
+
+func TestInclinarPesosPorPVPBuscaPVPDosFundosForaDaCarteira(t *testing.T) {
+	cfg := config.Load()
+	cfg.InclinacaoPVPFII = 2
+	cfg.LimiteInclinacaoPVPFII = 30
+	fonte := &indicadoresFalsos{valores: map[string]api.Indicadores{"VISC11": {PVP: 0.9}}}
+	servico := NewDistribuidoraService(cfg, fonte)
+
+	recomendados := []models.AtivoRecomendado{
+		{Ticker: "XPML11", Segmento: "Shoppings", PesoIdeal: 10},
+		{Ticker: "VISC11", Segmento: "Shoppings", PesoIdeal: 10},
+	}
+	carteira := []models.Ativo{{Ticker: "XPML11", PVP: 1.0}}
+
+	ajustados, inclinacoes := servico.InclinarPesosPorPVP(recomendados, carteira)
+
+	if len(fonte.consultas) != 1 || fonte.consultas[0] != "VISC11" {
+		t.Fatalf("esperava consultar apenas VISC11, consultou %v", fonte.consultas)
+	}
+	// A mediana do segmento inclui o fundo fora da carteira, em vez de comparar XPML11 consigo mesmo
+	if inclinacoes[1].PVP != 0.9 || math.Abs(inclinacoes[0].MedianaSegmento-0.95) > 1e-9 {
+		t.Fatalf("esperava P/VP 0,9 e mediana 0,95, obteve %v e %v", inclinacoes[1].PVP, inclinacoes[0].MedianaSegmento)
+	}
+	if ajustados[1].PesoIdeal <= 10 || ajustados[0].PesoIdeal >= 10 {
+		t.Errorf("esperava VISC11 acima e XPML11 abaixo de 10, obteve %v e %v", ajustados[1].PesoIdeal, ajustados[0].PesoIdeal)
+	}
+	if math.Abs(ajustados[0].PesoIdeal+ajustados[1].PesoIdeal-20) > 1e-9 {
+		t.Errorf("esperava manter a soma dos pesos em 20, obteve %v", ajustados[0].PesoIdeal+ajustados[1].PesoIdeal)
+	}
+}
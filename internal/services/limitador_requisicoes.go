@@ -0,0 +1,72 @@
+package services
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Instância global do limitador (compartilhada por todos os clientes HTTP que fazem scraping)
+var (
+	limitadorInstancia *LimitadorPorHost
+	limitadorOnce      sync.Once
+)
+
+// LimitadorPorHost garante um intervalo mínimo entre requisições feitas ao mesmo host,
+// evitando que as análises executadas em paralelo sobrecarreguem o site de origem
+type LimitadorPorHost struct {
+	Intervalo time.Duration
+	mu        sync.Mutex
+	proximas  map[string]time.Time // Próximo horário liberado para cada host
+}
+
+// ObterLimitadorPorHost retorna a instância única do limitador
+func ObterLimitadorPorHost(intervalo time.Duration) *LimitadorPorHost {
+	limitadorOnce.Do(func() {
+		limitadorInstancia = &LimitadorPorHost{
+			Intervalo: intervalo,
+			proximas:  make(map[string]time.Time),
+		}
+	})
+	return limitadorInstancia
+}
+
+// Aguardar bloqueia até que uma nova requisição ao host esteja liberada.
+// Cada chamada reserva o próximo horário disponível, de forma que chamadas
+// concorrentes sejam espaçadas pelo intervalo configurado.
+func (l *LimitadorPorHost) Aguardar(host string) {
+	if l.Intervalo <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	agora := time.Now()
+	liberado := l.proximas[host]
+	if liberado.Before(agora) {
+		liberado = agora
+	}
+	l.proximas[host] = liberado.Add(l.Intervalo)
+	l.mu.Unlock()
+
+	time.Sleep(liberado.Sub(agora))
+}
+
+// Transporte retorna um http.RoundTripper que respeita o limite antes de cada requisição
+func (l *LimitadorPorHost) Transporte(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transporteLimitado{base: base, limitador: l}
+}
+
+// transporteLimitado aplica o LimitadorPorHost a um http.RoundTripper
+type transporteLimitado struct {
+	base      http.RoundTripper
+	limitador *LimitadorPorHost
+}
+
+// RoundTrip aguarda a liberação do host e executa a requisição
+func (t *transporteLimitado) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.limitador.Aguardar(req.URL.Host)
+	return t.base.RoundTrip(req)
+}
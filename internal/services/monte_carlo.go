@@ -0,0 +1,362 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/api"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FonteHistoricoPrecos fornece o histórico de fechamentos mensais de um ativo
+type FonteHistoricoPrecos interface {
+	GetHistoricoMensal(ticker string, periodo string) ([]api.PrecoHistorico, error)
+}
+
+// MonteCarloService simula cenários aleatórios de evolução da carteira final
+type MonteCarloService struct {
+	Config    *config.Config
+	Relogio   relogio.Relogio
+	historico FonteHistoricoPrecos
+}
+
+// NewMonteCarloService cria um novo serviço de simulação de Monte Carlo.
+// Se historico for nil, os retornos são sempre gerados a partir das premissas por classe.
+func NewMonteCarloService(cfg *config.Config, historico FonteHistoricoPrecos, rel relogio.Relogio) *MonteCarloService {
+	return &MonteCarloService{
+		Config:    cfg,
+		Relogio:   rel,
+		historico: historico,
+	}
+}
+
+// geradorRetornos sorteia o retorno mensal de uma classe
+type geradorRetornos struct {
+	historicos []float64 // Retornos mensais históricos, alinhados aos meses comuns a todas as classes
+	media      float64   // Retorno médio mensal da premissa
+	desvio     float64   // Desvio padrão mensal da premissa
+}
+
+// sortear retorna o retorno histórico do mês sorteado (o mesmo para todas as classes, preservando a
+// correlação entre elas) ou, sem histórico, um retorno da distribuição normal da premissa
+func (g geradorRetornos) sortear(rng *rand.Rand, indiceMes int) float64 {
+	if len(g.historicos) > 0 {
+		return g.historicos[indiceMes]
+	}
+	return g.media + g.desvio*rng.NormFloat64()
+}
+
+// Simular executa a simulação de Monte Carlo sobre a carteira final. A mesma semente
+// produz sempre o mesmo resultado, o que permite reproduzir os cenários.
+func (s *MonteCarloService) Simular(dados *models.TemplateDados, params ParametrosProjecao, semente int64) *models.SimulacaoMonteCarlo {
+	if params.Anos <= 0 {
+		params.Anos = s.Config.AnosProjecaoPadrao
+	}
+	if params.Anos > s.Config.AnosProjecaoMaximo {
+		params.Anos = s.Config.AnosProjecaoMaximo
+	}
+
+	simulacoes := s.Config.SimulacoesMonteCarlo
+	if simulacoes <= 0 {
+		simulacoes = 1000
+	}
+
+	estadosIniciais := montarEstadosClasses(s.Config.PremissasProjecao, dados)
+	pesos, totalPesos := pesosDistribuicaoIdeal(dados.DistribuicaoIdeal)
+	classes := classesOrdenadas(estadosIniciais)
+
+	resultado := &models.SimulacaoMonteCarlo{
+		Simulacoes:      simulacoes,
+		Semente:         semente,
+		Anos:            params.Anos,
+		AporteMensal:    params.AporteMensal,
+		MetaRendaMensal: params.MetaRendaMensal,
+		FonteRetornos:   make(map[string]string),
+		SeriePatrimonio: make(map[string][]float64),
+		SerieRenda:      make(map[string][]float64),
+	}
+
+	// Montar os geradores de retorno de cada classe. O bootstrap sorteia meses inteiros, então só usa
+	// os meses em que todas as classes com histórico suficiente têm retorno.
+	historicos := s.retornosHistoricosPorClasse(dados)
+	meses := mesesComuns(historicos, s.Config.MinimoRetornosHistoricos)
+	geradores := make(map[string]geradorRetornos)
+	for _, classe := range classes {
+		premissa := s.Config.PremissasProjecao[classe]
+		gerador := geradorRetornos{
+			media:  math.Pow(1+premissa.CrescimentoPreco/100, 1.0/12) - 1,
+			desvio: premissa.Volatilidade / 100 / math.Sqrt(12),
+		}
+
+		if retornos := historicos[classe]; len(meses) > 0 && len(retornos) >= s.Config.MinimoRetornosHistoricos {
+			for _, mes := range meses {
+				gerador.historicos = append(gerador.historicos, retornos[mes])
+			}
+			resultado.FonteRetornos[classe] = "histórico"
+		} else {
+			resultado.FonteRetornos[classe] = "premissa"
+		}
+
+		geradores[classe] = gerador
+	}
+
+	totalMeses := params.Anos * 12
+	patrimonios := make([][]float64, totalMeses)
+	rendas := make([][]float64, totalMeses)
+	for mes := range patrimonios {
+		patrimonios[mes] = make([]float64, simulacoes)
+		rendas[mes] = make([]float64, simulacoes)
+	}
+
+	rng := rand.New(rand.NewSource(semente))
+	cenariosNaMeta := 0
+
+	for simulacao := 0; simulacao < simulacoes; simulacao++ {
+		estados := copiarEstadosClasses(estadosIniciais)
+		atingiuMeta := false
+
+		for mes := 0; mes < totalMeses; mes++ {
+			rendaMes := rendaTotalEstados(estados)
+
+			valorAportar := params.AporteMensal
+			if params.ReinvestirDividendos {
+				valorAportar += rendaMes
+			}
+
+			// Um mês histórico sorteado para todas as classes; retorno de preço e crescimento
+			// dos proventos aplicados em ordem fixa de classes
+			indiceMes := 0
+			if len(meses) > 0 {
+				indiceMes = rng.Intn(len(meses))
+			}
+			for _, classe := range classes {
+				estado := estados[classe]
+				retorno := geradores[classe].sortear(rng, indiceMes)
+				estado.valor = math.Max(0, estado.valor*(1+retorno))
+				estado.rendaMensal *= estado.fatorProventos
+			}
+
+			distribuirAporteEstados(estados, pesos, totalPesos, valorAportar)
+
+			patrimonios[mes][simulacao] = patrimonioTotalEstados(estados)
+			rendas[mes][simulacao] = rendaTotalEstados(estados)
+
+			if params.MetaRendaMensal > 0 && rendas[mes][simulacao] >= params.MetaRendaMensal {
+				atingiuMeta = true
+			}
+		}
+
+		if atingiuMeta {
+			cenariosNaMeta++
+		}
+	}
+
+	if params.MetaRendaMensal > 0 {
+		resultado.ProbabilidadeMetaRenda = float64(cenariosNaMeta) / float64(simulacoes) * 100
+	}
+
+	inicio := s.Relogio.Agora()
+	for mes := 0; mes < totalMeses; mes++ {
+		ponto := models.PontoMonteCarlo{
+			Mes:        mes + 1,
+			Rotulo:     inicio.AddDate(0, mes+1, 0).Format("01/2006"),
+			Patrimonio: calcularPercentis(patrimonios[mes]),
+			Renda:      calcularPercentis(rendas[mes]),
+		}
+
+		resultado.Pontos = append(resultado.Pontos, ponto)
+		resultado.Rotulos = append(resultado.Rotulos, ponto.Rotulo)
+		resultado.SeriePatrimonio["P10"] = append(resultado.SeriePatrimonio["P10"], math.Round(ponto.Patrimonio.P10))
+		resultado.SeriePatrimonio["P50"] = append(resultado.SeriePatrimonio["P50"], math.Round(ponto.Patrimonio.P50))
+		resultado.SeriePatrimonio["P90"] = append(resultado.SeriePatrimonio["P90"], math.Round(ponto.Patrimonio.P90))
+		resultado.SerieRenda["P10"] = append(resultado.SerieRenda["P10"], math.Round(ponto.Renda.P10))
+		resultado.SerieRenda["P50"] = append(resultado.SerieRenda["P50"], math.Round(ponto.Renda.P50))
+		resultado.SerieRenda["P90"] = append(resultado.SerieRenda["P90"], math.Round(ponto.Renda.P90))
+	}
+
+	log.Printf("Monte Carlo: %d simulações (semente %d), probabilidade de atingir a meta: %.1f%%",
+		simulacoes, semente, resultado.ProbabilidadeMetaRenda)
+
+	return resultado
+}
+
+// retornosHistoricosPorClasse calcula os retornos mensais históricos de cada classe, indexados por
+// mês ("2006-01"), ponderando os ativos da carteira final pelo seu valor. Só são considerados
+// fechamentos até a data de referência.
+func (s *MonteCarloService) retornosHistoricosPorClasse(dados *models.TemplateDados) map[string]map[string]float64 {
+	retornos := make(map[string]map[string]float64)
+	if s.historico == nil {
+		return retornos
+	}
+
+	for _, classe := range ClassesRendaVariavel {
+		// Valor de cada ticker da classe na carteira final
+		ativos := make(map[string]float64)
+		for _, ativo := range dados.CarteirasFinais[classe.Nome] {
+			ativos[ativo.Ticker] += ativo.ValorTotal
+		}
+
+		// Soma ponderada dos retornos por mês (chave ano/mês), em ordem fixa de tickers
+		somaRetornos := make(map[string]float64)
+		somaPesos := make(map[string]float64)
+		pesoTotal := 0.0
+
+		for _, ticker := range chavesOrdenadas(ativos) {
+			valor := ativos[ticker]
+			if valor <= 0 {
+				continue
+			}
+			pesoTotal += valor
+
+			historico, err := s.historicoAteReferencia(ticker)
+			if err != nil {
+				log.Printf("Histórico indisponível para %s: %v", ticker, err)
+				continue
+			}
+
+			for i := 1; i < len(historico); i++ {
+				anterior := historico[i-1].Fechamento
+				if anterior <= 0 {
+					continue
+				}
+				chave := historico[i].Data.Format("2006-01")
+				somaRetornos[chave] += valor * (historico[i].Fechamento/anterior - 1)
+				somaPesos[chave] += valor
+			}
+		}
+
+		// Considerar apenas meses em que ao menos metade da classe tem histórico
+		for chave, peso := range somaPesos {
+			if pesoTotal > 0 && peso >= pesoTotal/2 {
+				if retornos[classe.Nome] == nil {
+					retornos[classe.Nome] = make(map[string]float64)
+				}
+				retornos[classe.Nome][chave] = somaRetornos[chave] / somaPesos[chave]
+			}
+		}
+	}
+
+	return retornos
+}
+
+// historicoAteReferencia obtém o histórico mensal do ticker sem os fechamentos posteriores à data de
+// referência. Em análises retroativas, busca o histórico completo e mantém a janela configurada
+// terminando na data de referência.
+func (s *MonteCarloService) historicoAteReferencia(ticker string) ([]api.PrecoHistorico, error) {
+	periodo := s.Config.PeriodoHistoricoMonteCarlo
+	referencia := s.Relogio.Agora()
+	inicio := time.Time{}
+	if relogio.Retroativo(s.Relogio) {
+		if meses := mesesPeriodo(periodo); meses > 0 {
+			inicio = referencia.AddDate(0, -meses, 0)
+		}
+		periodo = "max"
+	}
+
+	historico, err := s.historico.GetHistoricoMensal(ticker, periodo)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtrado []api.PrecoHistorico
+	for _, ponto := range historico {
+		if !ponto.Data.After(referencia) && !ponto.Data.Before(inicio) {
+			filtrado = append(filtrado, ponto)
+		}
+	}
+	return filtrado, nil
+}
+
+// mesesPeriodo converte períodos da BrAPI como "5y" e "6mo" em meses; zero para os demais ("max", "ytd")
+func mesesPeriodo(periodo string) int {
+	switch {
+	case strings.HasSuffix(periodo, "mo"):
+		meses, _ := strconv.Atoi(strings.TrimSuffix(periodo, "mo"))
+		return meses
+	case strings.HasSuffix(periodo, "y"):
+		anos, _ := strconv.Atoi(strings.TrimSuffix(periodo, "y"))
+		return anos * 12
+	}
+	return 0
+}
+
+// mesesComuns retorna, em ordem, os meses presentes no histórico de todas as classes que têm ao menos
+// o mínimo de retornos. Se esses meses não chegam ao mínimo, o bootstrap não é usado.
+func mesesComuns(historicos map[string]map[string]float64, minimo int) []string {
+	var comuns []string
+	primeira := true
+	for _, classe := range chavesOrdenadas(historicos) {
+		retornos := historicos[classe]
+		if len(retornos) < minimo {
+			continue
+		}
+		if primeira {
+			comuns = chavesOrdenadas(retornos)
+			primeira = false
+			continue
+		}
+
+		var intersecao []string
+		for _, mes := range comuns {
+			if _, existe := retornos[mes]; existe {
+				intersecao = append(intersecao, mes)
+			}
+		}
+		comuns = intersecao
+	}
+
+	if len(comuns) < minimo {
+		if len(historicos) > 0 {
+			log.Printf("Monte Carlo: apenas %d meses de histórico comuns às classes; usando as premissas", len(comuns))
+		}
+		return nil
+	}
+	return comuns
+}
+
+// chavesOrdenadas retorna as chaves do mapa em ordem alfabética, para iterações reprodutíveis
+func chavesOrdenadas[V any](mapa map[string]V) []string {
+	chaves := make([]string, 0, len(mapa))
+	for chave := range mapa {
+		chaves = append(chaves, chave)
+	}
+	sort.Strings(chaves)
+	return chaves
+}
+
+// calcularPercentis calcula os percentis 10, 25, 50, 75 e 90 de uma amostra
+func calcularPercentis(valores []float64) models.FaixaPercentis {
+	ordenados := append([]float64(nil), valores...)
+	sort.Float64s(ordenados)
+
+	return models.FaixaPercentis{
+		P10: percentil(ordenados, 10),
+		P25: percentil(ordenados, 25),
+		P50: percentil(ordenados, 50),
+		P75: percentil(ordenados, 75),
+		P90: percentil(ordenados, 90),
+	}
+}
+
+// percentil calcula o percentil p de uma amostra já ordenada, com interpolação linear
+func percentil(ordenados []float64, p float64) float64 {
+	if len(ordenados) == 0 {
+		return 0
+	}
+
+	posicao := p / 100 * float64(len(ordenados)-1)
+	inferior := int(math.Floor(posicao))
+	superior := int(math.Ceil(posicao))
+	if inferior == superior {
+		return ordenados[inferior]
+	}
+
+	fracao := posicao - float64(inferior)
+	return ordenados[inferior]*(1-fracao) + ordenados[superior]*fracao
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/api"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// historicoFalso devolve, para cada ticker, fechamentos mensais fixos
+type historicoFalso map[string][]api.PrecoHistorico
+
+func (h historicoFalso) GetHistoricoMensal(ticker string, periodo string) ([]api.PrecoHistorico, error) {
+	return h[ticker], nil
+}
+
+// serieMensal gera fechamentos mensais a partir de janeiro de 2020, aplicando os retornos em sequência
+func serieMensal(retornos ...float64) []api.PrecoHistorico {
+	inicio := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	serie := []api.PrecoHistorico{{Data: inicio, Fechamento: 100}}
+	for i, retorno := range retornos {
+		anterior := serie[len(serie)-1].Fechamento
+		serie = append(serie, api.PrecoHistorico{Data: inicio.AddDate(0, i+1, 0), Fechamento: anterior * (1 + retorno)})
+	}
+	return serie
+}
+
+func retornosAlternados(meses int) []float64 {
+	retornos := make([]float64, meses)
+	for i := range retornos {
+		retornos[i] = float64(i%7-3) / 100
+	}
+	return retornos
+}
+
+func dadosMonteCarlo() *models.TemplateDados {
+	return &models.TemplateDados{
+		CarteirasFinais: map[string][]models.AtivoCarteiraFinal{
+			"FIIs":  {{Ticker: "HGLG11", ValorTotal: 10000, DividendosMensais: 80}},
+			"Ações": {{Ticker: "ITSA4", ValorTotal: 10000, DividendosMensais: 50}},
+		},
+		DistribuicaoIdeal: map[string]float64{"FIIs": 50, "Ações": 50},
+	}
+}
+
+func TestSimularMesmaSementeMesmoResultado(t *testing.T) {
+	cfg := config.Load()
+	cfg.SimulacoesMonteCarlo = 200
+	historico := historicoFalso{
+		"HGLG11": serieMensal(retornosAlternados(36)...),
+		"ITSA4":  serieMensal(retornosAlternados(36)...),
+	}
+	rel := relogio.Fixo{Momento: time.Date(2023, 6, 30, 0, 0, 0, 0, time.UTC)}
+	servico := NewMonteCarloService(cfg, historico, rel)
+	params := ParametrosProjecao{Anos: 5, AporteMensal: 1000, MetaRendaMensal: 500}
+
+	primeira := servico.Simular(dadosMonteCarlo(), params, 7)
+	segunda := servico.Simular(dadosMonteCarlo(), params, 7)
+
+	if !reflect.DeepEqual(primeira.Pontos, segunda.Pontos) {
+		t.Fatal("a mesma semente produziu percentis diferentes")
+	}
+	if primeira.ProbabilidadeMetaRenda != segunda.ProbabilidadeMetaRenda {
+		t.Fatalf("probabilidade da meta diferente: %v e %v", primeira.ProbabilidadeMetaRenda, segunda.ProbabilidadeMetaRenda)
+	}
+	if primeira.FonteRetornos["FIIs"] != "histórico" || primeira.FonteRetornos["Ações"] != "histórico" {
+		t.Fatalf("esperava bootstrap histórico, obteve %v", primeira.FonteRetornos)
+	}
+}
+
+func TestRetornosHistoricosCortadosNaDataReferencia(t *testing.T) {
+	cfg := config.Load()
+	retornos := retornosAlternados(36)
+	retornos[35] = 5 // Alta de 500% em janeiro de 2023, depois da data de referência
+	historico := historicoFalso{"HGLG11": serieMensal(retornos...)}
+	rel := relogio.NovoFixo(time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	servico := NewMonteCarloService(cfg, historico, rel)
+
+	fiis := servico.retornosHistoricosPorClasse(dadosMonteCarlo())["FIIs"]
+
+	if len(fiis) != 35 {
+		t.Fatalf("esperava 35 retornos até 12/2022, obteve %d", len(fiis))
+	}
+	if _, existe := fiis["2023-01"]; existe {
+		t.Fatal("retorno posterior à data de referência foi considerado")
+	}
+}
+
+func TestMesesComunsUsaApenasMesesDeTodasAsClasses(t *testing.T) {
+	historicos := map[string]map[string]float64{
+		"FIIs":  {"2022-01": 0.01, "2022-02": 0.02, "2022-03": 0.03},
+		"Ações": {"2022-02": 0.04, "2022-03": 0.05, "2022-04": 0.06},
+		"ETFs":  {"2022-01": 0.07}, // Abaixo do mínimo: usa a premissa e não restringe os meses
+	}
+
+	meses := mesesComuns(historicos, 2)
+
+	if !reflect.DeepEqual(meses, []string{"2022-02", "2022-03"}) {
+		t.Fatalf("meses comuns inesperados: %v", meses)
+	}
+	if mesesComuns(historicos, 3) != nil {
+		t.Fatal("com menos meses comuns que o mínimo o bootstrap não deve ser usado")
+	}
+}
@@ -5,49 +5,52 @@ import (
 	"sort"
 )
 
-// Se não e// OtimizadoraService otimiza o investimento das sobras
+// OtimizadoraService otimiza o investimento das sobras
 type OtimizadoraService struct {
 	dataComService *DataComService
+	emissorService *EmissorService
+	setorService   *SetorService
 }
 
 // NewOtimizadoraService cria um novo serviço de otimização
-func NewOtimizadoraService() *OtimizadoraService {
+func NewOtimizadoraService(dataComService *DataComService, emissorService *EmissorService, setorService *SetorService) *OtimizadoraService {
 	return &OtimizadoraService{
-		dataComService: NewDataComService(),
+		dataComService: dataComService,
+		emissorService: emissorService,
+		setorService:   setorService,
 	}
 }
 
 // AtivoCandidate representa um ativo candidato a receber investimento adicional
 type AtivoCandidate struct {
-	Tipo      string  // "FII", "ACAO" ou "ETF"
+	Tipo      string  // "FII", "ACAO", "ETF", "BDR" ou "CRIPTO"
 	Indice    int     // Índice no array original
 	Preco     float64 // Preço unitário
 	Ticker    string  // Código do ativo
 	PesoIdeal float64 // Peso ideal para desempate
 }
 
-// OtimizarSobras otimiza o investimento das sobras
-// OtimizarSobras otimiza o investimento das sobras
-func (s *OtimizadoraService) OtimizarSobras(
-	valorSobra float64,
-	recomendadosFII []models.FIIRecomendado,
-	recomendadosAcao []models.AcaoRecomendada,
-	recomendadosETF []models.ETFRecomendado,
-	recomendacoesFII *[]models.RecomendacaoCompraFII,
-	recomendacoesAcao *[]models.RecomendacaoCompraAcao,
-	recomendacoesETF *[]models.RecomendacaoCompraETF,
-	valorTotalRecomendadoFII *float64,
-	valorTotalRecomendadoAcao *float64,
-	valorTotalRecomendadoETF *float64,
-) float64 {
+// OtimizarSobras investe as sobras comprando, uma cota por vez, o ativo mais barato que ainda
+// cabe no valor restante entre os recomendados das classes informadas
+func (s *OtimizadoraService) OtimizarSobras(valorSobra float64, classes []*ComprasClasse) float64 {
 	// Cria uma estrutura para armazenar os ativos e seus preços
 	var candidatos []AtivoCandidate
+	comprasPorCodigo := make(map[string]*ComprasClasse)
 
-	// Adiciona FIIs como candidatos (se não estiver já em excesso)
-	if len(*recomendacoesFII) > 0 {
-		for i, rec := range recomendadosFII {
+	for _, compras := range classes {
+		comprasPorCodigo[compras.Classe.Codigo] = compras
+
+		// Algumas classes só recebem sobras quando já receberam investimento no aporte
+		if compras.Classe.SobrasSomenteComCompras && len(compras.Recomendacoes) == 0 {
+			continue
+		}
+		for i, rec := range compras.Recomendados {
+			// Ativos cotados acima do preço-teto não recebem sobras
+			if compras.PrecosTeto[rec.Ticker].AcimaDoTeto {
+				continue
+			}
 			candidatos = append(candidatos, AtivoCandidate{
-				Tipo:      "FII",
+				Tipo:      compras.Classe.Codigo,
 				Indice:    i,
 				Preco:     rec.Preco,
 				Ticker:    rec.Ticker,
@@ -56,28 +59,6 @@ func (s *OtimizadoraService) OtimizarSobras(
 		}
 	}
 
-	// Adiciona Ações como candidatos
-	for i, rec := range recomendadosAcao {
-		candidatos = append(candidatos, AtivoCandidate{
-			Tipo:      "ACAO",
-			Indice:    i,
-			Preco:     rec.Preco,
-			Ticker:    rec.Ticker,
-			PesoIdeal: rec.PesoIdeal,
-		})
-	}
-
-	// Adiciona ETFs como candidatos
-	for i, rec := range recomendadosETF {
-		candidatos = append(candidatos, AtivoCandidate{
-			Tipo:      "ETF",
-			Indice:    i,
-			Preco:     rec.Preco,
-			Ticker:    rec.Ticker,
-			PesoIdeal: rec.PesoIdeal,
-		})
-	}
-
 	// Ordena os candidatos por preço (do mais barato para o mais caro)
 	sort.Slice(candidatos, func(i, j int) bool {
 		return candidatos[i].Preco < candidatos[j].Preco
@@ -91,167 +72,15 @@ func (s *OtimizadoraService) OtimizarSobras(
 
 		for i, candidato := range candidatos {
 			if candidato.Preco <= sobraFinal {
-				// Podemos comprar este ativo
-				switch candidato.Tipo {
-				case "FII":
-					// Verifica se o ticker já existe nas recomendações
-					encontrado := false
-					for j, rec := range *recomendacoesFII {
-						if rec.Ticker == candidato.Ticker {
-							// Aumenta a quantidade
-							(*recomendacoesFII)[j].Quantidade++
-							(*recomendacoesFII)[j].ValorCompra += candidato.Preco
-							*valorTotalRecomendadoFII += candidato.Preco
-
-							// Atualizar análise de data com se ainda não tiver
-							if (*recomendacoesFII)[j].StatusCompra == "" {
-								if analiseDataCom, err := s.dataComService.AnalisarDataComTicker(candidato.Ticker, "FII"); err == nil {
-									if analiseDataCom != nil {
-										(*recomendacoesFII)[j].ProximaDataCom = analiseDataCom.ProximaDataCom.Format("02/01/2006")
-										(*recomendacoesFII)[j].DiasAteDataCom = analiseDataCom.DiasAteDataCom
-										(*recomendacoesFII)[j].StatusCompra = analiseDataCom.StatusCompra
-										(*recomendacoesFII)[j].MensagemStatus = analiseDataCom.MensagemStatus
-									}
-								} else {
-									(*recomendacoesFII)[j].StatusCompra = "INDISPONIVEL"
-									(*recomendacoesFII)[j].MensagemStatus = "Dados não disponíveis"
-								}
-							}
-
-							encontrado = true
-							break
-						}
-					}
-
-					// Se não encontrou, cria uma nova recomendação
-					if !encontrado && len(*recomendacoesFII) > 0 {
-						fii := recomendadosFII[candidato.Indice]
-						novaRec := models.RecomendacaoCompraFII{
-							Ticker:         fii.Ticker,
-							Nome:           fii.Nome,
-							Segmento:       fii.Segmento,
-							Tipo:           fii.Tipo,
-							Preco:          fii.Preco,
-							PesoAtual:      0,
-							PesoIdeal:      fii.PesoIdeal,
-							Diferenca:      fii.PesoIdeal,
-							Quantidade:     1,
-							ValorCompra:    fii.Preco,
-							PesoAposCompra: 0, // Será recalculado depois
-						}
-
-						// ADICIONE ANÁLISE DE DATA COM
-						if analiseDataCom, err := s.dataComService.AnalisarDataComTicker(fii.Ticker, "FII"); err == nil {
-							if analiseDataCom != nil {
-								novaRec.ProximaDataCom = analiseDataCom.ProximaDataCom.Format("02/01/2006")
-								novaRec.DiasAteDataCom = analiseDataCom.DiasAteDataCom
-								novaRec.StatusCompra = analiseDataCom.StatusCompra
-								novaRec.MensagemStatus = analiseDataCom.MensagemStatus
-							}
-						} else {
-							novaRec.StatusCompra = "INDISPONIVEL"
-							novaRec.MensagemStatus = "Dados não disponíveis"
-						}
-
-						*recomendacoesFII = append(*recomendacoesFII, novaRec)
-						*valorTotalRecomendadoFII += fii.Preco
-					}
-
-				case "ACAO":
-					// Verifica se o ticker já existe nas recomendações
-					encontrado := false
-					for j, rec := range *recomendacoesAcao {
-						if rec.Ticker == candidato.Ticker {
-							// Aumenta a quantidade
-							(*recomendacoesAcao)[j].Quantidade++
-							(*recomendacoesAcao)[j].ValorCompra += candidato.Preco
-							*valorTotalRecomendadoAcao += candidato.Preco
-
-							// Atualizar análise de data com se ainda não tiver
-							if (*recomendacoesAcao)[j].StatusCompra == "" {
-								if analiseDataCom, err := s.dataComService.AnalisarDataComTicker(candidato.Ticker, "ACAO"); err == nil {
-									if analiseDataCom != nil {
-										(*recomendacoesAcao)[j].ProximaDataCom = analiseDataCom.ProximaDataCom.Format("02/01/2006")
-										(*recomendacoesAcao)[j].DiasAteDataCom = analiseDataCom.DiasAteDataCom
-										(*recomendacoesAcao)[j].StatusCompra = analiseDataCom.StatusCompra
-										(*recomendacoesAcao)[j].MensagemStatus = analiseDataCom.MensagemStatus
-									}
-								} else {
-									(*recomendacoesAcao)[j].StatusCompra = "INDISPONIVEL"
-									(*recomendacoesAcao)[j].MensagemStatus = "Dados não disponíveis"
-								}
-							}
-
-							encontrado = true
-							break
-						}
-					}
-
-					// Se não encontrou, cria uma nova recomendação
-					if !encontrado {
-						acao := recomendadosAcao[candidato.Indice]
-						novaRec := models.RecomendacaoCompraAcao{
-							Ticker:         acao.Ticker,
-							Nome:           acao.Nome,
-							Preco:          acao.Preco,
-							PesoAtual:      0,
-							PesoIdeal:      acao.PesoIdeal,
-							Diferenca:      acao.PesoIdeal,
-							Quantidade:     1,
-							ValorCompra:    acao.Preco,
-							PesoAposCompra: 0, // Será recalculado depois
-						}
-
-						// ADICIONE ANÁLISE DE DATA COM
-						if analiseDataCom, err := s.dataComService.AnalisarDataComTicker(acao.Ticker, "ACAO"); err == nil {
-							if analiseDataCom != nil {
-								novaRec.ProximaDataCom = analiseDataCom.ProximaDataCom.Format("02/01/2006")
-								novaRec.DiasAteDataCom = analiseDataCom.DiasAteDataCom
-								novaRec.StatusCompra = analiseDataCom.StatusCompra
-								novaRec.MensagemStatus = analiseDataCom.MensagemStatus
-							}
-						} else {
-							novaRec.StatusCompra = "INDISPONIVEL"
-							novaRec.MensagemStatus = "Dados não disponíveis"
-						}
-
-						*recomendacoesAcao = append(*recomendacoesAcao, novaRec)
-						*valorTotalRecomendadoAcao += acao.Preco
-					}
-
-				case "ETF":
-					// Verifica se o ticker já existe nas recomendações
-					encontrado := false
-					for j, rec := range *recomendacoesETF {
-						if rec.Ticker == candidato.Ticker {
-							// Aumenta a quantidade
-							(*recomendacoesETF)[j].Quantidade++
-							(*recomendacoesETF)[j].ValorCompra += candidato.Preco
-							*valorTotalRecomendadoETF += candidato.Preco
-							encontrado = true
-							break
-						}
-					}
-
-					// Se não encontrou, cria uma nova recomendação
-					if !encontrado {
-						etf := recomendadosETF[candidato.Indice]
-						novaRec := models.RecomendacaoCompraETF{
-							Ticker:         etf.Ticker,
-							Nome:           etf.Nome,
-							Preco:          etf.Preco,
-							PesoAtual:      0,
-							PesoIdeal:      etf.PesoIdeal,
-							Diferenca:      etf.PesoIdeal,
-							Quantidade:     1,
-							ValorCompra:    etf.Preco,
-							PesoAposCompra: 0, // Será recalculado depois
-						}
-						*recomendacoesETF = append(*recomendacoesETF, novaRec)
-						*valorTotalRecomendadoETF += etf.Preco
-					}
+				// A cota extra não pode levar o emissor ou o setor além dos limites de concentração da classe
+				compras := comprasPorCodigo[candidato.Tipo]
+				if s.excedeLimites(compras, candidato) {
+					continue
 				}
 
+				// Podemos comprar este ativo
+				s.adicionarUnidade(compras, compras.Recomendados[candidato.Indice])
+
 				// Atualiza a sobra
 				sobraFinal -= candidato.Preco
 				candidatoEncontrado = true
@@ -271,3 +100,63 @@ func (s *OtimizadoraService) OtimizarSobras(
 	// Retorna a sobra que não foi possível investir
 	return sobraFinal
 }
+
+// adicionarUnidade compra mais uma cota do ativo, somando-a à recomendação existente
+// ou criando uma nova, com a análise de data com quando a classe é analisada
+func (s *OtimizadoraService) adicionarUnidade(compras *ComprasClasse, ativo models.AtivoRecomendado) {
+	compras.ValorTotal += ativo.Preco
+	tipoDataCom := compras.Classe.TipoDataCom
+
+	// Verifica se o ticker já existe nas recomendações
+	for j, rec := range compras.Recomendacoes {
+		if rec.Ticker == ativo.Ticker {
+			// Aumenta a quantidade
+			compras.Recomendacoes[j].Quantidade++
+			compras.Recomendacoes[j].ValorCompra += ativo.Preco
+
+			// Atualizar análise de data com se ainda não tiver
+			if tipoDataCom != "" && compras.Recomendacoes[j].StatusCompra == "" {
+				preencherDataCom(s.dataComService, &compras.Recomendacoes[j], tipoDataCom)
+			}
+			return
+		}
+	}
+
+	// Se não encontrou, cria uma nova recomendação
+	novaRec := models.RecomendacaoCompra{
+		Ticker:         ativo.Ticker,
+		Nome:           ativo.Nome,
+		Segmento:       ativo.Segmento,
+		Tipo:           ativo.Tipo,
+		Preco:          ativo.Preco,
+		PesoAtual:      0,
+		PesoIdeal:      ativo.PesoIdeal,
+		Diferenca:      ativo.PesoIdeal,
+		Quantidade:     1,
+		ValorCompra:    ativo.Preco,
+		PesoAposCompra: 0, // Será recalculado depois
+	}
+	preencherPrecoTeto(&novaRec, compras.PrecosTeto)
+	if tipoDataCom != "" {
+		preencherDataCom(s.dataComService, &novaRec, tipoDataCom)
+	}
+	compras.Recomendacoes = append(compras.Recomendacoes, novaRec)
+}
+
+// excedeLimites verifica se mais uma unidade do candidato levaria o emissor ou o setor além do limite
+func (s *OtimizadoraService) excedeLimites(compras *ComprasClasse, candidato AtivoCandidate) bool {
+	if limite := s.emissorService.LimiteEmissor(compras.Classe, compras.ValorFuturo); limite > 0 {
+		emissor := s.emissorService.Emissor(candidato.Ticker)
+		if valorGrupo(emissor, s.emissorService.Emissor, compras.Carteira, compras.Recomendacoes)+candidato.Preco > limite {
+			return true
+		}
+	}
+
+	setor := s.setorService.Setor(candidato.Ticker)
+	if limite := s.setorService.LimiteSetor(compras.Classe, setor, compras.ValorFuturo); limite > 0 {
+		if valorGrupo(setor, s.setorService.Setor, compras.Carteira, compras.Recomendacoes)+candidato.Preco > limite {
+			return true
+		}
+	}
+	return false
+}
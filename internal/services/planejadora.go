@@ -0,0 +1,181 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"fmt"
+	"log"
+	"math"
+)
+
+// PlanejadoraService simula aportes mensais sucessivos sobre a carteira
+type PlanejadoraService struct {
+	Config      *config.Config
+	calculadora *Calculadora
+}
+
+// NewPlanejadoraService cria um novo serviço de planejamento de aportes
+func NewPlanejadoraService(cfg *config.Config, calculadora *Calculadora) *PlanejadoraService {
+	return &PlanejadoraService{
+		Config:      cfg,
+		calculadora: calculadora,
+	}
+}
+
+// PlanejarAportes simula mês a mês o aporte fixo, reinvestindo os dividendos projetados
+// e aplicando as compras recomendadas à carteira antes do mês seguinte. Cada mês é calculado
+// com o relógio avançado até a sua data, de modo que a situação de data com e as janelas de
+// proventos dos últimos 12 meses acompanham o cronograma. Os preços e as listas de recomendados
+// continuam sendo os atuais em todos os meses.
+func (s *PlanejadoraService) PlanejarAportes(
+	aporteMensal float64,
+	meses int,
+	tiposInvestimento models.TiposInvestimento,
+	entradas *EntradasCalculo,
+	metaReserva float64,
+) (*models.PlanoAportes, error) {
+	if aporteMensal <= 0 {
+		return nil, fmt.Errorf("o aporte mensal deve ser maior que zero")
+	}
+	if meses <= 0 || meses > s.Config.MesesPlanejamentoMaximo {
+		return nil, fmt.Errorf("o horizonte deve estar entre 1 e %d meses", s.Config.MesesPlanejamentoMaximo)
+	}
+
+	// Trabalhar sobre cópias para não alterar as carteiras originais
+	carteiras := make(map[string][]models.Ativo, len(entradas.Carteiras))
+	for classe, ativos := range entradas.Carteiras {
+		carteiras[classe] = append([]models.Ativo(nil), ativos...)
+	}
+	// Os aportes em renda fixa são acumulados à parte, sem criar posições na carteira
+	entradasMes := &EntradasCalculo{
+		Carteiras:         carteiras,
+		Recomendados:      entradas.Recomendados,
+		CarteiraRendaFixa: entradas.CarteiraRendaFixa,
+	}
+
+	plano := &models.PlanoAportes{
+		AporteMensal:       aporteMensal,
+		Meses:              meses,
+		ToleranciaMeta:     s.Config.ToleranciaMetaClasse,
+		MesAtingimentoMeta: make(map[string]int),
+	}
+
+	dividendosAcumulados := 0.0
+	sobraAnterior := 0.0
+
+	for mes := 1; mes <= meses; mes++ {
+		valorInvestido := aporteMensal + dividendosAcumulados + sobraAnterior
+		relogioMes := relogio.Avancar(s.calculadora.relogio, 0, mes-1, 0)
+		log.Printf("=== PLANEJAMENTO: mês %d (%s) - investindo R$ %.2f ===",
+			mes, relogioMes.Agora().Format("02/01/2006"), valorInvestido)
+
+		dados, err := s.calculadora.comRelogio(relogioMes).CalcularRecomendacoes(
+			valorInvestido,
+			tiposInvestimento,
+			entradasMes,
+			metaReserva,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao calcular o mês %d: %w", mes, err)
+		}
+
+		if mes == 1 {
+			plano.ValorTotalInicial = dados.ValorTotalCarteira
+			plano.DistribuicaoInicial = dados.DistribuicaoAtual
+			plano.DistribuicaoIdeal = dados.DistribuicaoIdeal
+		}
+
+		// Aplicar as compras do mês à carteira
+		for classe, recomendacoes := range dados.Recomendacoes {
+			carteiras[classe] = aplicarCompras(carteiras[classe], recomendacoes)
+		}
+		entradasMes.RendaFixaPlanejada += dados.ValoresRecomendados[NomeRendaFixa]
+		entradasMes.ReservaPlanejada += dados.ReservaEmergencia.Aporte
+
+		// Dividendos projetados para o mês seguinte
+		rendimentosMensais := dados.RendaTotal.MensalLiquida
+
+		etapa := models.EtapaPlanoAportes{
+			Mes:                    mes,
+			Data:                   relogioMes.Agora(),
+			ValorAporte:            aporteMensal,
+			DividendosReinvestidos: dividendosAcumulados,
+			SobraAnterior:          sobraAnterior,
+			ValorInvestido:         valorInvestido,
+			RecomendacoesFII:       dados.RecomendacoesFII,
+			RecomendacoesAcao:      dados.RecomendacoesAcao,
+			RecomendacoesETF:       dados.RecomendacoesETF,
+			RecomendacoesBDR:       dados.RecomendacoesBDR,
+			RecomendacoesCripto:    dados.RecomendacoesCripto,
+			ValorRendaFixa:         dados.ValorTotalRecomendadoFixa,
+			ValorReservaEmergencia: dados.ReservaEmergencia.Aporte,
+			ValorRestante:          dados.ValorRestante,
+			ValorTotalCarteira:     dados.ValorTotalFinal,
+			RendimentosMensais:     rendimentosMensais,
+			DistribuicaoFinal:      dados.DistribuicaoFinal,
+			ClassesNaMeta:          make(map[string]bool),
+		}
+
+		// Registrar o primeiro mês em que cada classe atinge a meta
+		for classe, ideal := range dados.DistribuicaoIdeal {
+			if ideal <= 0 {
+				continue
+			}
+			naMeta := math.Abs(dados.DistribuicaoFinal[classe]-ideal) <= s.Config.ToleranciaMetaClasse
+			etapa.ClassesNaMeta[classe] = naMeta
+			if naMeta && plano.MesAtingimentoMeta[classe] == 0 {
+				plano.MesAtingimentoMeta[classe] = mes
+			}
+		}
+
+		plano.Etapas = append(plano.Etapas, etapa)
+		plano.TotalAportado += aporteMensal
+		plano.TotalDividendosReinvestidos += dividendosAcumulados
+		plano.ValorTotalFinal = dados.ValorTotalFinal
+
+		dividendosAcumulados = rendimentosMensais
+		sobraAnterior = dados.ValorRestante
+	}
+
+	// Garantir que todas as classes com meta apareçam no mapa
+	for classe, ideal := range plano.DistribuicaoIdeal {
+		if _, existe := plano.MesAtingimentoMeta[classe]; !existe && ideal > 0 {
+			plano.MesAtingimentoMeta[classe] = 0
+		}
+	}
+
+	return plano, nil
+}
+
+// aplicarCompras adiciona as cotas compradas às posições de uma classe e recalcula os pesos
+func aplicarCompras(carteira []models.Ativo, recomendacoes []models.RecomendacaoCompra) []models.Ativo {
+	for _, rec := range recomendacoes {
+		encontrado := false
+		for i := range carteira {
+			if carteira[i].Ticker == rec.Ticker {
+				carteira[i].Quantidade += rec.Quantidade
+				encontrado = true
+				break
+			}
+		}
+
+		if !encontrado {
+			carteira = append(carteira, models.Ativo{
+				Ticker:     rec.Ticker,
+				Segmento:   rec.Segmento,
+				Tipo:       rec.Tipo,
+				Preco:      rec.Preco,
+				Quantidade: rec.Quantidade,
+			})
+		}
+	}
+
+	total := valorTotalAtivos(carteira)
+	for i := range carteira {
+		if total > 0 && !carteira[i].SemCotacao {
+			carteira[i].PercentualCarteira = (carteira[i].Preco * float64(carteira[i].Quantidade) / total) * 100
+		}
+	}
+	return carteira
+}
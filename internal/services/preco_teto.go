@@ -0,0 +1,139 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/api"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"log"
+	"math"
+	"strings"
+)
+
+// FonteIndicadores fornece o P/L e o P/VP dos ativos (implementada pelo cliente da BrAPI)
+type FonteIndicadores interface {
+	GetIndicadores(ticker string) (api.Indicadores, error)
+}
+
+// PrecoTetoService estima o preço-teto das ações pelos métodos de Bazin e Graham
+type PrecoTetoService struct {
+	Config      *config.Config
+	fonte       FonteProventos
+	indicadores FonteIndicadores
+	relogio     relogio.Relogio
+}
+
+// NewPrecoTetoService cria um novo serviço de preço-teto
+func NewPrecoTetoService(cfg *config.Config, fonte FonteProventos, indicadores FonteIndicadores, rel relogio.Relogio) *PrecoTetoService {
+	return &PrecoTetoService{
+		Config:      cfg,
+		fonte:       fonte,
+		indicadores: indicadores,
+		relogio:     rel,
+	}
+}
+
+// AjustarCompra reduz a compra de um recomendado cuja cotação supera o preço-teto, conforme a política
+// configurada. O valor cortado não é redistribuído na classe e fica para a otimização das sobras.
+func (s *PrecoTetoService) AjustarCompra(valorCompra float64) float64 {
+	if strings.ToLower(s.Config.PoliticaPrecoTeto) == "pular" {
+		return 0
+	}
+	return valorCompra * math.Max(0, 100-s.Config.ReducaoAcimaPrecoTeto) / 100
+}
+
+// AvaliarRecomendados calcula o preço-teto de cada recomendado. O LPA e o VPA do método de Graham
+// vêm do P/L e do P/VP da carteira do Investidor10 para as ações em carteira e da BrAPI para as demais.
+// Retorna nil quando nenhum método está configurado.
+func (s *PrecoTetoService) AvaliarRecomendados(recomendados []models.AtivoRecomendado, carteira []models.Ativo) map[string]models.PrecoTeto {
+	if len(s.Config.MetodosPrecoTeto) == 0 {
+		return nil
+	}
+
+	naCarteira := make(map[string]models.Ativo)
+	for _, ativo := range carteira {
+		naCarteira[ativo.Ticker] = ativo
+	}
+
+	avaliacoes := make(map[string]models.PrecoTeto)
+	for _, rec := range recomendados {
+		avaliacao := s.avaliar(rec, naCarteira[rec.Ticker])
+		if avaliacao.AcimaDoTeto {
+			log.Printf("Ação %s: cotação R$ %.2f acima do preço-teto R$ %.2f (Bazin R$ %.2f, Graham R$ %.2f)",
+				rec.Ticker, rec.Preco, avaliacao.PrecoTeto, avaliacao.PrecoBazin, avaliacao.PrecoGraham)
+		}
+		avaliacoes[rec.Ticker] = avaliacao
+	}
+	return avaliacoes
+}
+
+// avaliar aplica os métodos configurados a um recomendado
+func (s *PrecoTetoService) avaliar(rec models.AtivoRecomendado, ativo models.Ativo) models.PrecoTeto {
+	var avaliacao models.PrecoTeto
+	for _, metodo := range s.Config.MetodosPrecoTeto {
+		switch strings.ToLower(metodo) {
+		case "bazin":
+			avaliacao.PrecoBazin = s.precoBazin(rec.Ticker)
+		case "graham":
+			preco, indicadores := s.indicadoresGraham(rec, ativo)
+			avaliacao.PrecoGraham = precoGraham(preco, indicadores)
+		}
+	}
+
+	for _, teto := range []float64{avaliacao.PrecoBazin, avaliacao.PrecoGraham} {
+		if teto > 0 && (avaliacao.PrecoTeto == 0 || teto < avaliacao.PrecoTeto) {
+			avaliacao.PrecoTeto = teto
+		}
+	}
+	if avaliacao.PrecoTeto > 0 {
+		avaliacao.MargemSeguranca = (avaliacao.PrecoTeto - rec.Preco) / avaliacao.PrecoTeto * 100
+		avaliacao.AcimaDoTeto = rec.Preco > avaliacao.PrecoTeto
+	}
+	return avaliacao
+}
+
+// indicadoresGraham retorna a cotação e os múltiplos usados no método de Graham: os da carteira do
+// Investidor10 quando a ação está em carteira com P/L e P/VP, ou os da BrAPI com a cotação do recomendado
+func (s *PrecoTetoService) indicadoresGraham(rec models.AtivoRecomendado, ativo models.Ativo) (float64, api.Indicadores) {
+	if ativo.PL > 0 && ativo.PVP > 0 {
+		return ativo.Preco, api.Indicadores{PL: ativo.PL, PVP: ativo.PVP}
+	}
+	if s.indicadores == nil {
+		return 0, api.Indicadores{}
+	}
+
+	indicadores, err := s.indicadores.GetIndicadores(rec.Ticker)
+	if err != nil {
+		log.Printf("Erro ao obter P/L e P/VP para o preço-teto de %s: %v", rec.Ticker, err)
+		return 0, api.Indicadores{}
+	}
+	return rec.Preco, indicadores
+}
+
+// precoBazin divide os proventos pagos nos últimos 12 meses (dividendos e JCP) pela taxa mínima
+func (s *PrecoTetoService) precoBazin(ticker string) float64 {
+	if s.Config.TaxaMinimaBazin <= 0 {
+		return 0
+	}
+	proventos, err := s.fonte.ObterHistoricoProventos(ticker, "ACAO")
+	if err != nil {
+		log.Printf("Erro ao obter proventos para o preço-teto de %s: %v", ticker, err)
+		return 0
+	}
+
+	total := 0.0
+	for _, provento := range proventosUltimoAno(proventos, s.relogio.Agora()) {
+		total += provento.Valor
+	}
+	return total / (s.Config.TaxaMinimaBazin / 100)
+}
+
+// precoGraham calcula o número de Graham a partir do LPA (cotação / P/L) e do VPA (cotação / P/VP)
+func precoGraham(preco float64, indicadores api.Indicadores) float64 {
+	if preco <= 0 || indicadores.PL <= 0 || indicadores.PVP <= 0 {
+		return 0
+	}
+	lpa := preco / indicadores.PL
+	vpa := preco / indicadores.PVP
+	return math.Sqrt(22.5 * lpa * vpa)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/api"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"math"
+	"testing"
+	"time"
+)
+
+// indicadoresFalsos devolve múltiplos fixos por ticker, registrando as consultas
+type indicadoresFalsos struct {
+	valores   map[string]api.Indicadores
+	consultas []string
+}
+
+func (f *indicadoresFalsos) GetIndicadores(ticker string) (api.Indicadores, error) {
+	f.consultas = append(f.consultas, ticker)
+	return f.valores[ticker], nil
+}
+
+func TestAvaliarRecomendadosBuscaIndicadoresForaDaCarteira(t *testing.T) {
+	cfg := config.Load()
+	cfg.MetodosPrecoTeto = []string{"graham"}
+	fonte := &indicadoresFalsos{valores: map[string]api.Indicadores{"WEGE3": {PL: 10, PVP: 1}}}
+	servico := NewPrecoTetoService(cfg, nil, fonte, relogio.Fixo{Momento: time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)})
+
+	avaliacoes := servico.AvaliarRecomendados(
+		[]models.AtivoRecomendado{{Ticker: "BBAS3", Preco: 20}, {Ticker: "WEGE3", Preco: 40}},
+		[]models.Ativo{{Ticker: "BBAS3", Preco: 20, PL: 4, PVP: 0.8}},
+	)
+
+	if len(fonte.consultas) != 1 || fonte.consultas[0] != "WEGE3" {
+		t.Fatalf("esperava consultar apenas WEGE3, consultou %v", fonte.consultas)
+	}
+	// Graham: raiz(22,5 x (cotação / P/L) x (cotação / P/VP))
+	for ticker, esperado := range map[string]float64{
+		"BBAS3": math.Sqrt(22.5 * 5 * 25),
+		"WEGE3": math.Sqrt(22.5 * 4 * 40),
+	} {
+		if math.Abs(avaliacoes[ticker].PrecoGraham-esperado) > 1e-9 {
+			t.Errorf("%s: esperava preço de Graham %v, obteve %v", ticker, esperado, avaliacoes[ticker].PrecoGraham)
+		}
+	}
+}
+
+func TestCorteDoPrecoTetoAplicadoDepoisDoAjusteProporcional(t *testing.T) {
+	cfg := config.Load()
+	cfg.PoliticaPrecoTeto = "reduzir"
+	cfg.ReducaoAcimaPrecoTeto = 50
+	servico := NewRecomendadoraService(nil, NewEmissorService(cfg, nil, nil), NewSetorService(cfg, nil), NewPrecoTetoService(cfg, nil, nil, relogio.Sistema{}))
+
+	// Sem data com, para não consultar o Investidor10
+	classe := ClasseAcao
+	classe.TipoDataCom = ""
+	recomendados := []models.AtivoRecomendado{
+		{Ticker: "BBAS3", Preco: 1, PesoIdeal: 50},
+		{Ticker: "WEGE3", Preco: 1, PesoIdeal: 50},
+	}
+	precosTeto := map[string]models.PrecoTeto{"WEGE3": {PrecoTeto: 0.5, AcimaDoTeto: true}}
+
+	// A classe precisa de 500 em cada ativo, mas recebe 500 no total: o ajuste proporcional deixa
+	// 250 para cada um e só então a compra de WEGE3 é reduzida pela metade
+	recomendacoes := servico.GerarRecomendacoes(classe, nil, recomendados, precosTeto, 500, 500)
+
+	compras := make(map[string]float64)
+	for _, rec := range recomendacoes {
+		compras[rec.Ticker] = rec.ValorCompra
+	}
+	if compras["BBAS3"] != 250 || compras["WEGE3"] != 125 {
+		t.Fatalf("esperava BBAS3 = 250 e WEGE3 = 125, obteve %v", compras)
+	}
+	if sobra := 500 - somarCompras(recomendacoes); sobra != 125 {
+		t.Fatalf("esperava 125 de sobra, obteve %v", sobra)
+	}
+}
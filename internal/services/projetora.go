@@ -0,0 +1,234 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"log"
+	"math"
+	"sort"
+)
+
+// ProjetoraService projeta a evolução de longo prazo da carteira final
+type ProjetoraService struct {
+	Config  *config.Config
+	Relogio relogio.Relogio
+}
+
+// NewProjetoraService cria um novo serviço de projeção de patrimônio
+func NewProjetoraService(cfg *config.Config, rel relogio.Relogio) *ProjetoraService {
+	return &ProjetoraService{
+		Config:  cfg,
+		Relogio: rel,
+	}
+}
+
+// ParametrosProjecao contém os parâmetros informados pelo usuário para a projeção
+type ParametrosProjecao struct {
+	Anos                 int
+	AporteMensal         float64
+	MetaRendaMensal      float64
+	ReinvestirDividendos bool
+}
+
+// estadoClasse guarda o valor e a renda mensal projetados de uma classe
+type estadoClasse struct {
+	valor          float64
+	rendaMensal    float64
+	yieldMensal    float64 // Yield usado quando a classe ainda não tem posição
+	fatorPreco     float64 // Fator mensal de valorização
+	fatorProventos float64 // Fator mensal de crescimento dos proventos
+}
+
+// ProjetarPatrimonio simula mês a mês os aportes, o reinvestimento dos dividendos e o
+// crescimento de preços e proventos de cada classe a partir da carteira final
+func (s *ProjetoraService) ProjetarPatrimonio(dados *models.TemplateDados, params ParametrosProjecao) *models.ProjecaoPatrimonio {
+	if params.Anos <= 0 {
+		params.Anos = s.Config.AnosProjecaoPadrao
+	}
+	if params.Anos > s.Config.AnosProjecaoMaximo {
+		params.Anos = s.Config.AnosProjecaoMaximo
+	}
+
+	estados := montarEstadosClasses(s.Config.PremissasProjecao, dados)
+	pesos, totalPesos := pesosDistribuicaoIdeal(dados.DistribuicaoIdeal)
+
+	projecao := &models.ProjecaoPatrimonio{
+		Anos:                 params.Anos,
+		AporteMensal:         params.AporteMensal,
+		MetaRendaMensal:      params.MetaRendaMensal,
+		ReinvestirDividendos: params.ReinvestirDividendos,
+	}
+
+	for _, estado := range estados {
+		projecao.PatrimonioInicial += estado.valor
+		projecao.RendaMensalInicial += estado.rendaMensal
+	}
+
+	inicio := s.Relogio.Agora()
+	totalMeses := params.Anos * 12
+
+	for mes := 1; mes <= totalMeses; mes++ {
+		// Renda gerada no mês pela carteira atual
+		rendaMes := rendaTotalEstados(estados)
+
+		dividendosReinvestidos := 0.0
+		if params.ReinvestirDividendos {
+			dividendosReinvestidos = rendaMes
+		}
+		valorAportar := params.AporteMensal + dividendosReinvestidos
+
+		// Crescimento de preços e proventos
+		for _, estado := range estados {
+			estado.valor *= estado.fatorPreco
+			estado.rendaMensal *= estado.fatorProventos
+		}
+
+		// Distribuir o aporte conforme a distribuição ideal, comprando ao yield corrente
+		distribuirAporteEstados(estados, pesos, totalPesos, valorAportar)
+
+		projecao.TotalAportado += params.AporteMensal
+		projecao.TotalDividendosReinvestidos += dividendosReinvestidos
+
+		ponto := models.PontoProjecao{
+			Mes:                    mes,
+			Rotulo:                 inicio.AddDate(0, mes, 0).Format("01/2006"),
+			Aporte:                 params.AporteMensal,
+			DividendosReinvestidos: dividendosReinvestidos,
+			TotalAportado:          projecao.TotalAportado,
+			PatrimonioPorClasse:    make(map[string]float64),
+		}
+		for classe, estado := range estados {
+			ponto.Patrimonio += estado.valor
+			ponto.RendaPassivaMensal += estado.rendaMensal
+			ponto.PatrimonioPorClasse[classe] = estado.valor
+		}
+
+		// Registrar o primeiro mês em que a renda passiva atinge a meta
+		if params.MetaRendaMensal > 0 && projecao.MesMetaRenda == 0 && ponto.RendaPassivaMensal >= params.MetaRendaMensal {
+			projecao.MesMetaRenda = mes
+			projecao.RotuloMetaRenda = ponto.Rotulo
+		}
+
+		projecao.Pontos = append(projecao.Pontos, ponto)
+		projecao.Rotulos = append(projecao.Rotulos, ponto.Rotulo)
+		projecao.SeriePatrimonio = append(projecao.SeriePatrimonio, math.Round(ponto.Patrimonio*100)/100)
+		projecao.SerieRenda = append(projecao.SerieRenda, math.Round(ponto.RendaPassivaMensal*100)/100)
+	}
+
+	if len(projecao.Pontos) > 0 {
+		ultimo := projecao.Pontos[len(projecao.Pontos)-1]
+		projecao.PatrimonioFinal = ultimo.Patrimonio
+		projecao.RendaMensalFinal = ultimo.RendaPassivaMensal
+	}
+
+	log.Printf("Projeção de %d anos: patrimônio final R$ %.2f, renda mensal R$ %.2f (meta no mês %d)",
+		params.Anos, projecao.PatrimonioFinal, projecao.RendaMensalFinal, projecao.MesMetaRenda)
+
+	return projecao
+}
+
+// montarEstadosClasses monta o estado inicial de cada classe a partir da carteira final. A renda inicial
+// é a renda líquida de IR estimada para a classe, que é o que efetivamente pode ser reinvestido; sem ela,
+// a estimativa pelo DY da carteira final e, por fim, a premissa de dividend yield da classe.
+func montarEstadosClasses(premissas map[string]config.PremissaClasse, dados *models.TemplateDados) map[string]*estadoClasse {
+	estados := make(map[string]*estadoClasse)
+	for _, classe := range ClassesInvestimento {
+		valor := dados.ValoresFinais[classe]
+		premissa := premissas[classe]
+		estado := &estadoClasse{
+			valor:          valor,
+			yieldMensal:    premissa.DividendYield / 100 / 12,
+			fatorPreco:     math.Pow(1+premissa.CrescimentoPreco/100, 1.0/12),
+			fatorProventos: math.Pow(1+premissa.CrescimentoDividendos/100, 1.0/12),
+		}
+
+		estado.rendaMensal = dados.RendaPorClasse[classe].MensalLiquida
+		if estado.rendaMensal <= 0 {
+			for _, ativo := range dados.CarteirasFinais[classe] {
+				estado.rendaMensal += ativo.DividendosMensais
+			}
+		}
+		if estado.rendaMensal <= 0 {
+			estado.rendaMensal = valor * estado.yieldMensal
+		}
+
+		estados[classe] = estado
+	}
+
+	return estados
+}
+
+// copiarEstadosClasses cria uma cópia independente dos estados das classes
+func copiarEstadosClasses(estados map[string]*estadoClasse) map[string]*estadoClasse {
+	copia := make(map[string]*estadoClasse, len(estados))
+	for classe, estado := range estados {
+		estadoCopia := *estado
+		copia[classe] = &estadoCopia
+	}
+	return copia
+}
+
+// pesosDistribuicaoIdeal retorna os pesos usados para distribuir os aportes futuros
+func pesosDistribuicaoIdeal(distribuicaoIdeal map[string]float64) (map[string]float64, float64) {
+	pesos := make(map[string]float64)
+	totalPesos := 0.0
+	for classe, percentual := range distribuicaoIdeal {
+		if percentual > 0 {
+			pesos[classe] = percentual
+			totalPesos += percentual
+		}
+	}
+	return pesos, totalPesos
+}
+
+// classesOrdenadas retorna os nomes das classes em ordem fixa, para somas reprodutíveis
+func classesOrdenadas(estados map[string]*estadoClasse) []string {
+	classes := make([]string, 0, len(estados))
+	for classe := range estados {
+		classes = append(classes, classe)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// rendaTotalEstados soma a renda mensal de todas as classes
+func rendaTotalEstados(estados map[string]*estadoClasse) float64 {
+	total := 0.0
+	for _, classe := range classesOrdenadas(estados) {
+		total += estados[classe].rendaMensal
+	}
+	return total
+}
+
+// patrimonioTotalEstados soma o valor de todas as classes
+func patrimonioTotalEstados(estados map[string]*estadoClasse) float64 {
+	total := 0.0
+	for _, classe := range classesOrdenadas(estados) {
+		total += estados[classe].valor
+	}
+	return total
+}
+
+// distribuirAporteEstados distribui o aporte entre as classes comprando ao yield corrente
+func distribuirAporteEstados(estados map[string]*estadoClasse, pesos map[string]float64, totalPesos, valorAportar float64) {
+	if valorAportar <= 0 || totalPesos <= 0 {
+		return
+	}
+
+	for classe, peso := range pesos {
+		estado, existe := estados[classe]
+		if !existe {
+			continue
+		}
+
+		valorClasse := valorAportar * (peso / totalPesos)
+		yieldCorrente := estado.yieldMensal
+		if estado.valor > 0 && estado.rendaMensal > 0 {
+			yieldCorrente = estado.rendaMensal / estado.valor
+		}
+
+		estado.valor += valorClasse
+		estado.rendaMensal += valorClasse * yieldCorrente
+	}
+}
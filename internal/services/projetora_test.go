@@ -0,0 +1,41 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"math"
+	"testing"
+)
+
+func TestMontarEstadosClassesUsaRendaDeCadaClasse(t *testing.T) {
+	premissas := map[string]config.PremissaClasse{
+		"ETFs":   {DividendYield: 12},
+		"BDRs":   {DividendYield: 6},
+		"Cripto": {DividendYield: 0},
+	}
+	dados := &models.TemplateDados{
+		ValoresFinais: map[string]float64{"FIIs": 10000, "ETFs": 5000, "BDRs": 2000},
+		// Sem renda estimada para FIIs, a projeção usa o DY da carteira final
+		CarteirasFinais: map[string][]models.AtivoCarteiraFinal{
+			"FIIs": {{Ticker: "HGLG11", ValorTotal: 10000, DividendosMensais: 80}},
+		},
+		// Qualquer classe do registro com renda estimada entra na projeção com ela
+		RendaPorClasse: map[string]models.ResumoRendaClasse{"ETFs": {MensalLiquida: 30}},
+	}
+
+	estados := montarEstadosClasses(premissas, dados)
+
+	esperadas := map[string]float64{
+		"FIIs": 80,
+		"ETFs": 30,
+		"BDRs": 2000 * 0.06 / 12,
+	}
+	for classe, esperada := range esperadas {
+		if math.Abs(estados[classe].rendaMensal-esperada) > 1e-9 {
+			t.Errorf("%s: esperava renda inicial %v, obteve %v", classe, esperada, estados[classe].rendaMensal)
+		}
+	}
+	if len(estados) != len(ClassesInvestimento) {
+		t.Fatalf("esperava um estado para cada uma das %d classes, obteve %d", len(ClassesInvestimento), len(estados))
+	}
+}
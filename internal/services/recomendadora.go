@@ -4,198 +4,147 @@ import (
 	"calculadora-investimentos/internal/models"
 	"log"
 	"sort"
-	"strconv"
-	"strings"
+	"time"
 )
 
 // RecomendadoraService gerencia as recomendações de investimentos
 type RecomendadoraService struct {
-	dataComService *DataComService
+	dataComService   *DataComService
+	emissorService   *EmissorService
+	setorService     *SetorService
+	precoTetoService *PrecoTetoService
 }
 
-// Atualize o construtor
-func NewRecomendadoraService() *RecomendadoraService {
+// NewRecomendadoraService cria um novo serviço de recomendação.
+// O serviço de data com é compartilhado com a otimizadora para aproveitar o mesmo cache.
+func NewRecomendadoraService(dataComService *DataComService, emissorService *EmissorService, setorService *SetorService, precoTetoService *PrecoTetoService) *RecomendadoraService {
 	return &RecomendadoraService{
-		dataComService: NewDataComService(),
+		dataComService:   dataComService,
+		emissorService:   emissorService,
+		setorService:     setorService,
+		precoTetoService: precoTetoService,
 	}
 }
 
-// GerarRecomendacoesFII gera recomendações de compra para FIIs
-func (s *RecomendadoraService) GerarRecomendacoesFII(
-	carteira *models.CarteiraDados,
-	recomendados []models.FIIRecomendado,
-	valorInvestimento, valorTotalCarteira float64,
-) []models.RecomendacaoCompraFII {
-	var recomendacoes []models.RecomendacaoCompraFII
-
-	// Valor total futuro da carteira
-	valorTotalFuturo := valorTotalCarteira + valorInvestimento
-
-	// Mapa para facilitar a busca de ativos na carteira
-	ativosNaCarteira := make(map[string]models.AtivoFII)
-	for _, ativo := range carteira.Data {
-		ativosNaCarteira[ativo.TickerName] = ativo
-	}
-
-	// Calcular o valor atual alocado em cada FII
-	valorAtualFII := make(map[string]float64)
-	for _, ativo := range carteira.Data {
-		valorAtualFII[ativo.TickerName] = ativo.CurrentPrice * float64(ativo.Quantity)
-	}
-
-	// Calcular o valor futuro ideal para cada FII com base nos pesos
-	valorIdealFII := make(map[string]float64)
-	for _, rec := range recomendados {
-		// Valor ideal baseado no peso
-		valorIdealFII[rec.Ticker] = (rec.PesoIdeal / 100) * valorTotalFuturo
-	}
-
-	// Calcular quanto precisa ser comprado de cada FII
-	valorCompraFII := make(map[string]float64)
-	for _, rec := range recomendados {
-		atual := valorAtualFII[rec.Ticker]
-		ideal := valorIdealFII[rec.Ticker]
-
-		// Só recomenda compra se o valor ideal for maior que o atual
-		if ideal > atual {
-			valorCompraFII[rec.Ticker] = ideal - atual
+// PreCarregarDataCom analisa em paralelo a data com de todos os recomendados das classes analisadas,
+// antes que a recomendação e a otimização das sobras consultem cada ticker individualmente
+func (s *RecomendadoraService) PreCarregarDataCom(classes []*ComprasClasse) {
+	var ativos []AtivoDataCom
+	for _, compras := range classes {
+		if compras.Classe.TipoDataCom == "" {
+			continue
 		}
-	}
-
-	// Verificar se o valor total de compra excede o disponível
-	valorTotalCompra := 0.0
-	for _, valor := range valorCompraFII {
-		valorTotalCompra += valor
-	}
-
-	// Se exceder, ajustar proporcionalmente
-	if valorTotalCompra > valorInvestimento {
-		fatorAjuste := valorInvestimento / valorTotalCompra
-		for ticker := range valorCompraFII {
-			valorCompraFII[ticker] *= fatorAjuste
+		for _, rec := range compras.Recomendados {
+			ativos = append(ativos, AtivoDataCom{Ticker: rec.Ticker, TipoAtivo: compras.Classe.TipoDataCom})
 		}
 	}
 
-	// Calcular a quantidade a ser comprada de cada FII
-	for _, rec := range recomendados {
-		valorCompra := valorCompraFII[rec.Ticker]
-
-		if valorCompra > 0 {
-			// Calcula quantidade a ser comprada (arredonda para baixo)
-			quantidadeCompra := int(valorCompra / rec.Preco)
-
-			// Recalcula o valor da compra com a quantidade ajustada
-			valorCompraAjustado := float64(quantidadeCompra) * rec.Preco
-
-			// Só recomenda se a quantidade for maior que zero
-			if quantidadeCompra > 0 {
-				// Calcula o peso atual
-				var pesoAtual float64
-				if ativo, existe := ativosNaCarteira[rec.Ticker]; existe {
-					pesoAtual = ativo.PercentWallet
-				}
-
-				// Calcula o peso após a compra
-				valorAtual := valorAtualFII[rec.Ticker]
-				pesoAposCompra := ((valorAtual + valorCompraAjustado) / valorTotalFuturo) * 100
-
-				recomendacao := models.RecomendacaoCompraFII{
-					Ticker:         rec.Ticker,
-					Nome:           rec.Nome,
-					Segmento:       rec.Segmento,
-					Tipo:           rec.Tipo,
-					Preco:          rec.Preco,
-					PesoAtual:      pesoAtual,
-					PesoIdeal:      rec.PesoIdeal,
-					Diferenca:      rec.PesoIdeal - pesoAtual,
-					Quantidade:     quantidadeCompra,
-					ValorCompra:    valorCompraAjustado,
-					PesoAposCompra: pesoAposCompra,
-				}
-
-				// ADICIONE ESTE CÓDIGO PARA ANÁLISE DE DATA COM
-				log.Printf("Analisando data com para FII: %s", rec.Ticker)
-				if analiseDataCom, err := s.dataComService.AnalisarDataComTicker(rec.Ticker, "FII"); err == nil {
-					if analiseDataCom != nil {
-						recomendacao.ProximaDataCom = analiseDataCom.ProximaDataCom.Format("02/01/2006")
-						recomendacao.DiasAteDataCom = analiseDataCom.DiasAteDataCom
-						recomendacao.StatusCompra = analiseDataCom.StatusCompra
-						recomendacao.MensagemStatus = analiseDataCom.MensagemStatus
-						log.Printf("Data com para %s: %s (Status: %s)", rec.Ticker, recomendacao.ProximaDataCom, recomendacao.StatusCompra)
-					}
-				} else {
-					log.Printf("Erro ao analisar data com para %s: %v", rec.Ticker, err)
-					recomendacao.StatusCompra = "INDISPONIVEL"
-					recomendacao.MensagemStatus = "Dados não disponíveis"
-				}
+	inicio := time.Now()
+	resultados := s.dataComService.AnalisarDataComTickers(ativos)
+	log.Printf("Análises de data com pré-carregadas: %d ativos em %v", len(resultados), time.Since(inicio))
+}
 
-				recomendacoes = append(recomendacoes, recomendacao)
+// AvaliarComprasDataCom reavalia o status de data com das recomendações com as quantidades
+// finais (após a otimização das sobras), já que a perda esperada depende de quantas cotas são compradas
+func (s *RecomendadoraService) AvaliarComprasDataCom(classes []*ComprasClasse) {
+	for _, compras := range classes {
+		if compras.Classe.TipoDataCom == "" {
+			continue
+		}
+		for i := range compras.Recomendacoes {
+			rec := &compras.Recomendacoes[i]
+			if analise, err := s.dataComService.AnalisarDataComTicker(rec.Ticker, compras.Classe.TipoDataCom); err == nil && analise != nil {
+				rec.StatusCompra, rec.MensagemStatus = s.dataComService.AvaliarCompra(analise, rec.Quantidade)
 			}
 		}
 	}
-
-	return recomendacoes
 }
 
-// GerarRecomendacoesAcao gera recomendações de compra para ações
-func (s *RecomendadoraService) GerarRecomendacoesAcao(
-	carteira *models.CarteiraAcoes,
-	recomendados []models.AcaoRecomendada,
+// GerarRecomendacoes gera recomendações de compra para uma classe de renda variável
+func (s *RecomendadoraService) GerarRecomendacoes(
+	classe models.Classe,
+	carteira []models.Ativo,
+	recomendados []models.AtivoRecomendado,
+	precosTeto map[string]models.PrecoTeto,
 	valorInvestimento, valorTotalCarteira float64,
-) []models.RecomendacaoCompraAcao {
-	var recomendacoes []models.RecomendacaoCompraAcao
+) []models.RecomendacaoCompra {
+	var recomendacoes []models.RecomendacaoCompra
 
 	// Valor total futuro da carteira
 	valorTotalFuturo := valorTotalCarteira + valorInvestimento
 
 	// Mapa para facilitar a busca de ativos na carteira
-	ativosNaCarteira := make(map[string]models.AtivoAcao)
-	for _, ativo := range carteira.Data {
-		ativosNaCarteira[ativo.TickerName] = ativo
+	ativosNaCarteira := make(map[string]models.Ativo)
+	for _, ativo := range carteira {
+		ativosNaCarteira[ativo.Ticker] = ativo
 	}
 
-	// Calcular o valor atual alocado em cada ação
-	valorAtualAcao := make(map[string]float64)
-	for _, ativo := range carteira.Data {
-		valorAtualAcao[ativo.TickerName] = ativo.CurrentPrice * float64(ativo.Quantity)
+	// Calcular o valor atual alocado em cada ativo
+	valorAtualAtivo := make(map[string]float64)
+	for _, ativo := range carteira {
+		if !ativo.SemCotacao {
+			valorAtualAtivo[ativo.Ticker] = ativo.Preco * float64(ativo.Quantidade)
+		}
 	}
 
-	// Calcular o valor futuro ideal para cada ação com base nos pesos
-	valorIdealAcao := make(map[string]float64)
+	// Calcular o valor futuro ideal para cada ativo com base nos pesos
+	valorIdealAtivo := make(map[string]float64)
 	for _, rec := range recomendados {
 		// Valor ideal baseado no peso
-		valorIdealAcao[rec.Ticker] = (rec.PesoIdeal / 100) * valorTotalFuturo
+		valorIdealAtivo[rec.Ticker] = (rec.PesoIdeal / 100) * valorTotalFuturo
 	}
 
-	// Calcular quanto precisa ser comprado de cada ação
-	valorCompraAcao := make(map[string]float64)
+	// Calcular quanto precisa ser comprado de cada ativo
+	valorCompraAtivo := make(map[string]float64)
 	for _, rec := range recomendados {
-		atual := valorAtualAcao[rec.Ticker]
-		ideal := valorIdealAcao[rec.Ticker]
+		atual := valorAtualAtivo[rec.Ticker]
+		ideal := valorIdealAtivo[rec.Ticker]
 
 		// Só recomenda compra se o valor ideal for maior que o atual
 		if ideal > atual {
-			valorCompraAcao[rec.Ticker] = ideal - atual
+			valorCompraAtivo[rec.Ticker] = ideal - atual
 		}
 	}
 
+	// Limitar a concentração por emissor, somando todos os tickers da mesma empresa
+	if limite := s.emissorService.LimiteEmissor(classe, valorTotalFuturo); limite > 0 {
+		limitarComprasPorGrupo(carteira, valorCompraAtivo, s.emissorService.Emissor, func(string) float64 { return limite })
+	}
+
+	// Limitar a concentração por setor
+	if classe.LimitarPorSetor {
+		limitarComprasPorGrupo(carteira, valorCompraAtivo, s.setorService.Setor, func(setor string) float64 {
+			return s.setorService.LimiteSetor(classe, setor, valorTotalFuturo)
+		})
+	}
+
 	// Verificar se o valor total de compra excede o disponível
 	valorTotalCompra := 0.0
-	for _, valor := range valorCompraAcao {
+	for _, valor := range valorCompraAtivo {
 		valorTotalCompra += valor
 	}
 
 	// Se exceder, ajustar proporcionalmente
 	if valorTotalCompra > valorInvestimento {
 		fatorAjuste := valorInvestimento / valorTotalCompra
-		for ticker := range valorCompraAcao {
-			valorCompraAcao[ticker] *= fatorAjuste
+		for ticker := range valorCompraAtivo {
+			valorCompraAtivo[ticker] *= fatorAjuste
 		}
 	}
 
-	// Calcular a quantidade a ser comprada de cada ação
+	// Pular ou reduzir as compras de ativos cotados acima do preço-teto depois do ajuste proporcional,
+	// para que o valor cortado não seja repassado aos demais recomendados da classe e fique nas sobras
+	valorReduzido := make(map[string]bool)
+	for ticker, valor := range valorCompraAtivo {
+		if precosTeto[ticker].AcimaDoTeto {
+			valorCompraAtivo[ticker] = s.precoTetoService.AjustarCompra(valor)
+			valorReduzido[ticker] = true
+		}
+	}
+
+	// Calcular a quantidade a ser comprada de cada ativo
 	for _, rec := range recomendados {
-		valorCompra := valorCompraAcao[rec.Ticker]
+		valorCompra := valorCompraAtivo[rec.Ticker]
 
 		if valorCompra > 0 {
 			// Calcula quantidade a ser comprada (arredonda para baixo)
@@ -209,16 +158,18 @@ func (s *RecomendadoraService) GerarRecomendacoesAcao(
 				// Calcula o peso atual
 				var pesoAtual float64
 				if ativo, existe := ativosNaCarteira[rec.Ticker]; existe {
-					pesoAtual = ativo.PercentWallet
+					pesoAtual = ativo.PercentualCarteira
 				}
 
 				// Calcula o peso após a compra
-				valorAtual := valorAtualAcao[rec.Ticker]
+				valorAtual := valorAtualAtivo[rec.Ticker]
 				pesoAposCompra := ((valorAtual + valorCompraAjustado) / valorTotalFuturo) * 100
 
-				recomendacao := models.RecomendacaoCompraAcao{
+				recomendacao := models.RecomendacaoCompra{
 					Ticker:         rec.Ticker,
 					Nome:           rec.Nome,
+					Segmento:       rec.Segmento,
+					Tipo:           rec.Tipo,
 					Preco:          rec.Preco,
 					PesoAtual:      pesoAtual,
 					PesoIdeal:      rec.PesoIdeal,
@@ -228,20 +179,12 @@ func (s *RecomendadoraService) GerarRecomendacoesAcao(
 					PesoAposCompra: pesoAposCompra,
 				}
 
-				// ADICIONE ESTE CÓDIGO PARA ANÁLISE DE DATA COM
-				log.Printf("Analisando data com para Ação: %s", rec.Ticker)
-				if analiseDataCom, err := s.dataComService.AnalisarDataComTicker(rec.Ticker, "ACAO"); err == nil {
-					if analiseDataCom != nil {
-						recomendacao.ProximaDataCom = analiseDataCom.ProximaDataCom.Format("02/01/2006")
-						recomendacao.DiasAteDataCom = analiseDataCom.DiasAteDataCom
-						recomendacao.StatusCompra = analiseDataCom.StatusCompra
-						recomendacao.MensagemStatus = analiseDataCom.MensagemStatus
-						log.Printf("Data com para %s: %s (Status: %s)", rec.Ticker, recomendacao.ProximaDataCom, recomendacao.StatusCompra)
-					}
-				} else {
-					log.Printf("Erro ao analisar data com para %s: %v", rec.Ticker, err)
-					recomendacao.StatusCompra = "INDISPONIVEL"
-					recomendacao.MensagemStatus = "Dados não disponíveis"
+				preencherPrecoTeto(&recomendacao, precosTeto)
+				recomendacao.CompraReduzida = valorReduzido[rec.Ticker]
+
+				if classe.TipoDataCom != "" {
+					log.Printf("Analisando data com para %s: %s", classe.Descricao, rec.Ticker)
+					preencherDataCom(s.dataComService, &recomendacao, classe.TipoDataCom)
 				}
 
 				recomendacoes = append(recomendacoes, recomendacao)
@@ -252,389 +195,122 @@ func (s *RecomendadoraService) GerarRecomendacoesAcao(
 	return recomendacoes
 }
 
-// GerarRecomendacoesETF gera recomendações de compra para ETFs
-func (s *RecomendadoraService) GerarRecomendacoesETF(
-	carteira *models.CarteiraETFs,
-	recomendados []models.ETFRecomendado,
-	valorInvestimento, valorTotalCarteira float64,
-) []models.RecomendacaoCompraETF {
-	var recomendacoes []models.RecomendacaoCompraETF
-
-	// Valor total futuro da carteira
-	valorTotalFuturo := valorTotalCarteira + valorInvestimento
-
-	// Mapa para facilitar a busca de ativos na carteira
-	ativosNaCarteira := make(map[string]models.AtivoETF)
-	for _, ativo := range carteira.Data {
-		ativosNaCarteira[ativo.TickerName] = ativo
-	}
-
-	// Calcular o valor atual alocado em cada ETF
-	valorAtualETF := make(map[string]float64)
-	for _, ativo := range carteira.Data {
-		currentPrice, err := strconv.ParseFloat(ativo.CurrentPrice, 64)
-		if err == nil {
-			valorAtualETF[ativo.TickerName] = currentPrice * float64(ativo.Quantity)
-		}
-	}
-
-	// Calcular o valor futuro ideal para cada ETF com base nos pesos
-	valorIdealETF := make(map[string]float64)
-	for _, rec := range recomendados {
-		// Valor ideal baseado no peso
-		valorIdealETF[rec.Ticker] = (rec.PesoIdeal / 100) * valorTotalFuturo
+// preencherDataCom preenche a próxima data com e o status de compra da recomendação
+func preencherDataCom(dataComService *DataComService, recomendacao *models.RecomendacaoCompra, tipoAtivo string) {
+	analiseDataCom, err := dataComService.AnalisarDataComTicker(recomendacao.Ticker, tipoAtivo)
+	if err != nil {
+		log.Printf("Erro ao analisar data com para %s: %v", recomendacao.Ticker, err)
+		recomendacao.StatusCompra = "INDISPONIVEL"
+		recomendacao.MensagemStatus = "Dados não disponíveis"
+		return
+	}
+	if analiseDataCom != nil {
+		recomendacao.ProximaDataCom = analiseDataCom.ProximaDataCom.Format("02/01/2006")
+		recomendacao.DiasAteDataCom = analiseDataCom.DiasAteDataCom
+		recomendacao.StatusCompra = analiseDataCom.StatusCompra
+		recomendacao.MensagemStatus = analiseDataCom.MensagemStatus
+		log.Printf("Data com para %s: %s (Status: %s)", recomendacao.Ticker, recomendacao.ProximaDataCom, recomendacao.StatusCompra)
 	}
+}
 
-	// Calcular quanto precisa ser comprado de cada ETF
-	valorCompraETF := make(map[string]float64)
-	for _, rec := range recomendados {
-		atual := valorAtualETF[rec.Ticker]
-		ideal := valorIdealETF[rec.Ticker]
-
-		// Só recomenda compra se o valor ideal for maior que o atual
-		if ideal > atual {
-			valorCompraETF[rec.Ticker] = ideal - atual
-		}
-	}
-
-	// Verificar se o valor total de compra excede o disponível
-	valorTotalCompra := 0.0
-	for _, valor := range valorCompraETF {
-		valorTotalCompra += valor
-	}
-
-	// Se exceder, ajustar proporcionalmente
-	// Se exceder, ajustar proporcionalmente
-	if valorTotalCompra > valorInvestimento {
-		fatorAjuste := valorInvestimento / valorTotalCompra
-		for ticker := range valorCompraETF {
-			valorCompraETF[ticker] *= fatorAjuste
-		}
-	}
-
-	// Calcular a quantidade a ser comprada de cada ETF
-	for _, rec := range recomendados {
-		valorCompra := valorCompraETF[rec.Ticker]
-
-		if valorCompra > 0 {
-			// Calcula quantidade a ser comprada (arredonda para baixo)
-			quantidadeCompra := int(valorCompra / rec.Preco)
-
-			// Recalcula o valor da compra com a quantidade ajustada
-			valorCompraAjustado := float64(quantidadeCompra) * rec.Preco
-
-			// Só recomenda se a quantidade for maior que zero
-			if quantidadeCompra > 0 {
-				// Calcula o peso atual
-				var pesoAtual float64
-				if ativo, existe := ativosNaCarteira[rec.Ticker]; existe {
-					pesoAtual = ativo.PercentWallet
-				}
-
-				// Calcula o peso após a compra
-				valorAtual := valorAtualETF[rec.Ticker]
-				pesoAposCompra := ((valorAtual + valorCompraAjustado) / valorTotalFuturo) * 100
-
-				recomendacao := models.RecomendacaoCompraETF{
-					Ticker:         rec.Ticker,
-					Nome:           rec.Nome,
-					Preco:          rec.Preco,
-					PesoAtual:      pesoAtual,
-					PesoIdeal:      rec.PesoIdeal,
-					Diferenca:      rec.PesoIdeal - pesoAtual,
-					Quantidade:     quantidadeCompra,
-					ValorCompra:    valorCompraAjustado,
-					PesoAposCompra: pesoAposCompra,
-				}
-
-				recomendacoes = append(recomendacoes, recomendacao)
-			}
-		}
+// preencherPrecoTeto copia o preço-teto e a margem de segurança do ativo para a recomendação
+func preencherPrecoTeto(recomendacao *models.RecomendacaoCompra, precosTeto map[string]models.PrecoTeto) {
+	if avaliacao, existe := precosTeto[recomendacao.Ticker]; existe {
+		recomendacao.PrecoTeto = avaliacao.PrecoTeto
+		recomendacao.MargemSeguranca = avaliacao.MargemSeguranca
 	}
-
-	return recomendacoes
 }
 
-// ObterCarteiraFinalFII obtém a carteira final de FIIs após as compras sugeridas
-func (s *RecomendadoraService) ObterCarteiraFinalFII(
-	carteira *models.CarteiraDados,
-	recomendacoes []models.RecomendacaoCompraFII,
-	recomendados []models.FIIRecomendado,
+// ObterCarteiraFinal obtém a carteira final de uma classe de renda variável após as compras sugeridas
+func (s *RecomendadoraService) ObterCarteiraFinal(
+	classe models.Classe,
+	carteira []models.Ativo,
+	recomendacoes []models.RecomendacaoCompra,
+	recomendados []models.AtivoRecomendado,
 	valorTotalCarteira, valorTotalRecomendado float64,
-) []models.FIICarteiraFinal {
+) []models.AtivoCarteiraFinal {
 	// Mapa para facilitar a busca de ativos na carteira
-	ativosNaCarteira := make(map[string]*models.AtivoFII)
-	for i := range carteira.Data {
-		ativosNaCarteira[carteira.Data[i].TickerName] = &carteira.Data[i]
+	ativosNaCarteira := make(map[string]bool)
+	for _, ativo := range carteira {
+		ativosNaCarteira[ativo.Ticker] = true
 	}
 
 	// Mapa para facilitar a busca de informações de recomendações
-	infoRecomendados := make(map[string]models.FIIRecomendado)
+	infoRecomendados := make(map[string]models.AtivoRecomendado)
 	for _, rec := range recomendados {
 		infoRecomendados[rec.Ticker] = rec
 	}
 
-	// Lista para armazenar os FIIs da carteira final
-	var carteiraFinal []models.FIICarteiraFinal
+	// Lista para armazenar os ativos da carteira final
+	var carteiraFinal []models.AtivoCarteiraFinal
 
-	// Primeiro, adiciona os FIIs que já estão na carteira
-	for _, ativo := range carteira.Data {
-		dy, _ := strconv.ParseFloat(strings.TrimSuffix(ativo.DY, "%"), 64)
-		pvp, _ := strconv.ParseFloat(strings.Replace(ativo.PVP, ",", ".", -1), 64)
+	// Primeiro, adiciona os ativos que já estão na carteira
+	for _, ativo := range carteira {
+		if ativo.SemCotacao {
+			continue
+		}
 
-		// Verifica se há compra adicional deste FII
-		quantidade := ativo.Quantity
+		// Verifica se há compra adicional deste ativo
+		quantidade := ativo.Quantidade
 		for _, rec := range recomendacoes {
-			if rec.Ticker == ativo.TickerName {
+			if rec.Ticker == ativo.Ticker {
 				quantidade += rec.Quantidade
 				break
 			}
 		}
 
-		valorTotal := float64(quantidade) * ativo.CurrentPrice
+		valorTotal := float64(quantidade) * ativo.Preco
 
 		// Calcula dividendos mensais (DY anual / 12 * valor total)
-		dividendosMensais := (dy / 100 / 12) * valorTotal
+		var dividendosMensais float64
+		if classe.DividendosPorDY {
+			dividendosMensais = (ativo.DY / 100 / 12) * valorTotal
+		}
 
 		// Verifica se tem peso ideal nas recomendações
 		var pesoIdeal float64
-		if rec, existe := infoRecomendados[ativo.TickerName]; existe {
+		if rec, existe := infoRecomendados[ativo.Ticker]; existe {
 			pesoIdeal = rec.PesoIdeal
 		}
 
-		fii := models.FIICarteiraFinal{
-			Ticker:            ativo.TickerName,
-			Nome:              ativo.TickerName, // Poderia buscar o nome completo em recomendados
-			Segmento:          ativo.Segment,
-			Tipo:              ativo.FiiType,
-			Preco:             ativo.CurrentPrice,
-			DY:                dy,
-			PVP:               pvp,
+		carteiraFinal = append(carteiraFinal, models.AtivoCarteiraFinal{
+			Ticker:            ativo.Ticker,
+			Nome:              ativo.Ticker, // Poderia buscar o nome completo em recomendados
+			Segmento:          ativo.Segmento,
+			Tipo:              ativo.Tipo,
+			Preco:             ativo.Preco,
+			PL:                ativo.PL,
+			PVP:               ativo.PVP,
+			DY:                ativo.DY,
 			Quantidade:        quantidade,
 			ValorTotal:        valorTotal,
 			Peso:              0, // Será calculado depois
 			DividendosMensais: dividendosMensais,
 			PesoIdeal:         pesoIdeal,
-		}
-
-		carteiraFinal = append(carteiraFinal, fii)
+		})
 	}
 
-	// Adiciona os novos FIIs que não estavam na carteira original
+	// Adiciona os novos ativos que não estavam na carteira original
 	for _, rec := range recomendacoes {
 		// Verifica se já existe na carteira
-		if _, existe := ativosNaCarteira[rec.Ticker]; !existe {
+		if !ativosNaCarteira[rec.Ticker] {
 			valorTotal := float64(rec.Quantidade) * rec.Preco
 
-			fii := models.FIICarteiraFinal{
+			carteiraFinal = append(carteiraFinal, models.AtivoCarteiraFinal{
 				Ticker:            rec.Ticker,
 				Nome:              rec.Nome,
 				Segmento:          rec.Segmento,
 				Tipo:              rec.Tipo,
 				Preco:             rec.Preco,
+				PL:                rec.PL,
+				PVP:               rec.PVP,
+				DY:                rec.DY,
 				Quantidade:        rec.Quantidade,
 				ValorTotal:        valorTotal,
 				Peso:              0, // Será calculado depois
 				DividendosMensais: 0,
 				PesoIdeal:         rec.PesoIdeal,
-			}
-
-			carteiraFinal = append(carteiraFinal, fii)
-		}
-	}
-
-	// Calcula o valor total da carteira final
-	valorTotalFinal := valorTotalCarteira + valorTotalRecomendado
-
-	// Calcula os pesos na carteira final
-	for i := range carteiraFinal {
-		carteiraFinal[i].Peso = (carteiraFinal[i].ValorTotal / valorTotalFinal) * 100
-	}
-
-	// Ordena a carteira final por peso (do maior para o menor)
-	sort.Slice(carteiraFinal, func(i, j int) bool {
-		return carteiraFinal[i].Peso > carteiraFinal[j].Peso
-	})
-
-	return carteiraFinal
-}
-
-// ObterCarteiraFinalAcao obtém a carteira final de ações após as compras sugeridas
-func (s *RecomendadoraService) ObterCarteiraFinalAcao(
-	carteira *models.CarteiraAcoes,
-	recomendacoes []models.RecomendacaoCompraAcao,
-	recomendados []models.AcaoRecomendada,
-	valorTotalCarteira, valorTotalRecomendado float64,
-) []models.AcaoCarteiraFinal {
-	// Mapa para facilitar a busca de ativos na carteira
-	ativosNaCarteira := make(map[string]*models.AtivoAcao)
-	for i := range carteira.Data {
-		ativosNaCarteira[carteira.Data[i].TickerName] = &carteira.Data[i]
-	}
-
-	// Mapa para facilitar a busca de informações de recomendações
-	infoRecomendados := make(map[string]models.AcaoRecomendada)
-	for _, rec := range recomendados {
-		infoRecomendados[rec.Ticker] = rec
-	}
-
-	// Lista para armazenar as ações da carteira final
-	var carteiraFinal []models.AcaoCarteiraFinal
-
-	// Primeiro, adiciona as ações que já estão na carteira
-	for _, ativo := range carteira.Data {
-		dy, _ := strconv.ParseFloat(strings.TrimSuffix(ativo.DY, "%"), 64)
-		pvp, _ := strconv.ParseFloat(strings.Replace(ativo.PVP, ",", ".", -1), 64)
-		pl, _ := strconv.ParseFloat(strings.Replace(ativo.PL, ",", ".", -1), 64)
-
-		// Verifica se há compra adicional desta ação
-		quantidade := ativo.Quantity
-		for _, rec := range recomendacoes {
-			if rec.Ticker == ativo.TickerName {
-				quantidade += rec.Quantidade
-				break
-			}
-		}
-
-		valorTotal := float64(quantidade) * ativo.CurrentPrice
-
-		// Verifica se tem peso ideal nas recomendações
-		var pesoIdeal float64
-		if rec, existe := infoRecomendados[ativo.TickerName]; existe {
-			pesoIdeal = rec.PesoIdeal
-		}
-
-		acao := models.AcaoCarteiraFinal{
-			Ticker:     ativo.TickerName,
-			Nome:       ativo.TickerName, // Poderia buscar o nome completo em recomendados
-			Preco:      ativo.CurrentPrice,
-			PL:         pl,
-			PVP:        pvp,
-			DY:         dy,
-			Quantidade: quantidade,
-			ValorTotal: valorTotal,
-			Peso:       0, // Será calculado depois
-			PesoIdeal:  pesoIdeal,
-		}
-
-		carteiraFinal = append(carteiraFinal, acao)
-	}
-
-	// Adiciona as novas ações que não estavam na carteira original
-	for _, rec := range recomendacoes {
-		// Verifica se já existe na carteira
-		if _, existe := ativosNaCarteira[rec.Ticker]; !existe {
-			valorTotal := float64(rec.Quantidade) * rec.Preco
-
-			acao := models.AcaoCarteiraFinal{
-				Ticker:     rec.Ticker,
-				Nome:       rec.Nome,
-				Preco:      rec.Preco,
-				PL:         rec.PL,
-				PVP:        rec.PVP,
-				DY:         rec.DY,
-				Quantidade: rec.Quantidade,
-				ValorTotal: valorTotal,
-				Peso:       0, // Será calculado depois
-				PesoIdeal:  rec.PesoIdeal,
-			}
-
-			carteiraFinal = append(carteiraFinal, acao)
-		}
-	}
-
-	// Calcula o valor total da carteira final
-	valorTotalFinal := valorTotalCarteira + valorTotalRecomendado
-
-	// Calcula os pesos na carteira final
-	for i := range carteiraFinal {
-		carteiraFinal[i].Peso = (carteiraFinal[i].ValorTotal / valorTotalFinal) * 100
-	}
-
-	// Ordena a carteira final por peso (do maior para o menor)
-	sort.Slice(carteiraFinal, func(i, j int) bool {
-		return carteiraFinal[i].Peso > carteiraFinal[j].Peso
-	})
-
-	return carteiraFinal
-}
-
-// ObterCarteiraFinalETF obtém a carteira final de ETFs após as compras sugeridas
-func (s *RecomendadoraService) ObterCarteiraFinalETF(
-	carteira *models.CarteiraETFs,
-	recomendacoes []models.RecomendacaoCompraETF,
-	recomendados []models.ETFRecomendado,
-	valorTotalCarteira, valorTotalRecomendado float64,
-) []models.ETFCarteiraFinal {
-	// Mapa para facilitar a busca de ativos na carteira
-	ativosNaCarteira := make(map[string]*models.AtivoETF)
-	for i := range carteira.Data {
-		ativosNaCarteira[carteira.Data[i].TickerName] = &carteira.Data[i]
-	}
-
-	// Mapa para facilitar a busca de informações de recomendações
-	infoRecomendados := make(map[string]models.ETFRecomendado)
-	for _, rec := range recomendados {
-		infoRecomendados[rec.Ticker] = rec
-	}
-
-	// Lista para armazenar os ETFs da carteira final
-	var carteiraFinal []models.ETFCarteiraFinal
-
-	// Primeiro, adiciona os ETFs que já estão na carteira
-	for _, ativo := range carteira.Data {
-		currentPrice, err := strconv.ParseFloat(ativo.CurrentPrice, 64)
-		if err != nil {
-			continue
-		}
-
-		// Verifica se há compra adicional deste ETF
-		quantidade := ativo.Quantity
-		for _, rec := range recomendacoes {
-			if rec.Ticker == ativo.TickerName {
-				quantidade += rec.Quantidade
-				break
-			}
-		}
-
-		valorTotal := float64(quantidade) * currentPrice
-
-		// Verifica se tem peso ideal nas recomendações
-		var pesoIdeal float64
-		if rec, existe := infoRecomendados[ativo.TickerName]; existe {
-			pesoIdeal = rec.PesoIdeal
-		}
-
-		etf := models.ETFCarteiraFinal{
-			Ticker:     ativo.TickerName,
-			Nome:       ativo.TickerName, // Poderia buscar o nome completo em recomendados
-			Preco:      currentPrice,
-			Quantidade: quantidade,
-			ValorTotal: valorTotal,
-			Peso:       0, // Será calculado depois
-			PesoIdeal:  pesoIdeal,
-		}
-
-		carteiraFinal = append(carteiraFinal, etf)
-	}
-
-	// Adiciona os novos ETFs que não estavam na carteira original
-	for _, rec := range recomendacoes {
-		// Verifica se já existe na carteira
-		if _, existe := ativosNaCarteira[rec.Ticker]; !existe {
-			valorTotal := float64(rec.Quantidade) * rec.Preco
-
-			etf := models.ETFCarteiraFinal{
-				Ticker:     rec.Ticker,
-				Nome:       rec.Nome,
-				Preco:      rec.Preco,
-				Quantidade: rec.Quantidade,
-				ValorTotal: valorTotal,
-				Peso:       0, // Será calculado depois
-				PesoIdeal:  rec.PesoIdeal,
-			}
-
-			carteiraFinal = append(carteiraFinal, etf)
+			})
 		}
 	}
 
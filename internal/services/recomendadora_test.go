@@ -0,0 +1,164 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/cache"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Os arquivos em testdata/classes_golden guardam as recomendações e carteiras finais geradas pelas
+// funções específicas de FIIs, ações e ETFs anteriores ao modelo genérico de classes. O modelo
+// genérico deve reproduzir os mesmos campos; os campos novos devem ficar vazios.
+
+type entradaGolden struct {
+	ValorInvestimento  float64                   `json:"valorInvestimento"`
+	ValorTotalCarteira float64                   `json:"valorTotalCarteira"`
+	Carteira           json.RawMessage           `json:"carteira"`
+	Recomendados       []models.AtivoRecomendado `json:"recomendados"`
+}
+
+type saidaGolden struct {
+	Recomendacoes []map[string]any `json:"recomendacoes"`
+	CarteiraFinal []map[string]any `json:"carteiraFinal"`
+}
+
+func lerJSONGolden(t *testing.T, nome string, destino any) {
+	t.Helper()
+	dados, err := os.ReadFile(filepath.Join("testdata", "classes_golden", nome))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(dados, destino); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// recomendadoraGolden monta a recomendadora com as análises de data com já em cache: FIIs e ações
+// em alerta, exceto o terceiro recomendado de ações, cuja análise falhou
+func recomendadoraGolden(t *testing.T, entradas map[string]entradaGolden) *RecomendadoraService {
+	t.Helper()
+	cfg := config.Load()
+	cacheInstancia := cache.GetInstance(cfg.CacheDuracao, cfg.CacheLimpeza)
+	hoje := time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)
+	analise := &AnaliseDataCom{
+		ProximaDataCom: time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC),
+		DiasAteDataCom: 3,
+		StatusCompra:   "ALERTA",
+		MensagemStatus: "Data com em 3 pregões",
+	}
+
+	preencher := func(tipoAtivo string, recomendados []models.AtivoRecomendado, falha int) {
+		for i, rec := range recomendados {
+			resultado := resultadoAnaliseDataCom{analise: analise}
+			if i == falha {
+				resultado = resultadoAnaliseDataCom{err: errors.New("página indisponível")}
+			}
+			chave := chaveAnaliseDataCom(rec.Ticker, tipoAtivo, hoje)
+			cacheInstancia.Set(chave, resultado, time.Hour)
+			t.Cleanup(func() { cacheInstancia.Delete(chave) })
+		}
+	}
+	preencher("FII", entradas["fii"].Recomendados, -1)
+	preencher("ACAO", entradas["acao"].Recomendados, 2)
+
+	dataCom := NewDataComService(cfg, cacheInstancia, relogio.Fixo{Momento: hoje})
+	return NewRecomendadoraService(dataCom, NewEmissorService(cfg, nil, nil), NewSetorService(cfg, nil), NewPrecoTetoService(cfg, nil, nil, relogio.Fixo{Momento: hoje}))
+}
+
+// conferirGolden compara cada item com o esperado: os campos do arquivo devem ter o mesmo valor e
+// os demais campos devem estar zerados
+func conferirGolden(t *testing.T, descricao string, obtidos any, esperados []map[string]any) {
+	t.Helper()
+	dados, err := json.Marshal(obtidos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var itens []map[string]any
+	if err := json.Unmarshal(dados, &itens); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(itens) != len(esperados) {
+		t.Fatalf("%s: esperava %d itens, obteve %d", descricao, len(esperados), len(itens))
+	}
+	for i, item := range itens {
+		for campo, valor := range item {
+			esperado, existe := esperados[i][campo]
+			if !existe {
+				if !reflect.ValueOf(valor).IsZero() {
+					t.Errorf("%s[%d].%s: campo novo deveria estar vazio, obteve %v", descricao, i, campo, valor)
+				}
+				continue
+			}
+			if numero, ok := valor.(float64); ok {
+				if math.Abs(numero-esperado.(float64)) > 1e-9 {
+					t.Errorf("%s[%d].%s: esperava %v, obteve %v", descricao, i, campo, esperado, numero)
+				}
+				continue
+			}
+			if valor != esperado {
+				t.Errorf("%s[%d].%s: esperava %v, obteve %v", descricao, i, campo, esperado, valor)
+			}
+		}
+		for campo := range esperados[i] {
+			if _, existe := item[campo]; !existe {
+				t.Errorf("%s[%d]: campo %s ausente", descricao, i, campo)
+			}
+		}
+	}
+}
+
+func TestRecomendacoesIguaisAsDasClassesAnteriores(t *testing.T) {
+	var entradas map[string]entradaGolden
+	var saidas map[string]saidaGolden
+	lerJSONGolden(t, "entradas.json", &entradas)
+	lerJSONGolden(t, "saida.json", &saidas)
+
+	servico := recomendadoraGolden(t, entradas)
+
+	casos := []struct {
+		chave    string
+		classe   models.Classe
+		carteira func(json.RawMessage) []models.Ativo
+	}{
+		{"fii", ClasseFII, func(dados json.RawMessage) []models.Ativo {
+			var carteira models.CarteiraDados
+			json.Unmarshal(dados, &carteira)
+			return AtivosFII(&carteira)
+		}},
+		{"acao", ClasseAcao, func(dados json.RawMessage) []models.Ativo {
+			var carteira models.CarteiraAcoes
+			json.Unmarshal(dados, &carteira)
+			return AtivosAcao(&carteira)
+		}},
+		{"etf", ClasseETF, func(dados json.RawMessage) []models.Ativo {
+			var carteira models.CarteiraETFs
+			json.Unmarshal(dados, &carteira)
+			return AtivosETF(&carteira)
+		}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.chave, func(t *testing.T) {
+			entrada := entradas[caso.chave]
+			carteira := caso.carteira(entrada.Carteira)
+
+			recomendacoes := servico.GerarRecomendacoes(caso.classe, carteira, entrada.Recomendados, nil,
+				entrada.ValorInvestimento, entrada.ValorTotalCarteira)
+			carteiraFinal := servico.ObterCarteiraFinal(caso.classe, carteira, recomendacoes, entrada.Recomendados,
+				entrada.ValorTotalCarteira, somarCompras(recomendacoes))
+
+			conferirGolden(t, "recomendacoes", recomendacoes, saidas[caso.chave].Recomendacoes)
+			conferirGolden(t, "carteiraFinal", carteiraFinal, saidas[caso.chave].CarteiraFinal)
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/b3"
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"calculadora-investimentos/internal/relogio"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiasUteisPorAno é a convenção de dias úteis usada na capitalização da renda fixa brasileira
+const DiasUteisPorAno = 252
+
+// tabelaIOF contém a alíquota (%) de IOF sobre o rendimento para resgates do 1º ao 29º dia
+var tabelaIOF = []float64{
+	96, 93, 90, 86, 83, 80, 76, 73, 70, 66,
+	63, 60, 56, 53, 50, 46, 43, 40, 36, 33,
+	30, 26, 23, 20, 16, 13, 10, 6, 3,
+}
+
+// RendaFixaService avalia as posições de renda fixa até o vencimento
+type RendaFixaService struct {
+	Config     *config.Config
+	Calendario *b3.Calendario
+	Relogio    relogio.Relogio
+}
+
+// NewRendaFixaService cria um novo serviço de avaliação de renda fixa
+func NewRendaFixaService(cfg *config.Config, rel relogio.Relogio) *RendaFixaService {
+	return &RendaFixaService{
+		Config:     cfg,
+		Calendario: b3.GetInstance(cfg.DiretorioFeriados),
+		Relogio:    rel,
+	}
+}
+
+// AvaliarCarteira projeta o valor bruto e líquido no vencimento de cada título da carteira
+func (s *RendaFixaService) AvaliarCarteira(carteira *models.CarteiraRendaFixa) *models.ResumoRendaFixa {
+	resumo := &models.ResumoRendaFixa{
+		PremissaCDI:   s.Config.TaxaCDIAnual,
+		PremissaSelic: s.Config.TaxaSelicAnual,
+		PremissaIPCA:  s.Config.TaxaIPCAAnual,
+	}
+	if carteira == nil {
+		return resumo
+	}
+
+	hoje := s.Relogio.Agora()
+	for _, ativo := range carteira.Data {
+		posicao := s.AvaliarPosicao(ativo, hoje)
+		resumo.Posicoes = append(resumo.Posicoes, posicao)
+		resumo.ValorAtualTotal += posicao.ValorAtual
+		resumo.ValorBrutoVencimentoTotal += posicao.ValorBrutoVencimento
+		resumo.ValorLiquidoVencimentoTotal += posicao.ValorLiquidoVencimento
+	}
+	resumo.FGC = s.AnalisarFGC(resumo.Posicoes)
+	resumo.Escada = s.MontarEscadaVencimentos(resumo.Posicoes)
+
+	return resumo
+}
+
+// AvaliarPosicao interpreta os campos do título e projeta seu valor no vencimento.
+// O valor atual é capitalizado pela taxa do indexador por dias úteis (base 252); o IR segue a
+// tabela regressiva pelo prazo desde a aplicação e o IOF incide em prazos inferiores a 30 dias.
+func (s *RendaFixaService) AvaliarPosicao(ativo models.AtivoRendaFixa, hoje time.Time) models.PosicaoRendaFixa {
+	posicao := models.PosicaoRendaFixa{
+		Nome:           ativo.Name,
+		Tipo:           ativo.InvestmentType,
+		Emissor:        ativo.Emitter,
+		Conglomerado:   s.conglomerado(ativo.Emitter),
+		CobertoFGC:     s.cobertoFGC(ativo.InvestmentType),
+		Isento:         s.tituloIsento(ativo.InvestmentType),
+		LiquidezDiaria: ativo.DailyLiquidity == 1,
+	}
+
+	posicao.ValorAtual, _ = converterNumero(ativo.EquityTotal)
+	posicao.ValorAplicado, _ = converterNumero(ativo.Applied)
+	if posicao.ValorAplicado <= 0 {
+		posicao.ValorAplicado = posicao.ValorAtual
+	}
+	// Sem projeção possível, o título permanece pelo valor atual
+	posicao.ValorBrutoVencimento = posicao.ValorAtual
+	posicao.ValorLiquidoVencimento = posicao.ValorAtual
+
+	vencimento, err := converterData(ativo.DueDate)
+	if err != nil {
+		posicao.Erro = "Data de vencimento inválida"
+		return posicao
+	}
+	posicao.DataVencimento = vencimento.Format("02/01/2006")
+
+	aplicacao, err := converterData(ativo.Buy)
+	if err == nil {
+		posicao.DataAplicacao = aplicacao.Format("02/01/2006")
+	} else {
+		// Sem data de aplicação, considera o prazo a partir de hoje (alíquotas mais conservadoras)
+		aplicacao = hoje
+	}
+
+	taxaBruta, indexador, descricao, err := s.taxaAnualProjetada(ativo)
+	if err != nil {
+		posicao.Erro = err.Error()
+		return posicao
+	}
+	posicao.Indexador = indexador
+	posicao.DescricaoTaxa = descricao
+	posicao.TaxaBrutaAnual = taxaBruta
+
+	posicao.DiasUteisAteVencimento = s.Calendario.DiasDePregaoEntre(hoje, vencimento)
+	if posicao.DiasUteisAteVencimento <= 0 {
+		posicao.Vencido = true
+		posicao.DiasUteisAteVencimento = 0
+		return posicao
+	}
+
+	fator := math.Pow(1+taxaBruta/100, float64(posicao.DiasUteisAteVencimento)/DiasUteisPorAno)
+	posicao.ValorBrutoVencimento = posicao.ValorAtual * fator
+
+	// Tributos sobre o rendimento total desde a aplicação
+	rendimento := posicao.ValorBrutoVencimento - posicao.ValorAplicado
+	if rendimento > 0 {
+		diasCorridos := int(vencimento.Sub(aplicacao).Hours() / 24)
+		posicao.ValorIOF = rendimento * AliquotaIOF(diasCorridos) / 100
+		if !posicao.Isento {
+			posicao.AliquotaIR = AliquotaIRRegressiva(diasCorridos)
+			posicao.ValorIR = (rendimento - posicao.ValorIOF) * posicao.AliquotaIR / 100
+		}
+	}
+	posicao.ValorLiquidoVencimento = posicao.ValorBrutoVencimento - posicao.ValorIOF - posicao.ValorIR
+
+	if posicao.ValorAtual > 0 {
+		anos := float64(posicao.DiasUteisAteVencimento) / DiasUteisPorAno
+		posicao.TaxaLiquidaAnual = (math.Pow(posicao.ValorLiquidoVencimento/posicao.ValorAtual, 1/anos) - 1) * 100
+	}
+
+	return posicao
+}
+
+// RecomendarAplicacoes divide o valor destinado à renda fixa entre os títulos recomendados
+// conforme os pesos ideais. A parcela com liquidez diária é reforçada até que a carteira de renda
+// fixa (atual mais o aporte) atinja o percentual e a reserva mínimos configurados, e títulos cuja fatia fique
+// abaixo da aplicação mínima são descartados, com o valor redistribuído entre os demais.
+// Títulos cobertos pelo FGC são limitados para que nenhum conglomerado ultrapasse a garantia.
+// Retorna as recomendações e o valor que não pôde ser alocado em nenhum título.
+func (s *RendaFixaService) RecomendarAplicacoes(
+	valor float64,
+	carteira *models.CarteiraRendaFixa,
+	recomendados []models.RendaFixaRecomendada,
+) ([]models.RecomendacaoCompraRendaFixa, float64) {
+	if valor <= 0 || len(recomendados) == 0 {
+		return nil, valor
+	}
+
+	necessidadeLiquidez := s.necessidadeLiquidez(valor, carteira)
+	capacidades, capacidadeGlobal := s.capacidadeFGC(carteira)
+
+	var candidatos []int
+	for i, titulo := range recomendados {
+		if titulo.PesoIdeal > 0 {
+			candidatos = append(candidatos, i)
+		}
+	}
+
+	// Descartar, um a um, o título mais distante da aplicação mínima até que todos a respeitem
+	var alocacao map[int]float64
+	var limitados map[int]bool
+	for len(candidatos) > 0 {
+		alocacao = distribuirRendaFixa(valor, necessidadeLiquidez, recomendados, candidatos)
+		limitados = s.limitarExposicaoFGC(alocacao, recomendados, candidatos, capacidades, capacidadeGlobal)
+
+		pior, piorRazao := -1, 1.0
+		for posicao, i := range candidatos {
+			if razao := alocacao[i] / recomendados[i].AplicacaoMinima; razao < piorRazao {
+				pior, piorRazao = posicao, razao
+			}
+		}
+		if pior < 0 {
+			break
+		}
+		candidatos = append(candidatos[:pior], candidatos[pior+1:]...)
+	}
+
+	// Nenhuma combinação respeita as aplicações mínimas: usar o título de menor mínimo que caiba no valor,
+	// dando preferência aos de liquidez diária
+	if len(candidatos) == 0 {
+		escolhido := -1
+		for i, titulo := range recomendados {
+			if titulo.AplicacaoMinima > valor {
+				continue
+			}
+			// O título precisa comportar ao menos a aplicação mínima dentro da garantia
+			if s.cobertoFGC(titulo.Tipo) && !s.comportaNoFGC(titulo, titulo.AplicacaoMinima, capacidades, capacidadeGlobal) {
+				continue
+			}
+			if escolhido < 0 {
+				escolhido = i
+				continue
+			}
+			atual := recomendados[escolhido]
+			if (titulo.LiquidezDiaria && !atual.LiquidezDiaria) ||
+				(titulo.LiquidezDiaria == atual.LiquidezDiaria && titulo.AplicacaoMinima < atual.AplicacaoMinima) {
+				escolhido = i
+			}
+		}
+		if escolhido < 0 {
+			return nil, valor
+		}
+		alocacao = map[int]float64{escolhido: valor}
+		candidatos = []int{escolhido}
+		limitados = s.limitarExposicaoFGC(alocacao, recomendados, candidatos, capacidades, capacidadeGlobal)
+	}
+
+	hoje := s.Relogio.Agora()
+	var recomendacoes []models.RecomendacaoCompraRendaFixa
+	valorAlocado := 0.0
+	for _, i := range candidatos {
+		titulo := recomendados[i]
+		valorCompra := alocacao[i]
+		if valorCompra <= 0 {
+			continue
+		}
+
+		projecao := s.AvaliarPosicao(tituloComoAtivo(titulo, valorCompra, hoje), hoje)
+		recomendacoes = append(recomendacoes, models.RecomendacaoCompraRendaFixa{
+			Nome:             titulo.Nome,
+			Tipo:             titulo.Tipo,
+			Emissor:          titulo.Emissor,
+			Conglomerado:     projecao.Conglomerado,
+			DescricaoTaxa:    projecao.DescricaoTaxa,
+			Vencimento:       titulo.Vencimento,
+			LiquidezDiaria:   titulo.LiquidezDiaria,
+			AplicacaoMinima:  titulo.AplicacaoMinima,
+			PesoIdeal:        titulo.PesoIdeal,
+			ValorCompra:      valorCompra,
+			Percentual:       valorCompra / valor * 100,
+			TaxaLiquidaAnual: projecao.TaxaLiquidaAnual,
+			LimitadoPeloFGC:  limitados[i],
+		})
+		valorAlocado += valorCompra
+	}
+
+	return recomendacoes, valor - valorAlocado
+}
+
+// RecomendarReserva divide o aporte da reserva de emergência entre os títulos recomendados
+// com liquidez diária, pelos mesmos critérios de RecomendarAplicacoes
+func (s *RendaFixaService) RecomendarReserva(
+	valor float64,
+	carteira *models.CarteiraRendaFixa,
+	recomendados []models.RendaFixaRecomendada,
+) ([]models.RecomendacaoCompraRendaFixa, float64) {
+	var liquidos []models.RendaFixaRecomendada
+	for _, titulo := range recomendados {
+		if titulo.LiquidezDiaria {
+			liquidos = append(liquidos, titulo)
+		}
+	}
+	return s.RecomendarAplicacoes(valor, carteira, liquidos)
+}
+
+// necessidadeLiquidez calcula quanto do aporte precisa ir para títulos com liquidez diária
+// para que a carteira de renda fixa atinja o percentual mínimo e a reserva mínima configurados
+func (s *RendaFixaService) necessidadeLiquidez(valor float64, carteira *models.CarteiraRendaFixa) float64 {
+	valorAtual, valorLiquido := 0.0, 0.0
+	if carteira != nil {
+		for _, ativo := range carteira.Data {
+			valorAtivo, _ := converterNumero(ativo.EquityTotal)
+			valorAtual += valorAtivo
+			if ativo.DailyLiquidity == 1 {
+				valorLiquido += valorAtivo
+			}
+		}
+	}
+
+	necessidade := s.metaLiquidez(valorAtual+valor) - valorLiquido
+	return math.Max(0, math.Min(valor, necessidade))
+}
+
+// distribuirRendaFixa reparte o valor entre os candidatos pelos pesos ideais, garantindo ao
+// grupo com liquidez diária pelo menos o valor da necessidade de liquidez
+func distribuirRendaFixa(valor, necessidadeLiquidez float64, recomendados []models.RendaFixaRecomendada, candidatos []int) map[int]float64 {
+	pesoLiquidos, pesoDemais := 0.0, 0.0
+	for _, i := range candidatos {
+		if recomendados[i].LiquidezDiaria {
+			pesoLiquidos += recomendados[i].PesoIdeal
+		} else {
+			pesoDemais += recomendados[i].PesoIdeal
+		}
+	}
+
+	// Valor de cada grupo: proporcional aos pesos, salvo se a liquidez exigir mais
+	valorLiquidos := valor * pesoLiquidos / (pesoLiquidos + pesoDemais)
+	if pesoLiquidos > 0 && valorLiquidos < necessidadeLiquidez {
+		valorLiquidos = necessidadeLiquidez
+	}
+	if pesoDemais == 0 {
+		valorLiquidos = valor
+	}
+	valorDemais := valor - valorLiquidos
+
+	alocacao := make(map[int]float64)
+	for _, i := range candidatos {
+		if recomendados[i].LiquidezDiaria {
+			alocacao[i] = valorLiquidos * recomendados[i].PesoIdeal / pesoLiquidos
+		} else {
+			alocacao[i] = valorDemais * recomendados[i].PesoIdeal / pesoDemais
+		}
+	}
+	return alocacao
+}
+
+// tituloComoAtivo monta um AtivoRendaFixa equivalente a uma aplicação hoje no título recomendado
+func tituloComoAtivo(titulo models.RendaFixaRecomendada, valor float64, hoje time.Time) models.AtivoRendaFixa {
+	ativo := models.AtivoRendaFixa{
+		Name:           titulo.Nome,
+		InvestmentType: titulo.Tipo,
+		Emitter:        titulo.Emissor,
+		Indexer:        titulo.Indexador,
+		EquityTotal:    strconv.FormatFloat(valor, 'f', 2, 64),
+		Applied:        strconv.FormatFloat(valor, 'f', 2, 64),
+		DueDate:        titulo.Vencimento,
+		Buy:            hoje.Format("2006-01-02"),
+	}
+	if titulo.Indexador == models.IndexadorCDI {
+		ativo.PercentageCDI = strconv.FormatFloat(titulo.Taxa, 'f', -1, 64)
+	} else {
+		ativo.PercentageYear = strconv.FormatFloat(titulo.Taxa, 'f', -1, 64)
+	}
+	if titulo.LiquidezDiaria {
+		ativo.DailyLiquidity = 1
+	}
+	return ativo
+}
+
+// taxaAnualProjetada calcula a taxa bruta anual (%) do título sob as premissas configuradas
+func (s *RendaFixaService) taxaAnualProjetada(ativo models.AtivoRendaFixa) (float64, string, string, error) {
+	percentualCDI, _ := converterNumero(ativo.PercentageCDI)
+	taxaAno, _ := converterNumero(ativo.PercentageYear)
+
+	switch identificarIndexador(ativo.Indexer, ativo.RateType, percentualCDI) {
+	case models.IndexadorCDI:
+		if percentualCDI > 0 {
+			return s.Config.TaxaCDIAnual * percentualCDI / 100, models.IndexadorCDI,
+				fmt.Sprintf("%s%% do CDI", formatarPercentual(percentualCDI)), nil
+		}
+		return compor(s.Config.TaxaCDIAnual, taxaAno), models.IndexadorCDI,
+			fmt.Sprintf("CDI + %s%%", formatarPercentual(taxaAno)), nil
+	case models.IndexadorSelic:
+		return compor(s.Config.TaxaSelicAnual, taxaAno), models.IndexadorSelic,
+			fmt.Sprintf("Selic + %s%%", formatarPercentual(taxaAno)), nil
+	case models.IndexadorIPCA:
+		return compor(s.Config.TaxaIPCAAnual, taxaAno), models.IndexadorIPCA,
+			fmt.Sprintf("IPCA + %s%%", formatarPercentual(taxaAno)), nil
+	case models.IndexadorPrefixado:
+		return taxaAno, models.IndexadorPrefixado,
+			fmt.Sprintf("%s%% a.a.", formatarPercentual(taxaAno)), nil
+	}
+
+	return 0, "", "", fmt.Errorf("Indexador não reconhecido: %q", ativo.Indexer)
+}
+
+// tituloIsento indica se o tipo do título é isento de IR para pessoa física
+func (s *RendaFixaService) tituloIsento(tipo string) bool {
+	tipoNormalizado := normalizarCabecalho(tipo)
+	for _, isento := range s.Config.TiposIsentosIR {
+		if strings.HasPrefix(tipoNormalizado, normalizarCabecalho(isento)) {
+			return true
+		}
+	}
+	return false
+}
+
+// identificarIndexador classifica o título a partir do indexador, do tipo de taxa e do percentual do CDI
+func identificarIndexador(indexador, tipoTaxa string, percentualCDI float64) string {
+	texto := normalizarCabecalho(indexador + " " + tipoTaxa)
+
+	switch {
+	case strings.Contains(texto, "ipca"):
+		return models.IndexadorIPCA
+	case strings.Contains(texto, "selic"):
+		return models.IndexadorSelic
+	case strings.Contains(texto, "cdi"), percentualCDI > 0:
+		return models.IndexadorCDI
+	case strings.Contains(texto, "pre"):
+		return models.IndexadorPrefixado
+	}
+	return ""
+}
+
+// AliquotaIRRegressiva retorna a alíquota (%) de IR da renda fixa conforme o prazo em dias corridos
+func AliquotaIRRegressiva(diasCorridos int) float64 {
+	switch {
+	case diasCorridos <= 180:
+		return 22.5
+	case diasCorridos <= 360:
+		return 20.0
+	case diasCorridos <= 720:
+		return 17.5
+	default:
+		return 15.0
+	}
+}
+
+// AliquotaIOF retorna a alíquota (%) de IOF sobre o rendimento conforme os dias corridos desde a aplicação
+func AliquotaIOF(diasCorridos int) float64 {
+	if diasCorridos < 1 {
+		return tabelaIOF[0]
+	}
+	if diasCorridos > len(tabelaIOF) {
+		return 0
+	}
+	return tabelaIOF[diasCorridos-1]
+}
+
+// compor soma um spread (% a.a.) a uma taxa base de forma composta
+func compor(taxaBase, spread float64) float64 {
+	return ((1+taxaBase/100)*(1+spread/100) - 1) * 100
+}
+
+// converterNumero interpreta números nos formatos "1234.56", "1.234,56" e "110%"
+func converterNumero(texto string) (float64, error) {
+	texto = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(texto), "%"))
+	if texto == "" {
+		return 0, fmt.Errorf("valor vazio")
+	}
+	if strings.Contains(texto, ",") {
+		return converterValorDecimal(texto)
+	}
+	return strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(texto, "R$")), 64)
+}
+
+// converterData interpreta as datas nos formatos usados pela API da carteira
+func converterData(texto string) (time.Time, error) {
+	texto = strings.TrimSpace(texto)
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2006-01-02 15:04:05", time.RFC3339} {
+		if data, err := time.ParseInLocation(layout, texto, time.Local); err == nil {
+			return data, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data inválida: %q", texto)
+}
+
+// formatarPercentual formata um percentual com vírgula decimal (ex: 6,50)
+func formatarPercentual(valor float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f", valor), ".", ",", 1)
+}
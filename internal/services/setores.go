@@ -0,0 +1,93 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"strings"
+)
+
+// Setor atribuído às ações ausentes de data/setores_acoes.txt
+const setorNaoClassificado = "Não classificado"
+
+// SetorService classifica as ações por setor e subsetor e aplica os limites de concentração setorial
+type SetorService struct {
+	Config  *config.Config
+	Setores map[string]models.SetorAcao // Ticker ou raiz de 4 letras -> classificação
+}
+
+// NewSetorService cria um novo serviço de setores
+func NewSetorService(cfg *config.Config, setores map[string]models.SetorAcao) *SetorService {
+	return &SetorService{Config: cfg, Setores: setores}
+}
+
+// Classificacao retorna o setor e o subsetor do ticker: os cadastrados para o ticker, os cadastrados
+// para a raiz de 4 letras ou, na falta de ambos, "Não classificado"
+func (s *SetorService) Classificacao(ticker string) models.SetorAcao {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if setor, existe := s.Setores[ticker]; existe {
+		return setor
+	}
+	if len(ticker) > 4 {
+		if setor, existe := s.Setores[ticker[:4]]; existe {
+			return setor
+		}
+	}
+	return models.SetorAcao{Setor: setorNaoClassificado, Subsetor: setorNaoClassificado}
+}
+
+// Setor retorna o setor do ticker
+func (s *SetorService) Setor(ticker string) string {
+	return s.Classificacao(ticker).Setor
+}
+
+// LimiteSetor retorna o valor máximo (R$) do setor na classe, dado o valor da classe após o aporte.
+// O limite específico do setor prevalece sobre o padrão. Zero indica que o setor não tem limite;
+// ações não classificadas nunca são limitadas.
+func (s *SetorService) LimiteSetor(classe models.Classe, setor string, valorTotalFuturo float64) float64 {
+	if !classe.LimitarPorSetor || setor == setorNaoClassificado {
+		return 0
+	}
+	percentual := s.Config.LimiteSetorAcoes
+	if especifico, existe := s.Config.LimitePorSetorAcoes[setor]; existe {
+		percentual = especifico
+	}
+	if percentual <= 0 {
+		return 0
+	}
+	return percentual / 100 * valorTotalFuturo
+}
+
+// AnalisarSetores agrega a carteira final de ações por setor e por subsetor, no mesmo formato da
+// análise de tipos e segmentos dos FIIs. Setores com limite configurado trazem também o limite em %.
+func (s *SetorService) AnalisarSetores(carteiraFinal []models.AtivoCarteiraFinal, valorTotal float64) (map[string]map[string]float64, map[string]map[string]float64) {
+	setores := make(map[string]map[string]float64)
+	subsetores := make(map[string]map[string]float64)
+
+	acumular := func(grupos map[string]map[string]float64, chave string, ativo models.AtivoCarteiraFinal) {
+		if _, existe := grupos[chave]; !existe {
+			grupos[chave] = make(map[string]float64)
+		}
+		grupos[chave]["valor"] += ativo.ValorTotal
+	}
+
+	for _, ativo := range carteiraFinal {
+		acumular(setores, ativo.Setor, ativo)
+		acumular(subsetores, ativo.Subsetor, ativo)
+	}
+
+	for _, grupos := range []map[string]map[string]float64{setores, subsetores} {
+		for _, dados := range grupos {
+			if valorTotal > 0 {
+				dados["percentual"] = dados["valor"] / valorTotal * 100
+			}
+		}
+	}
+
+	for setor, dados := range setores {
+		if limite := s.LimiteSetor(ClasseAcao, setor, 100); limite > 0 {
+			dados["limite"] = limite
+		}
+	}
+
+	return setores, subsetores
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"calculadora-investimentos/internal/config"
+	"calculadora-investimentos/internal/models"
+	"strings"
+)
+
+// TributacaoService aplica as regras de imposto de renda sobre os proventos
+type TributacaoService struct {
+	Config *config.Config
+}
+
+// NewTributacaoService cria um novo serviço de tributação de proventos
+func NewTributacaoService(cfg *config.Config) *TributacaoService {
+	return &TributacaoService{
+		Config: cfg,
+	}
+}
+
+// FIIIsento indica se os rendimentos do FII são isentos de IR para pessoa física.
+// A isenção depende de critérios do fundo (cotas negociadas em bolsa, número de cotistas etc.),
+// por isso pode ser definida por ticker na configuração.
+func (s *TributacaoService) FIIIsento(ticker string) bool {
+	if isento, existe := s.Config.IsencaoIRFIIPorTicker[strings.ToUpper(ticker)]; existe {
+		return isento
+	}
+	return s.Config.IsencaoIRFIIPadrao
+}
+
+// AliquotaFII retorna a alíquota (%) aplicada aos rendimentos do FII
+func (s *TributacaoService) AliquotaFII(ticker string) float64 {
+	if s.FIIIsento(ticker) {
+		return 0
+	}
+	return s.Config.AliquotaIRRendimentosFII
+}
+
+// AliquotaProvento retorna a alíquota (%) aplicada a um provento de ação conforme o tipo
+func (s *TributacaoService) AliquotaProvento(tipo string) float64 {
+	if tipo == models.TipoProventoJCP {
+		return s.Config.AliquotaIRJCP
+	}
+	return s.Config.AliquotaIRDividendos
+}
+
+// ValorLiquido desconta a alíquota (%) de um valor bruto
+func ValorLiquido(valorBruto, aliquota float64) float64 {
+	return valorBruto * (1 - aliquota/100)
+}
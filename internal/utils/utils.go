@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
@@ -139,6 +140,20 @@ func GetTemplateFuncs() template.FuncMap {
 		"calcularDiferenca": func(final, inicial float64) float64 {
 			return final - inicial
 		},
+		"mod": func(a, b int) int {
+			if b == 0 {
+				return 0
+			}
+			return a % b
+		},
+		"toJSON": func(value interface{}) string {
+			dados, err := json.Marshal(value)
+			if err != nil {
+				log.Printf("ERRO: Função 'toJSON' não conseguiu serializar o valor: %v", err)
+				return "null"
+			}
+			return string(dados)
+		},
 		"calcularPercentual": func(final, inicial float64) float64 {
 			if inicial <= 0 {
 				return 0
@@ -17,6 +17,9 @@ func main() {
 	mux.HandleFunc("/", handlers.IndexHandler)
 	mux.HandleFunc("/static/", handlers.StaticHandler)
 	mux.HandleFunc("/calcular", handlers.CalcularHandler)
+	mux.HandleFunc("/planejar", handlers.PlanejarHandler)
+	mux.HandleFunc("/calendario", handlers.CalendarioHandler)
+	mux.HandleFunc("/agenda.ics", handlers.AgendaICSHandler)
 	mux.HandleFunc("/status-cache", handlers.StatusCacheHandler) // Nova rota para verificar o status do cache
 
 	// Iniciar servidor
@@ -25,6 +25,8 @@ function scrollToElement(elementId) {
 // Event listeners
 document.addEventListener("DOMContentLoaded", function () {
   setupForm();
+  setupPlannerForm();
+  setupCalendarForm();
   setupNavigation();
   setupThemeToggle();
 });
@@ -106,6 +108,40 @@ function setupForm() {
         console.log("Tipos de investimento selecionados:", tiposSelecionados);
       }
 
+      // Parâmetros opcionais da projeção de longo prazo
+      formData.append(
+        "aporteMensalProjecao",
+        document.getElementById("projection-monthly").value.trim()
+      );
+      formData.append(
+        "anosProjecao",
+        document.getElementById("projection-years").value
+      );
+      formData.append(
+        "metaRendaMensal",
+        document.getElementById("projection-target").value.trim()
+      );
+      formData.append(
+        "reinvestirDividendos",
+        document.getElementById("projection-reinvest").checked
+      );
+
+      // Reserva de emergência opcional
+      formData.append(
+        "despesasMensais",
+        document.getElementById("emergency-expenses").value.trim()
+      );
+      formData.append(
+        "reservaEmergencia",
+        document.getElementById("emergency-target").value.trim()
+      );
+
+      // Data de referência opcional (AAAA-MM-DD)
+      const referenceDate = document.getElementById("reference-date");
+      if (referenceDate && referenceDate.value) {
+        formData.append("dataReferencia", referenceDate.value);
+      }
+
       // Enviar a requisição usando fetch com FormData
       fetch("/calcular", {
         method: "POST",
@@ -291,7 +327,216 @@ function setupFormDistribuicao() {
             "warning"
           );
         }
+        atualizarPercentuaisTipos(checkboxesTipos);
       });
     });
+    atualizarPercentuaisTipos(checkboxesTipos);
+  }
+}
+
+// Mostra, em cada tipo, o percentual que ele recebe com a seleção atual: os pesos
+// configurados são redistribuídos entre os tipos marcados, como no cálculo
+function atualizarPercentuaisTipos(checkboxesTipos) {
+  const pesoDe = (checkbox) => {
+    const badge = checkbox.parentElement.querySelector(".percentual-tipo");
+    return badge ? parseFloat(badge.dataset.peso) || 0 : 0;
+  };
+
+  const total = Array.from(checkboxesTipos)
+    .filter((cb) => cb.checked)
+    .reduce((soma, cb) => soma + pesoDe(cb), 0);
+
+  checkboxesTipos.forEach((checkbox) => {
+    const badge = checkbox.parentElement.querySelector(".percentual-tipo");
+    if (!badge) {
+      return;
+    }
+    const percentual = checkbox.checked && total > 0 ? (pesoDe(checkbox) / total) * 100 : 0;
+    badge.textContent = percentual.toFixed(2).replace(".", ",") + "%";
+  });
+}
+
+// Configuração do formulário de planejamento de aportes
+function setupPlannerForm() {
+  const form = document.getElementById("planner-form");
+  if (!form) {
+    return;
+  }
+
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+
+    const aporteMensal = document.getElementById("monthly-amount").value.trim();
+    const meses = document.getElementById("planner-months").value;
+
+    if (aporteMensal === "") {
+      showAlert("Por favor, insira o valor do aporte mensal.", "danger");
+      return;
+    }
+
+    const loadingIndicator = document.getElementById("loading-indicator");
+    loadingIndicator.classList.remove("d-none");
+
+    const resultsContainer = document.getElementById("results-container");
+    resultsContainer.innerHTML = "";
+
+    const formData = new FormData();
+    formData.append("aporteMensal", aporteMensal);
+    formData.append("meses", meses);
+
+    // Reaproveitar a seleção de tipos da calculadora
+    const personalizada = document.getElementById("distribuicao-personalizada");
+    if (personalizada && personalizada.checked) {
+      const tiposSelecionados = Array.from(
+        document.querySelectorAll(".tipo-investimento:checked")
+      ).map((checkbox) => checkbox.value);
+
+      formData.append("tiposInvestimento", JSON.stringify(tiposSelecionados));
+      formData.append("distribuicaoPersonalizada", "true");
+    }
+
+    fetch("/planejar", {
+      method: "POST",
+      body: formData,
+    })
+      .then((response) => response.json())
+      .then((data) => {
+        loadingIndicator.classList.add("d-none");
+
+        if (data.status === "success") {
+          resultsContainer.innerHTML = data.dados_html;
+          scrollToElement("results");
+          initBootstrapComponents();
+        } else {
+          showAlert(data.message, "danger");
+        }
+      })
+      .catch((error) => {
+        loadingIndicator.classList.add("d-none");
+        console.error("Erro na requisição de planejamento:", error);
+        showAlert(
+          `Erro ao processar o planejamento: ${error.message}`,
+          "danger"
+        );
+      });
+  });
+}
+
+// Configuração do formulário do calendário de proventos
+function setupCalendarForm() {
+  const form = document.getElementById("calendar-form");
+  if (!form) {
+    return;
   }
+
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+
+    const meses = document.getElementById("calendar-months").value;
+
+    const loadingIndicator = document.getElementById("loading-indicator");
+    loadingIndicator.classList.remove("d-none");
+
+    const resultsContainer = document.getElementById("results-container");
+    resultsContainer.innerHTML = "";
+
+    fetch(`/calendario?meses=${encodeURIComponent(meses)}`)
+      .then((response) => response.json())
+      .then((data) => {
+        loadingIndicator.classList.add("d-none");
+
+        if (data.status === "success") {
+          resultsContainer.innerHTML = data.dados_html;
+          scrollToElement("results");
+          initBootstrapComponents();
+        } else {
+          showAlert(data.message, "danger");
+        }
+      })
+      .catch((error) => {
+        loadingIndicator.classList.add("d-none");
+        console.error("Erro na requisição do calendário:", error);
+        showAlert(`Erro ao gerar o calendário: ${error.message}`, "danger");
+      });
+  });
 }
+
+// Gráficos ativos, destruídos antes de cada reinicialização
+let graficosAtivos = [];
+
+// Inicializar os gráficos de linha a partir dos atributos data-* dos canvas
+window.initCharts = function () {
+  graficosAtivos.forEach((grafico) => grafico.destroy());
+  graficosAtivos = [];
+
+  if (typeof Chart === "undefined") {
+    return;
+  }
+
+  const corTexto = document.body.classList.contains("dark-mode")
+    ? "#e0e0e0"
+    : "#333333";
+
+  // Gráficos de faixas de percentis (Monte Carlo)
+  const coresPercentis = { P10: "#dc3545", P50: "#0d6efd", P90: "#198754" };
+  document.querySelectorAll("canvas.grafico-bandas").forEach((canvas) => {
+    const rotulos = JSON.parse(canvas.dataset.rotulos || "[]");
+    const series = JSON.parse(canvas.dataset.series || "{}");
+
+    graficosAtivos.push(
+      new Chart(canvas, {
+        type: "line",
+        data: {
+          labels: rotulos,
+          datasets: ["P10", "P50", "P90"]
+            .filter((percentil) => series[percentil])
+            .map((percentil) => ({
+              label: percentil,
+              data: series[percentil],
+              borderColor: coresPercentis[percentil],
+              fill: false,
+              pointRadius: 0,
+            })),
+        },
+        options: {
+          plugins: { legend: { labels: { color: corTexto } } },
+          scales: {
+            x: { ticks: { color: corTexto, maxTicksLimit: 12 } },
+            y: { ticks: { color: corTexto } },
+          },
+        },
+      })
+    );
+  });
+
+  document.querySelectorAll("canvas.grafico-linha").forEach((canvas) => {
+    const rotulos = JSON.parse(canvas.dataset.rotulos || "[]");
+    const serie = JSON.parse(canvas.dataset.serie || "[]");
+
+    graficosAtivos.push(
+      new Chart(canvas, {
+        type: "line",
+        data: {
+          labels: rotulos,
+          datasets: [
+            {
+              label: canvas.dataset.titulo,
+              data: serie,
+              borderColor: "#0d6efd",
+              backgroundColor: "rgba(13, 110, 253, 0.1)",
+              fill: true,
+              pointRadius: 0,
+            },
+          ],
+        },
+        options: {
+          plugins: { legend: { labels: { color: corTexto } } },
+          scales: {
+            x: { ticks: { color: corTexto, maxTicksLimit: 12 } },
+            y: { ticks: { color: corTexto } },
+          },
+        },
+      })
+    );
+  });
+};